@@ -28,7 +28,60 @@ type Accumulator interface {
 		tags map[string]string,
 		t ...time.Time)
 
+	// AddSummary is the same as AddFields, but will add the metric as a
+	// "Summary" type, see telegraf.Summary for the expected fields.
+	AddSummary(measurement string,
+		fields map[string]interface{},
+		tags map[string]string,
+		t ...time.Time)
+
+	// AddHistogram is the same as AddFields, but will add the metric as a
+	// "Histogram" type, see telegraf.Histogram for the expected fields.
+	AddHistogram(measurement string,
+		fields map[string]interface{},
+		tags map[string]string,
+		t ...time.Time)
+
+	// AddMetric adds a metric to the accumulator.
+	AddMetric(Metric)
+
 	SetPrecision(precision, interval time.Duration)
 
 	AddError(err error)
+
+	// WithTracking upgrades to a TrackingAccumulator with space for maxTracked
+	// metric groups that have not been delivered yet.
+	WithTracking(maxTracked int) TrackingAccumulator
+}
+
+// TrackingID uniquely identifies a tracked metric group.
+type TrackingID uint64
+
+// DeliveryInfo provides the results of a delivered metric group.
+type DeliveryInfo interface {
+	// ID is the TrackingID of the metric group.
+	ID() TrackingID
+
+	// Delivered returns true if every metric of the group was accepted by
+	// all outputs, or was dropped on purpose along the way.
+	Delivered() bool
+}
+
+// TrackingAccumulator is an Accumulator that signals when a metric group has
+// been fully processed. Service inputs use it to acknowledge messages
+// upstream only after the outputs accepted them.
+//
+// The DeliveryInfo channel has room for maxTracked results, so the input must
+// not have more undelivered groups outstanding than that and must keep
+// reading from Delivered.
+type TrackingAccumulator interface {
+	Accumulator
+
+	// AddTrackingMetricGroup adds a group of metrics to the accumulator and
+	// returns the TrackingID that is reported once all of them are delivered.
+	AddTrackingMetricGroup(group []Metric) TrackingID
+
+	// Delivered returns a channel that receives the result of each tracked
+	// metric group.
+	Delivered() <-chan DeliveryInfo
 }
@@ -5,6 +5,8 @@ import (
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/models"
+	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/selfstat"
 )
 
@@ -76,6 +78,41 @@ func (ac *accumulator) AddCounter(
 	}
 }
 
+func (ac *accumulator) AddSummary(
+	measurement string,
+	fields map[string]interface{},
+	tags map[string]string,
+	t ...time.Time,
+) {
+	if m := ac.maker.MakeMetric(measurement, fields, tags, telegraf.Summary, ac.getTime(t)); m != nil {
+		ac.metrics <- m
+	}
+}
+
+func (ac *accumulator) AddHistogram(
+	measurement string,
+	fields map[string]interface{},
+	tags map[string]string,
+	t ...time.Time,
+) {
+	if m := ac.maker.MakeMetric(measurement, fields, tags, telegraf.Histogram, ac.getTime(t)); m != nil {
+		ac.metrics <- m
+	}
+}
+
+func (ac *accumulator) AddMetric(m telegraf.Metric) {
+	if m := ac.makeMetric(m); m != nil {
+		ac.metrics <- m
+	}
+}
+
+// makeMetric runs an already created metric through the MetricMaker, so it
+// gets the same treatment as the metrics added with AddFields.
+func (ac *accumulator) makeMetric(m telegraf.Metric) telegraf.Metric {
+	return ac.maker.MakeMetric(m.Name(), m.Fields(), m.Tags(), m.Type(),
+		ac.getTime([]time.Time{m.Time()}))
+}
+
 // AddError passes a runtime error to the accumulator.
 // The error will be tagged with the plugin name and written to the log.
 func (ac *accumulator) AddError(err error) {
@@ -83,6 +120,9 @@ func (ac *accumulator) AddError(err error) {
 		return
 	}
 	NErrors.Incr(1)
+	if input, ok := ac.maker.(*models.RunningInput); ok {
+		input.GatherErrors.Incr(1)
+	}
 	//TODO suppress/throttle consecutive duplicate errors?
 	log.Printf("E! Error in plugin [%s]: %s", ac.maker.Name(), err)
 }
@@ -108,6 +148,13 @@ func (ac *accumulator) SetPrecision(precision, interval time.Duration) {
 	}
 }
 
+func (ac *accumulator) WithTracking(maxTracked int) telegraf.TrackingAccumulator {
+	return &trackingAccumulator{
+		accumulator: ac,
+		delivered:   make(chan telegraf.DeliveryInfo, maxTracked),
+	}
+}
+
 func (ac accumulator) getTime(t []time.Time) time.Time {
 	var timestamp time.Time
 	if len(t) > 0 {
@@ -117,3 +164,39 @@ func (ac accumulator) getTime(t []time.Time) time.Time {
 	}
 	return timestamp.Round(ac.precision)
 }
+
+type trackingAccumulator struct {
+	*accumulator
+	delivered chan telegraf.DeliveryInfo
+}
+
+func (a *trackingAccumulator) AddTrackingMetricGroup(group []telegraf.Metric) telegraf.TrackingID {
+	metrics := make([]telegraf.Metric, 0, len(group))
+	for _, m := range group {
+		if m := a.makeMetric(m); m != nil {
+			metrics = append(metrics, m)
+		}
+	}
+
+	metrics, id := metric.WithGroupTracking(metrics, a.onDelivery)
+	for _, m := range metrics {
+		a.metrics <- m
+	}
+	return id
+}
+
+func (a *trackingAccumulator) Delivered() <-chan telegraf.DeliveryInfo {
+	return a.delivered
+}
+
+func (a *trackingAccumulator) onDelivery(info telegraf.DeliveryInfo) {
+	select {
+	case a.delivered <- info:
+	default:
+		// This is a programming error in the input, it has more undelivered
+		// metric groups outstanding than it asked for.
+		log.Printf("E! Delivery notification dropped for plugin [%s], "+
+			"more than the maximum number of metrics are undelivered",
+			a.maker.Name())
+	}
+}
@@ -309,6 +309,36 @@ func TestAddCounter(t *testing.T) {
 	assert.Equal(t, testm.Type(), telegraf.Counter)
 }
 
+func TestAddHistogram(t *testing.T) {
+	metrics := make(chan telegraf.Metric, 10)
+	defer close(metrics)
+	a := NewAccumulator(&TestMetricMaker{}, metrics)
+
+	a.AddHistogram("acctest",
+		map[string]interface{}{"0.5": float64(1), "+Inf": float64(3),
+			"count": float64(3), "sum": float64(4.5)},
+		map[string]string{})
+
+	testm := <-metrics
+	assert.Equal(t, telegraf.Histogram, testm.Type())
+	assert.Equal(t, float64(3), testm.Fields()["+Inf"])
+}
+
+func TestAddSummary(t *testing.T) {
+	metrics := make(chan telegraf.Metric, 10)
+	defer close(metrics)
+	a := NewAccumulator(&TestMetricMaker{}, metrics)
+
+	a.AddSummary("acctest",
+		map[string]interface{}{"0.99": float64(2), "count": float64(3),
+			"sum": float64(4.5)},
+		map[string]string{})
+
+	testm := <-metrics
+	assert.Equal(t, telegraf.Summary, testm.Type())
+	assert.Equal(t, float64(2), testm.Fields()["0.99"])
+}
+
 type TestMetricMaker struct {
 }
 
@@ -335,6 +365,10 @@ func (tm *TestMetricMaker) MakeMetric(
 		if m, err := metric.New(measurement, tags, fields, t, telegraf.Gauge); err == nil {
 			return m
 		}
+	case telegraf.Histogram, telegraf.Summary:
+		if m, err := metric.New(measurement, tags, fields, t, mType); err == nil {
+			return m
+		}
 	}
 	return nil
 }
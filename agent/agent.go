@@ -18,6 +18,32 @@ import (
 // Agent runs telegraf and collects data based on the given config
 type Agent struct {
 	Config *config.Config
+
+	// Guards the plugins in Config, Reload replaces them while the agent is
+	// running.
+	pluginsMu sync.RWMutex
+
+	metricC chan telegraf.Metric
+	aggC    chan telegraf.Metric
+	// outMetricC holds the processed metrics, on their way to the
+	// aggregators and outputs.
+	outMetricC chan telegraf.Metric
+	// drainC asks the flusher to process the metrics left in metricC.
+	drainC chan chan struct{}
+
+	// the goroutines of the running plugins, nil when the agent is not
+	// running.
+	units map[interface{}]*unit
+	// started is set once all the plugins have been started, until then
+	// the plugins can't be reloaded.
+	started bool
+	unitsMu sync.Mutex
+}
+
+// unit is the goroutine of a running plugin, it can be stopped on its own.
+type unit struct {
+	stop chan struct{}
+	wg   sync.WaitGroup
 }
 
 // NewAgent returns an Agent struct based off the given Config
@@ -39,34 +65,48 @@ func NewAgent(config *config.Config) (*Agent, error) {
 		config.Tags["host"] = a.Config.Agent.Hostname
 	}
 
+	numberInputs(nil, config.Inputs)
+	numberOutputs(nil, config.Outputs)
 	return a, nil
 }
 
 // Connect connects to all configured outputs
 func (a *Agent) Connect() error {
 	for _, o := range a.Config.Outputs {
-		switch ot := o.Output.(type) {
-		case telegraf.ServiceOutput:
-			if err := ot.Start(); err != nil {
-				log.Printf("E! Service for output %s failed to start, exiting\n%s\n",
-					o.Name, err.Error())
+		if o.Config.BufferDirectory != "" {
+			if err := o.OpenDiskBuffer(); err != nil {
 				return err
 			}
 		}
+		if err := connectOutput(o); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-		log.Printf("D! Attempting connection to output: %s\n", o.Name)
-		err := o.Output.Connect()
+func connectOutput(o *models.RunningOutput) error {
+	switch ot := o.Output.(type) {
+	case telegraf.ServiceOutput:
+		if err := ot.Start(); err != nil {
+			log.Printf("E! Service for output %s failed to start, exiting\n%s\n",
+				o.Name, err.Error())
+			return err
+		}
+	}
+
+	log.Printf("D! Attempting connection to output: %s\n", o.Name)
+	err := o.Output.Connect()
+	if err != nil {
+		log.Printf("E! Failed to connect to output %s, retrying in 15s, "+
+			"error was '%s' \n", o.Name, err)
+		time.Sleep(15 * time.Second)
+		err = o.Output.Connect()
 		if err != nil {
-			log.Printf("E! Failed to connect to output %s, retrying in 15s, "+
-				"error was '%s' \n", o.Name, err)
-			time.Sleep(15 * time.Second)
-			err = o.Output.Connect()
-			if err != nil {
-				return err
-			}
+			return err
 		}
-		log.Printf("D! Successfully connected to output: %s\n", o.Name)
 	}
+	log.Printf("D! Successfully connected to output: %s\n", o.Name)
 	return nil
 }
 
@@ -74,11 +114,16 @@ func (a *Agent) Connect() error {
 func (a *Agent) Close() error {
 	var err error
 	for _, o := range a.Config.Outputs {
-		err = o.Output.Close()
-		switch ot := o.Output.(type) {
-		case telegraf.ServiceOutput:
-			ot.Stop()
-		}
+		err = closeOutput(o)
+	}
+	return err
+}
+
+func closeOutput(o *models.RunningOutput) error {
+	err := o.Close()
+	switch ot := o.Output.(type) {
+	case telegraf.ServiceOutput:
+		ot.Stop()
 	}
 	return err
 }
@@ -227,23 +272,46 @@ func (a *Agent) Test() error {
 	return nil
 }
 
-// flush writes a list of metrics to all configured outputs
-func (a *Agent) flush() {
-	var wg sync.WaitGroup
+// flushLoop writes the metrics of a single output every flush interval, or
+// as soon as a full batch is ready, until shutdown is closed. Each output has
+// its own loop so a slow output only backs up its own buffer.
+func (a *Agent) flushLoop(output *models.RunningOutput, shutdown chan struct{}) {
+	interval := a.Config.Agent.FlushInterval.Duration
+	if output.Config.FlushInterval != 0 {
+		interval = output.Config.FlushInterval
+	}
+	jitter := a.Config.Agent.FlushJitter.Duration
+	if output.Config.FlushJitter != 0 {
+		jitter = output.Config.FlushJitter
+	}
 
-	wg.Add(len(a.Config.Outputs))
-	for _, o := range a.Config.Outputs {
-		go func(output *models.RunningOutput) {
-			defer wg.Done()
-			err := output.Write()
-			if err != nil {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-shutdown:
+			// the last write is attempted even if the output is backing off
+			if err := output.Flush(); err != nil {
 				log.Printf("E! Error writing to output [%s]: %s\n",
 					output.Name, err.Error())
 			}
-		}(o)
+			return
+		case <-ticker.C:
+			internal.RandomSleep(jitter, shutdown)
+			a.flush(output)
+		case <-output.BatchReady:
+			a.flush(output)
+		}
 	}
+}
 
-	wg.Wait()
+// flush writes the buffered metrics of an output
+func (a *Agent) flush(output *models.RunningOutput) {
+	err := output.Write()
+	if err != nil {
+		log.Printf("E! Error writing to output [%s]: %s\n",
+			output.Name, err.Error())
+	}
 }
 
 // flusher monitors the metrics input channel and flushes on the minimum interval
@@ -252,9 +320,9 @@ func (a *Agent) flusher(shutdown chan struct{}, metricC chan telegraf.Metric, ag
 	// the flusher will flush after metrics are collected.
 	time.Sleep(time.Millisecond * 300)
 
-	// create an output metric channel and a gorouting that continously passes
-	// each metric onto the output plugins & aggregators.
-	outMetricC := make(chan telegraf.Metric, 100)
+	// a gorouting that continously passes each metric of the output metric
+	// channel onto the output plugins & aggregators.
+	outMetricC := a.outMetricC
 	var wg sync.WaitGroup
 	wg.Add(1)
 	go func() {
@@ -268,25 +336,25 @@ func (a *Agent) flusher(shutdown chan struct{}, metricC chan telegraf.Metric, ag
 				}
 				return
 			case m := <-outMetricC:
+				a.pluginsMu.RLock()
+				aggregators := a.Config.Aggregators
+				a.pluginsMu.RUnlock()
+
 				// if dropOriginal is set to true, then we will only send this
 				// metric to the aggregators, not the outputs.
 				var dropOriginal bool
 				if !m.IsAggregate() {
-					for _, agg := range a.Config.Aggregators {
+					for _, agg := range aggregators {
 						if ok := agg.Add(m.Copy()); ok {
 							dropOriginal = true
 						}
 					}
 				}
-				if !dropOriginal {
-					for i, o := range a.Config.Outputs {
-						if i == len(a.Config.Outputs)-1 {
-							o.AddMetric(m)
-						} else {
-							o.AddMetric(m.Copy())
-						}
-					}
+				if dropOriginal {
+					m.Drop()
+					continue
 				}
+				a.addToOutputs(m)
 			}
 		}
 	}()
@@ -304,7 +372,7 @@ func (a *Agent) flusher(shutdown chan struct{}, metricC chan telegraf.Metric, ag
 				return
 			case metric := <-aggC:
 				metrics := []telegraf.Metric{metric}
-				for _, processor := range a.Config.Processors {
+				for _, processor := range a.processors() {
 					metrics = processor.Apply(metrics...)
 				}
 				for _, m := range metrics {
@@ -314,43 +382,97 @@ func (a *Agent) flusher(shutdown chan struct{}, metricC chan telegraf.Metric, ag
 		}
 	}()
 
-	ticker := time.NewTicker(a.Config.Agent.FlushInterval.Duration)
-	semaphore := make(chan struct{}, 1)
 	for {
 		select {
 		case <-shutdown:
 			log.Println("I! Hang on, flushing any cached metrics before shutdown")
 			// wait for outMetricC to get flushed before flushing outputs
 			wg.Wait()
-			a.flush()
+			a.pluginsMu.RLock()
+			outputs := a.Config.Outputs
+			a.pluginsMu.RUnlock()
+			a.stopOutputs(outputs)
 			return nil
-		case <-ticker.C:
-			go func() {
-				select {
-				case semaphore <- struct{}{}:
-					internal.RandomSleep(a.Config.Agent.FlushJitter.Duration, shutdown)
-					a.flush()
-					<-semaphore
-				default:
-					// skipping this flush because one is already happening
-					log.Println("W! Skipping a scheduled flush because there is" +
-						" already a flush ongoing.")
-				}
-			}()
-		case metric := <-metricC:
-			// NOTE potential bottleneck here as we put each metric through the
-			// processors serially.
-			mS := []telegraf.Metric{metric}
-			for _, processor := range a.Config.Processors {
-				mS = processor.Apply(mS...)
-			}
-			for _, m := range mS {
-				outMetricC <- m
+		case done := <-a.drainC:
+			for len(metricC) > 0 {
+				a.process(<-metricC)
 			}
+			close(done)
+		case metric := <-metricC:
+			a.process(metric)
+		}
+	}
+}
+
+// process passes a metric of the inputs through the processors and on to the
+// aggregators and outputs.
+func (a *Agent) process(metric telegraf.Metric) {
+	// NOTE potential bottleneck here as we put each metric through the
+	// processors serially.
+	mS := []telegraf.Metric{metric}
+	for _, processor := range a.processors() {
+		mS = processor.Apply(mS...)
+	}
+	for _, m := range mS {
+		a.outMetricC <- m
+	}
+}
+
+// drainInputs waits until the flusher has processed the metrics left by the
+// inputs, which must be stopped.
+func (a *Agent) drainInputs() {
+	done := make(chan struct{})
+	a.drainC <- done
+	<-done
+}
+
+// addToOutputs adds a metric to all the outputs. The plugins lock is held
+// until the outputs have taken the metric, so that Reload doesn't stop an
+// output that is about to receive it.
+func (a *Agent) addToOutputs(m telegraf.Metric) {
+	a.pluginsMu.RLock()
+	defer a.pluginsMu.RUnlock()
+	outputs := a.Config.Outputs
+	if len(outputs) == 0 {
+		m.Drop()
+		return
+	}
+	for i, o := range outputs {
+		if i == len(outputs)-1 {
+			o.AddMetric(m)
+		} else {
+			o.AddMetric(m.Copy())
 		}
 	}
 }
 
+func (a *Agent) processors() models.RunningProcessors {
+	a.pluginsMu.RLock()
+	defer a.pluginsMu.RUnlock()
+	return a.Config.Processors
+}
+
+// flushProcessor passes the metrics released by a windowed processor through
+// the processors that come after it, and on to the outputs.
+func (a *Agent) flushProcessor(proc *models.RunningProcessor, metrics []telegraf.Metric) {
+	after := false
+	for _, processor := range a.processors() {
+		if after {
+			metrics = processor.Apply(metrics...)
+		}
+		if processor == proc {
+			after = true
+		}
+	}
+	if !after && len(metrics) > 0 {
+		log.Printf("W! Processor [%s] is not running anymore, its %d metrics "+
+			"skip the processors after it\n", proc.Name, len(metrics))
+	}
+	for _, m := range metrics {
+		a.outMetricC <- m
+	}
+}
+
 // Run runs the agent daemon, gathering every Interval
 func (a *Agent) Run(shutdown chan struct{}) error {
 	var wg sync.WaitGroup
@@ -361,26 +483,31 @@ func (a *Agent) Run(shutdown chan struct{}) error {
 		a.Config.Agent.Hostname, a.Config.Agent.FlushInterval.Duration)
 
 	// channel shared between all input threads for accumulating metrics
-	metricC := make(chan telegraf.Metric, 100)
-	aggC := make(chan telegraf.Metric, 100)
+	a.metricC = make(chan telegraf.Metric, 100)
+	a.aggC = make(chan telegraf.Metric, 100)
+	a.outMetricC = make(chan telegraf.Metric, 100)
+	a.drainC = make(chan chan struct{})
+	a.unitsMu.Lock()
+	a.units = make(map[interface{}]*unit)
+	a.unitsMu.Unlock()
+
+	if a.Config.Agent.HealthAddress != "" {
+		srv, err := a.startHealth(shutdown)
+		if err != nil {
+			return err
+		}
+		defer srv.Close()
+	}
 
 	now := time.Now()
 
 	// Start all ServicePlugins
-	for _, input := range a.Config.Inputs {
-		input.SetDefaultTags(a.Config.Tags)
-		switch p := input.Input.(type) {
-		case telegraf.ServiceInput:
-			acc := NewAccumulator(input, metricC)
-			// Service input plugins should set their own precision of their
-			// metrics.
-			acc.SetPrecision(time.Nanosecond, 0)
-			if err := p.Start(acc); err != nil {
-				log.Printf("E! Service for input %s failed to start, exiting\n%s\n",
-					input.Name(), err.Error())
-				return err
-			}
-			defer p.Stop()
+	for i, input := range a.Config.Inputs {
+		if err := a.startService(input); err != nil {
+			log.Printf("E! Service for input %s failed to start, exiting\n%s\n",
+				input.Name(), err.Error())
+			a.stopInputs(a.Config.Inputs[:i])
+			return err
 		}
 	}
 
@@ -390,40 +517,197 @@ func (a *Agent) Run(shutdown chan struct{}) error {
 		time.Sleep(time.Duration(i - (time.Now().UnixNano() % i)))
 	}
 
+	for _, output := range a.Config.Outputs {
+		a.startOutput(output)
+	}
+
+	// the flusher is stopped once the inputs and aggregators are, so the
+	// metrics they produced last are still written.
+	flusherShutdown := make(chan struct{})
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		if err := a.flusher(shutdown, metricC, aggC); err != nil {
+		if err := a.flusher(flusherShutdown, a.metricC, a.aggC); err != nil {
 			log.Printf("E! Flusher routine failed, exiting: %s\n", err.Error())
 			close(shutdown)
 		}
 	}()
 
-	wg.Add(len(a.Config.Aggregators))
+	for _, processor := range a.Config.Processors {
+		a.startProcessor(processor)
+	}
+
 	for _, aggregator := range a.Config.Aggregators {
-		go func(agg *models.RunningAggregator) {
-			defer wg.Done()
-			acc := NewAccumulator(agg, aggC)
-			acc.SetPrecision(a.Config.Agent.Precision.Duration,
-				a.Config.Agent.Interval.Duration)
-			agg.Run(acc, now, shutdown)
-		}(aggregator)
+		a.startAggregator(aggregator, now)
 	}
 
-	wg.Add(len(a.Config.Inputs))
 	for _, input := range a.Config.Inputs {
-		interval := a.Config.Agent.Interval.Duration
-		// overwrite global interval if this plugin has it's own.
-		if input.Config.Interval != 0 {
-			interval = input.Config.Interval
-		}
-		go func(in *models.RunningInput, interv time.Duration) {
-			defer wg.Done()
-			a.gatherer(shutdown, in, interv, metricC)
-		}(input, interval)
+		a.startGatherer(input)
 	}
-
+	a.unitsMu.Lock()
+	a.started = true
+	a.unitsMu.Unlock()
+
+	<-shutdown
+	a.unitsMu.Lock()
+	a.started = false
+	a.unitsMu.Unlock()
+	a.pluginsMu.RLock()
+	inputs, aggregators := a.Config.Inputs, a.Config.Aggregators
+	processors := a.Config.Processors
+	a.pluginsMu.RUnlock()
+	// the plugins are stopped in the order the metrics flow through them,
+	// each one releasing its last metrics to the ones that are still running.
+	a.stopInputs(inputs)
+	a.drainInputs()
+	a.stopProcessors(processors)
+	a.stopAggregators(aggregators)
+	close(flusherShutdown)
 	wg.Wait()
+
+	a.unitsMu.Lock()
+	a.units = nil
+	a.unitsMu.Unlock()
 	a.Close()
 	return nil
 }
+
+// run starts f in the goroutine of plugin, stop is closed when the plugin
+// is stopped.
+func (a *Agent) run(plugin interface{}, f func(stop chan struct{})) {
+	u := &unit{stop: make(chan struct{})}
+	u.wg.Add(1)
+	a.unitsMu.Lock()
+	a.units[plugin] = u
+	a.unitsMu.Unlock()
+	go func() {
+		defer u.wg.Done()
+		f(u.stop)
+	}()
+}
+
+// stop stops the goroutines of the given plugins and waits for them to
+// return.
+func (a *Agent) stop(plugins ...interface{}) {
+	var units []*unit
+	a.unitsMu.Lock()
+	for _, p := range plugins {
+		if u, ok := a.units[p]; ok {
+			units = append(units, u)
+			delete(a.units, p)
+		}
+	}
+	a.unitsMu.Unlock()
+
+	for _, u := range units {
+		close(u.stop)
+	}
+	for _, u := range units {
+		u.wg.Wait()
+	}
+}
+
+// startService starts the input if it is a service input.
+func (a *Agent) startService(input *models.RunningInput) error {
+	input.SetDefaultTags(a.Config.Tags)
+	p, ok := input.Input.(telegraf.ServiceInput)
+	if !ok {
+		return nil
+	}
+	acc := NewAccumulator(input, a.metricC)
+	// Service input plugins should set their own precision of their
+	// metrics.
+	acc.SetPrecision(time.Nanosecond, 0)
+	return p.Start(acc)
+}
+
+// startGatherer gathers the input every interval.
+func (a *Agent) startGatherer(input *models.RunningInput) {
+	interval := a.Config.Agent.Interval.Duration
+	// overwrite global interval if this plugin has it's own.
+	if input.Config.Interval != 0 {
+		interval = input.Config.Interval
+	}
+	a.run(input, func(stop chan struct{}) {
+		a.gatherer(stop, input, interval, a.metricC)
+	})
+}
+
+// stopInputs stops gathering the inputs and stops the service inputs.
+func (a *Agent) stopInputs(inputs []*models.RunningInput) {
+	plugins := make([]interface{}, len(inputs))
+	for i, input := range inputs {
+		plugins[i] = input
+	}
+	a.stop(plugins...)
+
+	for _, input := range inputs {
+		if p, ok := input.Input.(telegraf.ServiceInput); ok {
+			p.Stop()
+		}
+	}
+}
+
+// startProcessor flushes the processor at the end of each window, if it is a
+// windowed processor.
+func (a *Agent) startProcessor(proc *models.RunningProcessor) {
+	if !proc.IsWindowed() {
+		return
+	}
+	a.run(proc, func(stop chan struct{}) {
+		ticker := time.NewTicker(proc.Config.Period)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				a.flushProcessor(proc, proc.Close())
+				return
+			case <-ticker.C:
+				a.flushProcessor(proc, proc.Flush())
+			}
+		}
+	})
+}
+
+// stopProcessors stops the windowed processors, which release the metrics
+// they hold one last time.
+func (a *Agent) stopProcessors(processors models.RunningProcessors) {
+	plugins := make([]interface{}, len(processors))
+	for i, proc := range processors {
+		plugins[i] = proc
+	}
+	a.stop(plugins...)
+}
+
+func (a *Agent) startAggregator(agg *models.RunningAggregator, now time.Time) {
+	a.run(agg, func(stop chan struct{}) {
+		acc := NewAccumulator(agg, a.aggC)
+		acc.SetPrecision(a.Config.Agent.Precision.Duration,
+			a.Config.Agent.Interval.Duration)
+		agg.Run(acc, a.addToOutputs, now, stop)
+	})
+}
+
+func (a *Agent) stopAggregators(aggregators []*models.RunningAggregator) {
+	plugins := make([]interface{}, len(aggregators))
+	for i, agg := range aggregators {
+		plugins[i] = agg
+	}
+	a.stop(plugins...)
+}
+
+func (a *Agent) startOutput(output *models.RunningOutput) {
+	a.run(output, func(stop chan struct{}) {
+		a.flushLoop(output, stop)
+	})
+}
+
+// stopOutputs stops the flush loops of the outputs, which write their
+// buffered metrics one last time.
+func (a *Agent) stopOutputs(outputs []*models.RunningOutput) {
+	plugins := make([]interface{}, len(outputs))
+	for i, output := range outputs {
+		plugins[i] = output
+	}
+	a.stop(plugins...)
+}
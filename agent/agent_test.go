@@ -1,9 +1,15 @@
 package agent
 
 import (
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/internal/config"
+	"github.com/influxdata/telegraf/internal/models"
+	"github.com/influxdata/telegraf/metric"
 
 	// needing to load the plugins
 	_ "github.com/influxdata/telegraf/plugins/inputs/all"
@@ -11,6 +17,7 @@ import (
 	_ "github.com/influxdata/telegraf/plugins/outputs/all"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestAgent_OmitHostname(t *testing.T) {
@@ -109,3 +116,127 @@ func TestAgent_LoadOutput(t *testing.T) {
 	a, _ = NewAgent(c)
 	assert.Equal(t, 3, len(a.Config.Outputs))
 }
+
+type recordOutput struct {
+	sync.Mutex
+	metrics []telegraf.Metric
+	// closeDelay makes Close slow.
+	closeDelay time.Duration
+	// onClose is called by Close if set.
+	onClose func()
+}
+
+func (o *recordOutput) Connect() error { return nil }
+func (o *recordOutput) Close() error {
+	if o.onClose != nil {
+		o.onClose()
+	}
+	time.Sleep(o.closeDelay)
+	return nil
+}
+func (o *recordOutput) Description() string  { return "" }
+func (o *recordOutput) SampleConfig() string { return "" }
+func (o *recordOutput) Write(metrics []telegraf.Metric) error {
+	o.Lock()
+	defer o.Unlock()
+	o.metrics = append(o.metrics, metrics...)
+	return nil
+}
+
+func (o *recordOutput) Len() int {
+	o.Lock()
+	defer o.Unlock()
+	return len(o.metrics)
+}
+
+// holdProcessor is a windowed processor that holds all the metrics back.
+type holdProcessor struct {
+	metrics []telegraf.Metric
+}
+
+func (p *holdProcessor) SampleConfig() string { return "" }
+func (p *holdProcessor) Description() string  { return "" }
+func (p *holdProcessor) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	p.metrics = append(p.metrics, in...)
+	return nil
+}
+func (p *holdProcessor) Flush() []telegraf.Metric {
+	out := p.metrics
+	p.metrics = nil
+	return out
+}
+
+type nopAggregator struct{}
+
+func (a *nopAggregator) SampleConfig() string          { return "" }
+func (a *nopAggregator) Description() string           { return "" }
+func (a *nopAggregator) Add(in telegraf.Metric)        {}
+func (a *nopAggregator) Push(acc telegraf.Accumulator) {}
+func (a *nopAggregator) Reset()                        {}
+
+// startAgent runs the agent until shutdown is closed, the returned channel
+// receives the result of Run.
+func startAgent(a *Agent, shutdown chan struct{}) chan error {
+	done := make(chan error)
+	go func() {
+		done <- a.Run(shutdown)
+	}()
+	for {
+		a.unitsMu.Lock()
+		started := a.started
+		a.unitsMu.Unlock()
+		if started {
+			return done
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func newTestConfig() *config.Config {
+	c := config.NewConfig()
+	c.Agent.OmitHostname = true
+	c.Agent.RoundInterval = false
+	c.Agent.Interval = internal.Duration{Duration: time.Hour}
+	c.Agent.FlushInterval = internal.Duration{Duration: time.Hour}
+	return c
+}
+
+// Test that on shutdown the metrics gathered last go through the windowed
+// processors, and the metrics they hold through the aggregators.
+func TestAgent_ShutdownOrder(t *testing.T) {
+	c := newTestConfig()
+	o := &recordOutput{}
+	c.Outputs = append(c.Outputs, models.NewRunningOutput("record", o,
+		&models.OutputConfig{Name: "record"}, 1000, 1000))
+	c.Processors = append(c.Processors, &models.RunningProcessor{
+		Name:      "hold",
+		Processor: &holdProcessor{},
+		Config:    &models.ProcessorConfig{Name: "hold", Period: time.Hour},
+	})
+	c.Aggregators = append(c.Aggregators, models.NewRunningAggregator(
+		&nopAggregator{},
+		&models.AggregatorConfig{Name: "nop", Period: time.Hour}))
+	a, err := NewAgent(c)
+	require.NoError(t, err)
+
+	shutdown := make(chan struct{})
+	done := startAgent(a, shutdown)
+
+	// more metrics than the channels of the agent and the aggregator hold.
+	const n = 250
+	for i := 0; i < n; i++ {
+		m, err := metric.New("cpu", map[string]string{},
+			map[string]interface{}{"value": i}, time.Now())
+		require.NoError(t, err)
+		a.metricC <- m
+	}
+	close(shutdown)
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("shutdown timed out")
+	}
+	assert.Equal(t, n, o.Len())
+}
@@ -0,0 +1,285 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf/internal/models"
+	"github.com/influxdata/telegraf/selfstat"
+)
+
+// HealthReport is the body of the health check response.
+type HealthReport struct {
+	Healthy bool           `json:"healthy"`
+	Inputs  []PluginHealth `json:"inputs"`
+	Outputs []PluginHealth `json:"outputs"`
+}
+
+// PluginHealth holds the health of a single plugin, and the stats it was
+// checked against.
+type PluginHealth struct {
+	Name    string   `json:"name"`
+	Healthy bool     `json:"healthy"`
+	Reasons []string `json:"reasons,omitempty"`
+
+	GatherErrors *int64 `json:"gather_errors,omitempty"`
+	WriteErrors  *int64 `json:"write_errors,omitempty"`
+	BufferSize   *int64 `json:"buffer_size,omitempty"`
+	BufferLimit  *int64 `json:"buffer_limit,omitempty"`
+}
+
+// healthChecker checks the health of the plugins of the agent against the
+// health rules of the agent config. Errors are counted over the health
+// window from samples of the error counters, taken at regular intervals
+// while the agent runs.
+type healthChecker struct {
+	agent *Agent
+
+	mu      sync.Mutex
+	samples map[uint64][]sample
+}
+
+type sample struct {
+	t time.Time
+	v int64
+}
+
+func newHealthChecker(a *Agent) *healthChecker {
+	h := &healthChecker{
+		agent:   a,
+		samples: make(map[uint64][]sample),
+	}
+	// the first samples are the baseline of the error counts.
+	h.Sample(time.Now())
+	return h
+}
+
+// run samples the error counters every tenth of the health window until
+// shutdown is closed.
+func (h *healthChecker) run(shutdown chan struct{}) {
+	interval := h.agent.Config.Agent.HealthWindow.Duration / 10
+	if interval < time.Second {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-shutdown:
+			return
+		case now := <-ticker.C:
+			h.Sample(now)
+		}
+	}
+}
+
+// Sample records the error counters of the plugins at now. Only the samples
+// within the health window, and the newest one before it as the baseline,
+// are kept.
+func (h *healthChecker) Sample(now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	a := h.agent
+	a.pluginsMu.RLock()
+	inputs, outputs := a.Config.Inputs, a.Config.Outputs
+	a.pluginsMu.RUnlock()
+
+	stats := make([]selfstat.Stat, 0, len(inputs)+len(outputs))
+	for _, input := range inputs {
+		stats = append(stats, input.GatherErrors)
+	}
+	for _, output := range outputs {
+		stats = append(stats, output.WriteErrors)
+	}
+
+	window := a.Config.Agent.HealthWindow.Duration
+	seen := make(map[uint64]bool)
+	for _, stat := range stats {
+		key := stat.Key()
+		seen[key] = true
+		samples := append(h.samples[key], sample{t: now, v: stat.Get()})
+		for len(samples) > 1 && now.Sub(samples[1].t) >= window {
+			samples = samples[1:]
+		}
+		h.samples[key] = samples
+	}
+
+	// forget about the plugins that were removed.
+	for key := range h.samples {
+		if !seen[key] {
+			delete(h.samples, key)
+		}
+	}
+}
+
+// Check returns the health of the agent at now.
+func (h *healthChecker) Check(now time.Time) *HealthReport {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	a := h.agent
+	a.pluginsMu.RLock()
+	inputs, outputs := a.Config.Inputs, a.Config.Outputs
+	a.pluginsMu.RUnlock()
+	agentConfig := a.Config.Agent
+
+	report := &HealthReport{
+		Healthy: true,
+		Inputs:  make([]PluginHealth, 0, len(inputs)),
+		Outputs: make([]PluginHealth, 0, len(outputs)),
+	}
+
+	for _, input := range inputs {
+		ph := PluginHealth{Name: input.Name(), Healthy: true}
+		errors := h.errors(input.GatherErrors, now)
+		ph.GatherErrors = &errors
+
+		max := agentConfig.HealthMaxGatherErrors
+		if max > 0 && errors >= max {
+			ph.fail(fmt.Sprintf("%d gather errors in the last %s",
+				errors, agentConfig.HealthWindow.Duration))
+		}
+		report.Healthy = report.Healthy && ph.Healthy
+		report.Inputs = append(report.Inputs, ph)
+	}
+
+	for _, output := range outputs {
+		ph := PluginHealth{Name: "outputs." + output.Name, Healthy: true}
+		errors := h.errors(output.WriteErrors, now)
+		ph.WriteErrors = &errors
+		size := output.BufferSize.Get()
+		ph.BufferSize = &size
+
+		max := agentConfig.HealthMaxWriteErrors
+		if max > 0 && errors >= max {
+			ph.fail(fmt.Sprintf("%d write errors in the last %s",
+				errors, agentConfig.HealthWindow.Duration))
+		}
+
+		// outputs with a disk buffer are limited in bytes, not metrics.
+		if output.Config.BufferDirectory == "" {
+			limit := int64(output.MetricBufferLimit)
+			ph.BufferLimit = &limit
+			fill := agentConfig.HealthMaxBufferFill
+			if fill > 0 && float64(size) > fill*float64(limit) {
+				ph.fail(fmt.Sprintf("buffer is %.0f%% full",
+					100*float64(size)/float64(limit)))
+			}
+		}
+		report.Healthy = report.Healthy && ph.Healthy
+		report.Outputs = append(report.Outputs, ph)
+	}
+	return report
+}
+
+// errors returns the increase of the error counter stat over the health
+// window ending at now, the counters of the plugins that were not sampled yet
+// are not counted.
+func (h *healthChecker) errors(stat selfstat.Stat, now time.Time) int64 {
+	samples := h.samples[stat.Key()]
+	if len(samples) == 0 {
+		return 0
+	}
+
+	// the baseline is the newest sample older than the window.
+	window := h.agent.Config.Agent.HealthWindow.Duration
+	base := samples[0]
+	for _, s := range samples[1:] {
+		if now.Sub(s.t) < window {
+			break
+		}
+		base = s
+	}
+	return stat.Get() - base.v
+}
+
+// numberInputs gives the added inputs the lowest instance numbers that are
+// not used by the running inputs of the same type.
+func numberInputs(running, added []*models.RunningInput) {
+	used := make(map[string]map[int]bool)
+	for _, input := range running {
+		useInstance(used, input.Config.Name, input.Instance)
+	}
+	for _, input := range added {
+		input.SetInstance(nextInstance(used, input.Config.Name))
+	}
+}
+
+// numberOutputs gives the added outputs the lowest instance numbers that are
+// not used by the running outputs of the same type.
+func numberOutputs(running, added []*models.RunningOutput) {
+	used := make(map[string]map[int]bool)
+	for _, output := range running {
+		useInstance(used, output.Name, output.Instance)
+	}
+	for _, output := range added {
+		output.SetInstance(nextInstance(used, output.Name))
+	}
+}
+
+func useInstance(used map[string]map[int]bool, name string, instance int) {
+	if used[name] == nil {
+		used[name] = make(map[int]bool)
+	}
+	used[name][instance] = true
+}
+
+func nextInstance(used map[string]map[int]bool, name string) int {
+	instance := 0
+	for used[name][instance] {
+		instance++
+	}
+	useInstance(used, name, instance)
+	return instance
+}
+
+func (ph *PluginHealth) fail(reason string) {
+	ph.Healthy = false
+	ph.Reasons = append(ph.Reasons, reason)
+}
+
+// ServeHTTP answers health checks with 200 when the agent is healthy and 503
+// otherwise, the body holds the details of the report.
+func (h *healthChecker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	report := h.Check(time.Now())
+
+	w.Header().Set("Content-Type", "application/json")
+	if report.Healthy {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		log.Printf("E! Error writing health check response: %s", err)
+	}
+}
+
+// startHealth starts the health check listener and the sampling of the error
+// counters until shutdown is closed, the returned server has to be closed
+// once the agent stops.
+func (a *Agent) startHealth(shutdown chan struct{}) (*http.Server, error) {
+	ln, err := net.Listen("tcp", a.Config.Agent.HealthAddress)
+	if err != nil {
+		return nil, fmt.Errorf("unable to start health check listener: %s", err)
+	}
+
+	h := newHealthChecker(a)
+	go h.run(shutdown)
+
+	mux := http.NewServeMux()
+	mux.Handle("/health", h)
+	srv := &http.Server{Handler: mux}
+	go func() {
+		if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
+			log.Printf("E! Health check listener failed: %s", err)
+		}
+	}()
+	log.Printf("I! Health check listening on http://%s/health", ln.Addr())
+	return srv, nil
+}
@@ -0,0 +1,151 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/config"
+	"github.com/influxdata/telegraf/internal/models"
+	"github.com/influxdata/telegraf/metric"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type healthOutput struct {
+	err error
+}
+
+func (o *healthOutput) Connect() error                  { return nil }
+func (o *healthOutput) Close() error                    { return nil }
+func (o *healthOutput) Description() string             { return "" }
+func (o *healthOutput) SampleConfig() string            { return "" }
+func (o *healthOutput) Write(_ []telegraf.Metric) error { return o.err }
+
+func newHealthAgent(t *testing.T, name string, o *healthOutput) *Agent {
+	c := config.NewConfig()
+	c.Outputs = append(c.Outputs, models.NewRunningOutput(name, o,
+		&models.OutputConfig{Name: name}, 1, 10))
+	c.Inputs = append(c.Inputs, models.NewRunningInput(nil,
+		&models.InputConfig{Name: name}))
+	a, err := NewAgent(c)
+	require.NoError(t, err)
+	return a
+}
+
+func testMetric(t *testing.T) telegraf.Metric {
+	m, err := metric.New("cpu", map[string]string{},
+		map[string]interface{}{"value": 42}, time.Unix(0, 0))
+	require.NoError(t, err)
+	return m
+}
+
+func TestHealthWriteErrors(t *testing.T) {
+	o := &healthOutput{}
+	a := newHealthAgent(t, "health_write_errors", o)
+	a.Config.Agent.HealthMaxWriteErrors = 2
+	now := time.Now()
+	h := newHealthChecker(a)
+
+	report := h.Check(now)
+	assert.True(t, report.Healthy)
+
+	ro := a.Config.Outputs[0]
+	o.err = errors.New("connection refused")
+	for i := 0; i < 2; i++ {
+		ro.AddMetric(testMetric(t))
+		ro.Write()
+	}
+	h.Sample(now.Add(time.Second))
+	report = h.Check(now.Add(time.Second))
+	assert.False(t, report.Healthy)
+	assert.False(t, report.Outputs[0].Healthy)
+	assert.Equal(t, int64(2), *report.Outputs[0].WriteErrors)
+
+	// the errors are forgotten once they are out of the window
+	report = h.Check(now.Add(2 * time.Minute))
+	assert.True(t, report.Healthy)
+	assert.Equal(t, int64(0), *report.Outputs[0].WriteErrors)
+}
+
+// Test that the outputs of the same type don't share their error counters.
+func TestHealthWriteErrorsPerInstance(t *testing.T) {
+	o := &healthOutput{}
+	a := newHealthAgent(t, "health_instances", o)
+	failing := &healthOutput{err: errors.New("connection refused")}
+	a.Config.Outputs = append(a.Config.Outputs, models.NewRunningOutput(
+		"health_instances", failing,
+		&models.OutputConfig{Name: "health_instances"}, 1, 10))
+	numberOutputs(a.Config.Outputs[:1], a.Config.Outputs[1:])
+	a.Config.Agent.HealthMaxWriteErrors = 1
+	now := time.Now()
+	h := newHealthChecker(a)
+
+	ro := a.Config.Outputs[1]
+	ro.AddMetric(testMetric(t))
+	ro.Write()
+	report := h.Check(now)
+	assert.True(t, report.Outputs[0].Healthy)
+	assert.Equal(t, int64(0), *report.Outputs[0].WriteErrors)
+	assert.False(t, report.Outputs[1].Healthy)
+	assert.Equal(t, int64(1), *report.Outputs[1].WriteErrors)
+}
+
+func TestHealthBufferFill(t *testing.T) {
+	o := &healthOutput{err: errors.New("connection refused")}
+	a := newHealthAgent(t, "health_buffer_fill", o)
+	a.Config.Agent.HealthMaxWriteErrors = 0
+	h := newHealthChecker(a)
+
+	ro := a.Config.Outputs[0]
+	for i := 0; i < 10; i++ {
+		ro.AddMetric(testMetric(t))
+	}
+	ro.Write()
+
+	report := h.Check(time.Now())
+	assert.False(t, report.Healthy)
+	assert.Equal(t, int64(10), *report.Outputs[0].BufferSize)
+	assert.Equal(t, int64(10), *report.Outputs[0].BufferLimit)
+	assert.Equal(t, []string{"buffer is 100% full"}, report.Outputs[0].Reasons)
+}
+
+func TestHealthGatherErrors(t *testing.T) {
+	a := newHealthAgent(t, "health_gather_errors", &healthOutput{})
+	a.Config.Agent.HealthMaxGatherErrors = 1
+	h := newHealthChecker(a)
+
+	acc := NewAccumulator(a.Config.Inputs[0], make(chan telegraf.Metric, 1))
+	acc.AddError(errors.New("permission denied"))
+
+	report := h.Check(time.Now())
+	assert.False(t, report.Healthy)
+	assert.False(t, report.Inputs[0].Healthy)
+	assert.Equal(t, int64(1), *report.Inputs[0].GatherErrors)
+}
+
+func TestHealthHandler(t *testing.T) {
+	a := newHealthAgent(t, "health_handler", &healthOutput{})
+	h := newHealthChecker(a)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/health", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var report HealthReport
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.True(t, report.Healthy)
+	require.Len(t, report.Outputs, 1)
+	assert.Equal(t, "outputs.health_handler", report.Outputs[0].Name)
+
+	a.Config.Agent.HealthMaxBufferFill = 0.5
+	a.Config.Outputs[0].BufferSize.Set(8)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/health", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
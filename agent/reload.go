@@ -0,0 +1,262 @@
+package agent
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/influxdata/telegraf/internal/config"
+	"github.com/influxdata/telegraf/internal/models"
+)
+
+// ErrRestartRequired is returned by Reload when the new configuration can't
+// be applied to the running agent, it has to be restarted instead.
+var ErrRestartRequired = errors.New("agent settings changed, restart required")
+
+// Reload applies the plugins of c to the running agent. Only the plugins
+// that were added, removed or whose configuration changed are started and
+// stopped, the others keep running. Outputs that did not change keep their
+// buffered metrics.
+func (a *Agent) Reload(c *config.Config) error {
+	if c.AgentFingerprint != a.Config.AgentFingerprint {
+		return ErrRestartRequired
+	}
+	a.unitsMu.Lock()
+	started := a.started
+	a.unitsMu.Unlock()
+	if !started {
+		return ErrRestartRequired
+	}
+
+	a.pluginsMu.RLock()
+	old := *a.Config
+	a.pluginsMu.RUnlock()
+
+	// Every plugin of c is either a running plugin with the same fingerprint
+	// or has to be started.
+	var inputs, addedInputs, removedInputs []*models.RunningInput
+	keptInputs, removed := match(inputFingerprints(old.Inputs), inputFingerprints(c.Inputs))
+	for i, input := range c.Inputs {
+		if keptInputs[i] >= 0 {
+			inputs = append(inputs, old.Inputs[keptInputs[i]])
+		} else {
+			addedInputs = append(addedInputs, input)
+		}
+	}
+	for _, i := range removed {
+		removedInputs = append(removedInputs, old.Inputs[i])
+	}
+
+	var aggregators, addedAggregators, removedAggregators []*models.RunningAggregator
+	keptAggregators, removed := match(aggregatorFingerprints(old.Aggregators),
+		aggregatorFingerprints(c.Aggregators))
+	for i, agg := range c.Aggregators {
+		if keptAggregators[i] >= 0 {
+			aggregators = append(aggregators, old.Aggregators[keptAggregators[i]])
+		} else {
+			addedAggregators = append(addedAggregators, agg)
+		}
+	}
+	for _, i := range removed {
+		removedAggregators = append(removedAggregators, old.Aggregators[i])
+	}
+
+	var outputs, addedOutputs, removedOutputs []*models.RunningOutput
+	keptOutputs, removed := match(outputFingerprints(old.Outputs), outputFingerprints(c.Outputs))
+	for i, o := range c.Outputs {
+		if keptOutputs[i] >= 0 {
+			outputs = append(outputs, old.Outputs[keptOutputs[i]])
+		} else {
+			addedOutputs = append(addedOutputs, o)
+		}
+	}
+	for _, i := range removed {
+		removedOutputs = append(removedOutputs, old.Outputs[i])
+	}
+
+	// The running processors are reused so they keep their state, only the
+	// windowed processors run on their own.
+	processors := make(models.RunningProcessors, len(c.Processors))
+	var addedProcessors, removedProcessors models.RunningProcessors
+	keptProcessors, removed := match(processorFingerprints(old.Processors),
+		processorFingerprints(c.Processors))
+	for i, p := range c.Processors {
+		if keptProcessors[i] >= 0 {
+			processors[i] = old.Processors[keptProcessors[i]]
+		} else {
+			processors[i] = p
+			addedProcessors = append(addedProcessors, p)
+		}
+	}
+	for _, i := range removed {
+		removedProcessors = append(removedProcessors, old.Processors[i])
+	}
+
+	// the error counters of the added plugins must not be shared with the
+	// running plugins of the same type.
+	numberInputs(inputs, addedInputs)
+	numberOutputs(outputs, addedOutputs)
+
+	for i, o := range addedOutputs {
+		if err := connectOutput(o); err != nil {
+			for _, connected := range addedOutputs[:i] {
+				closeOutput(connected)
+			}
+			return err
+		}
+	}
+
+	// Outputs with a disk buffer can only be started once the output they
+	// replace has released the buffer directory, the others receive
+	// metrics right away.
+	var diskOutputs []*models.RunningOutput
+	for _, o := range addedOutputs {
+		if o.Config.BufferDirectory != "" {
+			diskOutputs = append(diskOutputs, o)
+			continue
+		}
+		a.startOutput(o)
+		outputs = append(outputs, o)
+	}
+
+	// The metrics are added to the outputs under the read lock, so once the
+	// plugins are swapped the removed outputs have received their last
+	// metric and can be flushed one last time.
+	a.pluginsMu.Lock()
+	a.Config.Inputs = inputs
+	a.Config.Aggregators = aggregators
+	a.Config.Processors = processors
+	// the metrics are held back until the outputs with a disk buffer have
+	// taken over, so that none is missed in between. The metrics in the
+	// buffer directory are handed over without flushing the old output.
+	for _, o := range diskOutputs {
+		releaseDiskBuffer(removedOutputs, o.Config.BufferDirectory)
+		if err := o.OpenDiskBuffer(); err != nil {
+			log.Printf("E! %s, buffering in memory instead", err)
+		}
+		a.startOutput(o)
+		outputs = append(outputs, o)
+	}
+	a.Config.Outputs = outputs
+	a.pluginsMu.Unlock()
+
+	a.stopOutputs(removedOutputs)
+	closeOutputs(removedOutputs)
+
+	a.stopInputs(removedInputs)
+	a.stopAggregators(removedAggregators)
+	a.stopProcessors(removedProcessors)
+
+	for _, p := range addedProcessors {
+		a.startProcessor(p)
+	}
+
+	now := time.Now()
+	for _, agg := range addedAggregators {
+		a.startAggregator(agg, now)
+		aggregators = append(aggregators, agg)
+	}
+
+	for _, input := range addedInputs {
+		if err := a.startService(input); err != nil {
+			log.Printf("E! Service for input %s failed to start: %s",
+				input.Name(), err)
+			continue
+		}
+		a.startGatherer(input)
+		inputs = append(inputs, input)
+	}
+
+	a.pluginsMu.Lock()
+	a.Config.Inputs = inputs
+	a.Config.Aggregators = aggregators
+	a.pluginsMu.Unlock()
+
+	log.Printf("I! Reloaded config, started %d and stopped %d plugins",
+		len(addedInputs)+len(addedAggregators)+len(addedOutputs),
+		len(removedInputs)+len(removedAggregators)+len(removedOutputs))
+	return nil
+}
+
+// releaseDiskBuffer closes the disk buffer in dir of the removed output that
+// used it, if any, so that the output replacing it can open it.
+func releaseDiskBuffer(removed []*models.RunningOutput, dir string) {
+	for _, o := range removed {
+		if o.Config.BufferDirectory != dir {
+			continue
+		}
+		if err := o.ReleaseDiskBuffer(); err != nil {
+			log.Printf("E! Could not close disk buffer for output %s: %s",
+				o.Name, err)
+		}
+	}
+}
+
+func closeOutputs(outputs []*models.RunningOutput) {
+	for _, o := range outputs {
+		if err := closeOutput(o); err != nil {
+			log.Printf("E! Error closing output [%s]: %s", o.Name, err)
+		}
+	}
+}
+
+// match pairs the loaded plugins with running plugins of the same
+// fingerprint. kept[i] is the index of the running plugin that takes the place
+// of loaded plugin i, or -1 if it has to be started. removed holds the indexes
+// of the running plugins that have to be stopped.
+func match(running, loaded []string) (kept []int, removed []int) {
+	unused := make(map[string][]int)
+	for i, fp := range running {
+		unused[fp] = append(unused[fp], i)
+	}
+
+	kept = make([]int, len(loaded))
+	for i, fp := range loaded {
+		kept[i] = -1
+		if idx := unused[fp]; len(idx) > 0 {
+			kept[i] = idx[0]
+			unused[fp] = idx[1:]
+		}
+	}
+
+	for i, fp := range running {
+		for _, j := range unused[fp] {
+			if i == j {
+				removed = append(removed, i)
+			}
+		}
+	}
+	return kept, removed
+}
+
+func inputFingerprints(inputs []*models.RunningInput) []string {
+	fps := make([]string, len(inputs))
+	for i, input := range inputs {
+		fps[i] = input.Fingerprint
+	}
+	return fps
+}
+
+func aggregatorFingerprints(aggregators []*models.RunningAggregator) []string {
+	fps := make([]string, len(aggregators))
+	for i, agg := range aggregators {
+		fps[i] = agg.Fingerprint
+	}
+	return fps
+}
+
+func outputFingerprints(outputs []*models.RunningOutput) []string {
+	fps := make([]string, len(outputs))
+	for i, o := range outputs {
+		fps[i] = o.Fingerprint
+	}
+	return fps
+}
+
+func processorFingerprints(processors models.RunningProcessors) []string {
+	fps := make([]string, len(processors))
+	for i, p := range processors {
+		fps[i] = p.Fingerprint
+	}
+	return fps
+}
@@ -0,0 +1,154 @@
+package agent
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/internal/config"
+	"github.com/influxdata/telegraf/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatch(t *testing.T) {
+	var tests = []struct {
+		name    string
+		running []string
+		loaded  []string
+		kept    []int
+		removed []int
+	}{
+		{
+			name:    "unchanged",
+			running: []string{"a", "b"},
+			loaded:  []string{"a", "b"},
+			kept:    []int{0, 1},
+		},
+		{
+			name:    "added",
+			running: []string{"a"},
+			loaded:  []string{"a", "b"},
+			kept:    []int{0, -1},
+		},
+		{
+			name:    "removed",
+			running: []string{"a", "b"},
+			loaded:  []string{"b"},
+			kept:    []int{1},
+			removed: []int{0},
+		},
+		{
+			name:    "changed",
+			running: []string{"a", "b"},
+			loaded:  []string{"a", "c"},
+			kept:    []int{0, -1},
+			removed: []int{1},
+		},
+		{
+			name:    "duplicates",
+			running: []string{"a", "a", "a"},
+			loaded:  []string{"a", "a"},
+			kept:    []int{0, 1},
+			removed: []int{2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kept, removed := match(tt.running, tt.loaded)
+			assert.Equal(t, tt.kept, kept)
+			assert.Equal(t, tt.removed, removed)
+		})
+	}
+}
+
+func newReloadOutput(name, fingerprint, dir string) (*models.RunningOutput, *recordOutput) {
+	o := &recordOutput{}
+	ro := models.NewRunningOutput(name, o,
+		&models.OutputConfig{Name: name, BufferDirectory: dir}, 10000, 10000)
+	ro.Fingerprint = fingerprint
+	return ro, o
+}
+
+// Test that no metric is lost by the outputs that are kept or replaced while
+// metrics are flowing.
+func TestReloadOutputs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "telegraf-reload")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	c := newTestConfig()
+	kept, keptOut := newReloadOutput("kept", "a", "")
+	memory, memoryOut := newReloadOutput("memory", "b", "")
+	disk, diskOut := newReloadOutput("disk", "c", dir)
+	require.NoError(t, disk.OpenDiskBuffer())
+	// metrics keep coming while the old disk output is closed.
+	diskOut.closeDelay = 20 * time.Millisecond
+	c.Outputs = []*models.RunningOutput{kept, memory, disk}
+	a, err := NewAgent(c)
+	require.NoError(t, err)
+
+	// the old disk output is closed once the plugins are unlocked.
+	unlocked := make(chan bool, 1)
+	diskOut.onClose = func() {
+		rlocked := make(chan struct{})
+		go func() {
+			a.pluginsMu.RLock()
+			a.pluginsMu.RUnlock()
+			close(rlocked)
+		}()
+		select {
+		case <-rlocked:
+			unlocked <- true
+		case <-time.After(time.Second):
+			unlocked <- false
+		}
+	}
+
+	shutdown := make(chan struct{})
+	done := startAgent(a, shutdown)
+
+	const n = 2000
+	sent := make(chan struct{})
+	go func() {
+		defer close(sent)
+		for i := 0; i < n; i++ {
+			a.metricC <- testMetric(t)
+			if i%5 == 0 {
+				time.Sleep(time.Millisecond)
+			}
+		}
+	}()
+
+	// the memory and disk outputs change, the new disk output takes over the
+	// directory of the old one.
+	keptCopy, keptCopyOut := newReloadOutput("kept", "a", "")
+	newMemory, newMemoryOut := newReloadOutput("memory", "b2", "")
+	newDisk, newDiskOut := newReloadOutput("disk", "c2", dir)
+	reloaded := config.NewConfig()
+	reloaded.AgentFingerprint = c.AgentFingerprint
+	reloaded.Outputs = []*models.RunningOutput{keptCopy, newMemory, newDisk}
+	// the flusher starts routing metrics after 300ms
+	time.Sleep(400 * time.Millisecond)
+	require.NoError(t, a.Reload(reloaded))
+	assert.True(t, <-unlocked)
+
+	// the removed outputs wrote their metrics when they were stopped, the
+	// kept output still buffers them.
+	nMemory, nDisk := memoryOut.Len(), diskOut.Len()
+	assert.Equal(t, 0, keptOut.Len())
+
+	<-sent
+	close(shutdown)
+	require.NoError(t, <-done)
+
+	assert.Equal(t, n, keptOut.Len())
+	assert.Equal(t, 0, keptCopyOut.Len())
+	assert.Equal(t, nMemory, memoryOut.Len())
+	assert.Equal(t, n, nMemory+newMemoryOut.Len())
+	assert.Equal(t, nDisk, diskOut.Len())
+	assert.Equal(t, n, nDisk+newDiskOut.Len())
+}
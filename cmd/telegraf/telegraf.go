@@ -1,8 +1,10 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"net/http"
 	_ "net/http/pprof" // Comment this line to disable pprof endpoint.
@@ -11,6 +13,7 @@ import (
 	"runtime"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/influxdata/telegraf/agent"
 	"github.com/influxdata/telegraf/internal/config"
@@ -21,6 +24,8 @@ import (
 	"github.com/influxdata/telegraf/plugins/outputs"
 	_ "github.com/influxdata/telegraf/plugins/outputs/all"
 	_ "github.com/influxdata/telegraf/plugins/processors/all"
+	_ "github.com/influxdata/telegraf/plugins/secretstores/all"
+	"github.com/influxdata/telegraf/plugins/secretstores/file"
 	"github.com/kardianos/service"
 )
 
@@ -34,6 +39,10 @@ var fTest = flag.Bool("test", false, "gather metrics, print them out, and exit")
 var fConfig = flag.String("config", "", "configuration file to load")
 var fConfigDirectory = flag.String("config-directory", "",
 	"directory containing additional *.conf files")
+var fConfigCA = flag.String("config-ca", "",
+	"CA file to verify the server when the config is loaded from a https URL")
+var fConfigPollInterval = flag.Duration("config-poll-interval", time.Minute,
+	"how often to check the config URL for changes, 0 to disable")
 var fVersion = flag.Bool("version", false, "display the version")
 var fSampleConfig = flag.Bool("sample-config", false,
 	"print out full sample configuration")
@@ -85,10 +94,18 @@ The commands & flags are:
 
   config              print out full sample configuration to stdout
   version             print the version to stdout
+  encrypt-secrets <key file> <secrets.json>
+                      encrypt a JSON object of secrets for the file secret
+                      store and print it to stdout
 
-  --config <file>     configuration file to load
+  --config <file>     configuration file or http(s) URL to load, the
+                      TELEGRAF_CONFIG_TOKEN environment variable is sent as
+                      a bearer token to the URL
   --test              gather metrics once, print them to stdout, and exit
   --config-directory  directory containing additional *.conf files
+  --config-ca         CA file to verify the server of a https config URL
+  --config-poll-interval  how often to check the config URL for changes,
+                      default 1m, 0 to disable
   --input-filter      filter the input plugins to enable, separator is :
   --output-filter     filter the output plugins to enable, separator is :
   --usage             print usage for a plugin, ie, 'telegraf --usage mysql'
@@ -113,8 +130,15 @@ Examples:
   # run telegraf, enabling the cpu & memory input, and influxdb output plugins
   telegraf --config telegraf.conf --input-filter cpu:mem --output-filter influxdb
 
+  # encrypt secrets for the file secret store
+  openssl rand -hex 32 > secrets.key
+  telegraf encrypt-secrets secrets.key secrets.json > secrets.enc
+
   # run telegraf with pprof
   telegraf --config telegraf.conf --pprof-addr localhost:6060
+
+  # run telegraf with a config served over https, reloading it when it changes
+  TELEGRAF_CONFIG_TOKEN=secret telegraf --config https://config.example.com/telegraf.conf
 `
 
 var stop chan struct{}
@@ -132,37 +156,11 @@ func reloadLoop(
 		reload <- false
 
 		// If no other options are specified, load the config file and run.
-		c := config.NewConfig()
-		c.OutputFilters = outputFilters
-		c.InputFilters = inputFilters
-		err := c.LoadConfig(*fConfig)
+		c, err := loadConfig(inputFilters, outputFilters, nil)
 		if err != nil {
 			log.Fatal("E! " + err.Error())
 		}
 
-		if *fConfigDirectory != "" {
-			err = c.LoadDirectory(*fConfigDirectory)
-			if err != nil {
-				log.Fatal("E! " + err.Error())
-			}
-		}
-		if !*fTest && len(c.Outputs) == 0 {
-			log.Fatalf("E! Error: no outputs found, did you provide a valid config file?")
-		}
-		if len(c.Inputs) == 0 {
-			log.Fatalf("E! Error: no inputs found, did you provide a valid config file?")
-		}
-
-		if int64(c.Agent.Interval.Duration) <= 0 {
-			log.Fatalf("E! Agent interval must be positive, found %s",
-				c.Agent.Interval.Duration)
-		}
-
-		if int64(c.Agent.FlushInterval.Duration) <= 0 {
-			log.Fatalf("E! Agent flush_interval must be positive; found %s",
-				c.Agent.Interval.Duration)
-		}
-
 		ag, err := agent.NewAgent(c)
 		if err != nil {
 			log.Fatal("E! " + err.Error())
@@ -191,20 +189,48 @@ func reloadLoop(
 		shutdown := make(chan struct{})
 		signals := make(chan os.Signal)
 		signal.Notify(signals, os.Interrupt, syscall.SIGHUP)
+		configChanged := make(chan []byte, 1)
+		if configSource != nil && *fConfigPollInterval > 0 {
+			go pollConfig(configSource, *fConfigPollInterval, configChanged, shutdown)
+		}
 		go func() {
-			select {
-			case sig := <-signals:
-				if sig == os.Interrupt {
-					close(shutdown)
-				}
-				if sig == syscall.SIGHUP {
+			for {
+				var data []byte
+				select {
+				case sig := <-signals:
+					if sig == os.Interrupt {
+						close(shutdown)
+						return
+					}
+					if sig != syscall.SIGHUP {
+						continue
+					}
 					log.Printf("I! Reloading Telegraf config\n")
-					<-reload
-					reload <- true
+				case data = <-configChanged:
+					log.Printf("I! Config at %s changed, reloading Telegraf config",
+						*fConfig)
+				case <-stop:
 					close(shutdown)
+					return
+				}
+
+				nc, err := loadConfig(inputFilters, outputFilters, data)
+				if err != nil {
+					log.Printf("E! Error reloading config, keeping the "+
+						"running config: %s", err)
+					continue
 				}
-			case <-stop:
+				// only the plugins that changed are restarted, unless
+				// the agent settings changed too.
+				err = ag.Reload(nc)
+				if err == nil {
+					continue
+				}
+				log.Printf("I! %s, restarting Telegraf", err)
+				<-reload
+				reload <- true
 				close(shutdown)
+				return
 			}
 		}()
 
@@ -231,10 +257,128 @@ func reloadLoop(
 			}
 		}
 
-		ag.Run(shutdown)
+		err = ag.Run(shutdown)
+		if err != nil {
+			log.Fatal("E! " + err.Error())
+		}
 	}
 }
 
+// configSource is the source of the config when it is loaded from a URL.
+var configSource *config.HTTPSource
+
+// pollConfig checks the config URL for changes every interval until shutdown
+// is closed, and sends the new config on changed when it changed.
+func pollConfig(
+	source *config.HTTPSource,
+	interval time.Duration,
+	changed chan []byte,
+	shutdown chan struct{},
+) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-shutdown:
+			return
+		case <-ticker.C:
+			data, err := source.Changed()
+			if err != nil {
+				log.Printf("E! Error checking %s for changes: %s", source.URL, err)
+				continue
+			}
+			if data == nil {
+				continue
+			}
+			// a change that was not loaded yet is replaced by the new one
+			select {
+			case <-changed:
+			default:
+			}
+			changed <- data
+		}
+	}
+}
+
+// loadConfig loads the config file or URL and directory and checks that the
+// config is usable. data is the config downloaded from the URL when it is
+// already known, it is fetched if nil.
+func loadConfig(inputFilters, outputFilters []string, data []byte) (*config.Config, error) {
+	c := config.NewConfig()
+	c.OutputFilters = outputFilters
+	c.InputFilters = inputFilters
+	var err error
+	if config.IsURL(*fConfig) {
+		if configSource == nil {
+			configSource, err = config.NewHTTPSource(*fConfig,
+				os.Getenv("TELEGRAF_CONFIG_TOKEN"), *fConfigCA)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if data == nil {
+			data, err = configSource.Fetch()
+			if err != nil {
+				return nil, fmt.Errorf("Error loading %s, %s", *fConfig, err)
+			}
+		}
+		err = c.LoadConfigData(data, *fConfig)
+	} else {
+		err = c.LoadConfig(*fConfig)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if *fConfigDirectory != "" {
+		err = c.LoadDirectory(*fConfigDirectory)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !*fTest && len(c.Outputs) == 0 {
+		return nil, fmt.Errorf("Error: no outputs found, did you provide a valid config file?")
+	}
+	if len(c.Inputs) == 0 {
+		return nil, fmt.Errorf("Error: no inputs found, did you provide a valid config file?")
+	}
+
+	if int64(c.Agent.Interval.Duration) <= 0 {
+		return nil, fmt.Errorf("Agent interval must be positive, found %s",
+			c.Agent.Interval.Duration)
+	}
+
+	if int64(c.Agent.FlushInterval.Duration) <= 0 {
+		return nil, fmt.Errorf("Agent flush_interval must be positive; found %s",
+			c.Agent.Interval.Duration)
+	}
+	return c, nil
+}
+
+// encryptSecrets encrypts the JSON object of secrets in secretsFile with the
+// key in keyFile and prints it to stdout.
+func encryptSecrets(keyFile, secretsFile string) error {
+	key, err := file.ReadKey(keyFile)
+	if err != nil {
+		return err
+	}
+	data, err := ioutil.ReadFile(secretsFile)
+	if err != nil {
+		return err
+	}
+	secrets := make(map[string]string)
+	if err := json.Unmarshal(data, &secrets); err != nil {
+		return fmt.Errorf("Error parsing %s, %s", secretsFile, err)
+	}
+
+	encrypted, err := file.Encrypt(key, secrets)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(encrypted)
+	return err
+}
+
 func usageExit(rc int) {
 	fmt.Println(usage)
 	os.Exit(rc)
@@ -324,6 +468,14 @@ func main() {
 				processorFilters,
 			)
 			return
+		case "encrypt-secrets":
+			if len(args) != 3 {
+				usageExit(1)
+			}
+			if err := encryptSecrets(args[1], args[2]); err != nil {
+				log.Fatal("E! " + err.Error())
+			}
+			return
 		}
 	}
 
@@ -385,6 +537,13 @@ func main() {
 		if *fService != "" {
 			if *fConfig != "" {
 				(*svcConfig).Arguments = []string{"-config", *fConfig}
+				if *fConfigCA != "" {
+					(*svcConfig).Arguments = append((*svcConfig).Arguments, "-config-ca", *fConfigCA)
+				}
+				if *fConfigPollInterval != time.Minute {
+					(*svcConfig).Arguments = append((*svcConfig).Arguments,
+						"-config-poll-interval", fConfigPollInterval.String())
+				}
 			}
 			if *fConfigDirectory != "" {
 				(*svcConfig).Arguments = append((*svcConfig).Arguments, "-config-directory", *fConfigDirectory)
@@ -0,0 +1,533 @@
+package buffer
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+const (
+	// Default maximum size of a single segment file.
+	DEFAULT_SEGMENT_SIZE = 8 * 1024 * 1024
+
+	segmentExt = ".seg"
+	cursorFile = "cursor"
+)
+
+// DiskBuffer is a write-ahead buffer that persists metrics to segmented,
+// append-only files in a directory. Metrics are only removed from the
+// buffer once a batch has been accepted, so anything that has not been
+// written to the output yet is replayed when the buffer is opened again.
+//
+// Each record in a segment is a single line holding the numeric
+// telegraf.ValueType of the metric followed by its line-protocol form.
+type DiskBuffer struct {
+	dir         string
+	segmentSize int64
+	maxSize     int64
+	maxAge      time.Duration
+
+	// segments are ordered oldest first. The read cursor always points into
+	// segments[0] and new records are always appended to the last segment.
+	segments []*segment
+	writer   *os.File
+	nextID   uint64
+
+	readOff  int64
+	readRecs int
+
+	// position after the last batch returned by Batch, committed by Accept.
+	pending *position
+
+	mu sync.Mutex
+}
+
+type segment struct {
+	id      uint64
+	path    string
+	size    int64
+	records int
+	modTime time.Time
+}
+
+type position struct {
+	seg  int
+	off  int64
+	recs int
+}
+
+// NewDiskBuffer opens the disk buffer stored in dir, creating the directory
+// if needed, and replays any metrics that have not been accepted yet.
+//   segmentSize is the size in bytes after which a new segment is started.
+//   maxSize is the maximum size in bytes of all segments, 0 means unlimited.
+//   maxAge is the maximum age of a segment, 0 means unlimited.
+// When either limit is exceeded the oldest segment is dropped.
+func NewDiskBuffer(
+	dir string,
+	segmentSize int64,
+	maxSize int64,
+	maxAge time.Duration,
+) (*DiskBuffer, error) {
+	if segmentSize <= 0 {
+		segmentSize = DEFAULT_SEGMENT_SIZE
+	}
+	if maxSize > 0 && segmentSize > maxSize {
+		segmentSize = maxSize
+	}
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return nil, err
+	}
+
+	b := &DiskBuffer{
+		dir:         dir,
+		segmentSize: segmentSize,
+		maxSize:     maxSize,
+		maxAge:      maxAge,
+	}
+	if err := b.load(); err != nil {
+		return nil, err
+	}
+	if err := b.rotate(); err != nil {
+		return nil, err
+	}
+	b.enforceLimits()
+	return b, nil
+}
+
+// load scans the buffer directory for existing segments and restores the
+// read cursor.
+func (b *DiskBuffer) load() error {
+	files, err := ioutil.ReadDir(b.dir)
+	if err != nil {
+		return err
+	}
+	for _, info := range files {
+		name := info.Name()
+		if info.IsDir() || !strings.HasSuffix(name, segmentExt) {
+			continue
+		}
+		id, err := strconv.ParseUint(strings.TrimSuffix(name, segmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		s := &segment{
+			id:      id,
+			path:    filepath.Join(b.dir, name),
+			modTime: info.ModTime(),
+		}
+		if s.size, s.records, err = countRecords(s.path); err != nil {
+			return err
+		}
+		if id >= b.nextID {
+			b.nextID = id + 1
+		}
+		if s.records == 0 {
+			// nothing to replay, the segment was never written to.
+			os.Remove(s.path)
+			continue
+		}
+		b.segments = append(b.segments, s)
+	}
+	sort.Slice(b.segments, func(i, j int) bool {
+		return b.segments[i].id < b.segments[j].id
+	})
+
+	id, off, recs, err := b.readCursor()
+	if err != nil {
+		return err
+	}
+	// Remove the segments that were completely read before the last shutdown.
+	for len(b.segments) > 0 && b.segments[0].id < id {
+		b.removeSegment(0)
+	}
+	if len(b.segments) > 0 && b.segments[0].id == id &&
+		off <= b.segments[0].size && recs <= b.segments[0].records {
+		b.readOff, b.readRecs = off, recs
+	}
+	return nil
+}
+
+// countRecords returns the size of the complete records in the given
+// segment file and how many there are. A partially written record at the
+// end of the file, as left behind by a crash, is ignored.
+func countRecords(path string) (int64, int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+
+	var size int64
+	var records int
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			return size, records, nil
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+		size += int64(len(line))
+		records++
+	}
+}
+
+func (b *DiskBuffer) readCursor() (uint64, int64, int, error) {
+	contents, err := ioutil.ReadFile(filepath.Join(b.dir, cursorFile))
+	if os.IsNotExist(err) {
+		return 0, 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	var id uint64
+	var off int64
+	var recs int
+	_, err = fmt.Sscanf(string(contents), "%d %d %d", &id, &off, &recs)
+	if err != nil {
+		log.Printf("W! Ignoring invalid disk buffer cursor in %s: %s", b.dir, err)
+		return 0, 0, 0, nil
+	}
+	return id, off, recs, nil
+}
+
+// writeCursor atomically persists the read cursor.
+func (b *DiskBuffer) writeCursor() error {
+	var id uint64
+	if len(b.segments) > 0 {
+		id = b.segments[0].id
+	}
+	tmp := filepath.Join(b.dir, cursorFile+".tmp")
+	contents := fmt.Sprintf("%d %d %d\n", id, b.readOff, b.readRecs)
+	if err := ioutil.WriteFile(tmp, []byte(contents), 0640); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(b.dir, cursorFile))
+}
+
+// rotate closes the current segment and starts a new one.
+func (b *DiskBuffer) rotate() error {
+	if b.writer != nil {
+		b.writer.Sync()
+		b.writer.Close()
+		b.writer = nil
+	}
+	s := &segment{
+		id:      b.nextID,
+		path:    filepath.Join(b.dir, fmt.Sprintf("%020d%s", b.nextID, segmentExt)),
+		modTime: time.Now(),
+	}
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640)
+	if err != nil {
+		return err
+	}
+	b.nextID++
+	b.writer = f
+	b.segments = append(b.segments, s)
+	return nil
+}
+
+// removeSegment deletes the segment at index i from disk. If it is the
+// segment currently being read, the read cursor moves on to the next one.
+func (b *DiskBuffer) removeSegment(i int) {
+	s := b.segments[i]
+	if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+		log.Printf("E! Could not remove disk buffer segment %s: %s", s.path, err)
+	}
+	b.segments = append(b.segments[:i], b.segments[i+1:]...)
+	if i == 0 {
+		b.readOff, b.readRecs = 0, 0
+	}
+
+	if b.pending != nil {
+		if b.pending.seg > i {
+			b.pending.seg--
+		} else {
+			// the batch pointed into the removed segment and is gone with it.
+			b.pending = nil
+		}
+	}
+}
+
+// dropOldest drops the oldest segment, including any unread metrics in it.
+func (b *DiskBuffer) dropOldest() {
+	if len(b.segments) == 1 {
+		// the only segment is the one being written, start a fresh one
+		// before removing it.
+		if err := b.rotate(); err != nil {
+			log.Printf("E! Could not create disk buffer segment in %s: %s",
+				b.dir, err)
+			return
+		}
+	}
+	dropped := b.segments[0].records - b.readRecs
+	b.removeSegment(0)
+	if dropped > 0 {
+		MetricsDropped.Incr(int64(dropped))
+		log.Printf("W! Disk buffer %s dropped %d metrics", b.dir, dropped)
+	}
+}
+
+// enforceLimits drops the oldest segments until the buffer is within its
+// size and age limits again.
+func (b *DiskBuffer) enforceLimits() {
+	if b.maxSize > 0 {
+		for len(b.segments) > 1 && b.size() > b.maxSize {
+			b.dropOldest()
+		}
+	}
+	if b.maxAge > 0 {
+		cutoff := time.Now().Add(-b.maxAge)
+		for len(b.segments) > 0 && b.segments[0].records > 0 &&
+			b.segments[0].modTime.Before(cutoff) {
+			b.dropOldest()
+		}
+	}
+}
+
+func (b *DiskBuffer) size() int64 {
+	var size int64
+	for _, s := range b.segments {
+		size += s.size
+	}
+	return size
+}
+
+// IsEmpty returns true if there are no unaccepted metrics in the buffer.
+func (b *DiskBuffer) IsEmpty() bool {
+	return b.Len() == 0
+}
+
+// Len returns the number of unaccepted metrics in the buffer.
+func (b *DiskBuffer) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	n := -b.readRecs
+	for _, s := range b.segments {
+		n += s.records
+	}
+	return n
+}
+
+// Add appends metrics to the current segment. Tracked metrics are accepted
+// once they have been written and synced to disk.
+func (b *DiskBuffer) Add(metrics ...telegraf.Metric) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	written := make([]telegraf.Metric, 0, len(metrics))
+	var buf bytes.Buffer
+	for _, m := range metrics {
+		buf.Reset()
+		buf.WriteString(strconv.Itoa(int(m.Type())))
+		buf.WriteByte(' ')
+		buf.Write(m.Serialize())
+
+		s := b.segments[len(b.segments)-1]
+		if s.records > 0 && s.size+int64(buf.Len()) > b.segmentSize {
+			if err := b.rotate(); err != nil {
+				log.Printf("E! Could not create disk buffer segment in %s: %s",
+					b.dir, err)
+				MetricsDropped.Incr(1)
+				m.Reject()
+				continue
+			}
+			s = b.segments[len(b.segments)-1]
+		}
+
+		n, err := b.writer.Write(buf.Bytes())
+		if err != nil {
+			log.Printf("E! Could not write to disk buffer segment %s: %s",
+				s.path, err)
+			MetricsDropped.Incr(1)
+			m.Reject()
+			if n > 0 {
+				// don't leave a partial record behind.
+				b.writer.Truncate(s.size)
+			}
+			continue
+		}
+		written = append(written, m)
+		MetricsWritten.Incr(1)
+		s.size += int64(n)
+		s.records++
+		s.modTime = time.Now()
+	}
+
+	// the inputs may acknowledge the tracked metrics to their source once
+	// they are accepted, so they must not be lost on a crash. The other
+	// metrics are synced when the segment is rotated or closed.
+	var err error
+	for _, m := range written {
+		if metric.IsTracking(m) {
+			if err = b.writer.Sync(); err != nil {
+				log.Printf("E! Could not sync disk buffer segment in %s: %s",
+					b.dir, err)
+			}
+			break
+		}
+	}
+	for _, m := range written {
+		if err != nil {
+			m.Reject()
+		} else {
+			m.Accept()
+		}
+	}
+	b.enforceLimits()
+}
+
+// Batch returns up to batchSize of the oldest unaccepted metrics. The
+// metrics stay in the buffer until Accept is called, calling Reject (or
+// Batch again) returns them once more.
+func (b *DiskBuffer) Batch(batchSize int) []telegraf.Metric {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.enforceLimits()
+
+	out := make([]telegraf.Metric, 0, batchSize)
+	pos := position{off: b.readOff, recs: b.readRecs}
+	n := 0
+	for n < batchSize && pos.seg < len(b.segments) {
+		s := b.segments[pos.seg]
+		metrics, records, off, err := s.read(pos.off, batchSize-n)
+		if err != nil {
+			log.Printf("E! Could not read disk buffer segment %s: %s", s.path, err)
+			break
+		}
+		out = append(out, metrics...)
+		n += records
+		pos.off = off
+		pos.recs += records
+
+		if pos.off < s.size || pos.seg == len(b.segments)-1 {
+			break
+		}
+		pos.seg++
+		pos.off, pos.recs = 0, 0
+	}
+	b.pending = &pos
+	return out
+}
+
+// Accept removes the metrics returned by the last call to Batch from the
+// buffer.
+func (b *DiskBuffer) Accept() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.pending == nil {
+		return
+	}
+	pos := *b.pending
+	for i := 0; i < pos.seg; i++ {
+		b.removeSegment(0)
+	}
+	b.readOff, b.readRecs = pos.off, pos.recs
+	b.pending = nil
+
+	if err := b.writeCursor(); err != nil {
+		log.Printf("E! Could not write disk buffer cursor in %s: %s", b.dir, err)
+	}
+}
+
+// Reject keeps the metrics returned by the last call to Batch in the buffer,
+// they are returned again by the next call to Batch.
+func (b *DiskBuffer) Reject() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.pending = nil
+}
+
+// Close syncs the current segment and persists the read cursor.
+func (b *DiskBuffer) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.writer != nil {
+		b.writer.Sync()
+		if err := b.writer.Close(); err != nil {
+			return err
+		}
+		b.writer = nil
+	}
+	return b.writeCursor()
+}
+
+// read reads up to n records starting at offset off. It returns the parsed
+// metrics, the number of records consumed and the offset of the next record.
+// Records that can not be parsed are logged and skipped.
+func (s *segment) read(off int64, n int) ([]telegraf.Metric, int, int64, error) {
+	f, err := os.Open(s.path)
+	if err != nil {
+		return nil, 0, off, err
+	}
+	defer f.Close()
+	if _, err := f.Seek(off, io.SeekStart); err != nil {
+		return nil, 0, off, err
+	}
+
+	var metrics []telegraf.Metric
+	records := 0
+	r := bufio.NewReader(io.LimitReader(f, s.size-off))
+	for records < n {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return metrics, records, off, err
+		}
+		off += int64(len(line))
+		records++
+
+		m, err := parseRecord(line)
+		if err != nil {
+			log.Printf("E! Skipping invalid record in disk buffer segment %s: %s",
+				s.path, err)
+			MetricsDropped.Incr(1)
+			continue
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, records, off, nil
+}
+
+func parseRecord(line []byte) (telegraf.Metric, error) {
+	i := bytes.IndexByte(line, ' ')
+	if i < 0 {
+		return nil, fmt.Errorf("missing value type")
+	}
+	mType, err := strconv.Atoi(string(line[:i]))
+	if err != nil {
+		return nil, err
+	}
+	metrics, err := metric.Parse(line[i+1:])
+	if err != nil {
+		return nil, err
+	}
+	if len(metrics) != 1 {
+		return nil, fmt.Errorf("expected 1 metric, got %d", len(metrics))
+	}
+	m := metrics[0]
+	if telegraf.ValueType(mType) == m.Type() {
+		return m, nil
+	}
+	return metric.New(m.Name(), m.Tags(), m.Fields(), m.Time(),
+		telegraf.ValueType(mType))
+}
@@ -0,0 +1,214 @@
+package buffer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "telegraf-disk-buffer")
+	require.NoError(t, err)
+	return dir
+}
+
+func names(metrics []telegraf.Metric) []string {
+	out := make([]string, len(metrics))
+	for i, m := range metrics {
+		out[i] = m.Name()
+	}
+	return out
+}
+
+func TestDiskBufferBatchAcceptReject(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b, err := NewDiskBuffer(dir, 0, 0, 0)
+	require.NoError(t, err)
+	defer b.Close()
+
+	assert.True(t, b.IsEmpty())
+	b.Add(metricList...)
+	assert.Equal(t, 5, b.Len())
+
+	batch := b.Batch(2)
+	assert.Equal(t, []string{"mymetric1", "mymetric2"}, names(batch))
+	b.Reject()
+	assert.Equal(t, 5, b.Len())
+
+	batch = b.Batch(2)
+	assert.Equal(t, []string{"mymetric1", "mymetric2"}, names(batch))
+	b.Accept()
+	assert.Equal(t, 3, b.Len())
+
+	batch = b.Batch(10)
+	assert.Equal(t, []string{"mymetric3", "mymetric4", "mymetric5"}, names(batch))
+	b.Accept()
+	assert.True(t, b.IsEmpty())
+}
+
+// Test that tracked metrics are delivered once they are in the buffer.
+func TestDiskBufferDeliversTracked(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b, err := NewDiskBuffer(dir, 0, 0, 0)
+	require.NoError(t, err)
+	defer b.Close()
+
+	var delivered bool
+	m, _ := metric.WithTracking(testutil.TestMetric(1, "cpu"),
+		func(info telegraf.DeliveryInfo) { delivered = info.Delivered() })
+	b.Add(m)
+	assert.True(t, delivered)
+	assert.Equal(t, 1, b.Len())
+}
+
+func TestDiskBufferReplay(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b, err := NewDiskBuffer(dir, 0, 0, 0)
+	require.NoError(t, err)
+	b.Add(metricList...)
+	b.Batch(2)
+	b.Accept()
+	// an unaccepted batch must be replayed after a restart.
+	b.Batch(1)
+	require.NoError(t, b.Close())
+
+	b, err = NewDiskBuffer(dir, 0, 0, 0)
+	require.NoError(t, err)
+	defer b.Close()
+	assert.Equal(t, 3, b.Len())
+	batch := b.Batch(10)
+	assert.Equal(t, []string{"mymetric3", "mymetric4", "mymetric5"}, names(batch))
+	assert.Equal(t, metricList[2].Fields(), batch[0].Fields())
+	assert.Equal(t, metricList[2].Tags(), batch[0].Tags())
+	assert.Equal(t, metricList[2].Time(), batch[0].Time())
+}
+
+func TestDiskBufferKeepsValueType(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b, err := NewDiskBuffer(dir, 0, 0, 0)
+	require.NoError(t, err)
+	defer b.Close()
+
+	m, err := metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 42.0},
+		time.Unix(0, 0), telegraf.Counter)
+	require.NoError(t, err)
+	b.Add(m)
+
+	batch := b.Batch(1)
+	require.Len(t, batch, 1)
+	assert.Equal(t, telegraf.Counter, batch[0].Type())
+}
+
+func TestDiskBufferSegments(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	m := testutil.TestMetric(1, "mymetric")
+	// room for two records per segment
+	size := int64(2*(len(m.Serialize())+2) + 1)
+	b, err := NewDiskBuffer(dir, size, 0, 0)
+	require.NoError(t, err)
+	defer b.Close()
+
+	for i := 0; i < 5; i++ {
+		b.Add(m)
+	}
+	segments, err := filepath.Glob(filepath.Join(dir, "*"+segmentExt))
+	require.NoError(t, err)
+	assert.Len(t, segments, 3)
+
+	// batches span segments
+	assert.Len(t, b.Batch(3), 3)
+	b.Accept()
+	assert.Equal(t, 2, b.Len())
+	segments, err = filepath.Glob(filepath.Join(dir, "*"+segmentExt))
+	require.NoError(t, err)
+	assert.Len(t, segments, 2)
+
+	assert.Len(t, b.Batch(10), 2)
+	b.Accept()
+	assert.True(t, b.IsEmpty())
+}
+
+func TestDiskBufferMaxSize(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	MetricsDropped.Set(0)
+
+	m := testutil.TestMetric(1, "mymetric")
+	record := int64(len(m.Serialize()) + 2)
+	b, err := NewDiskBuffer(dir, 2*record, 4*record, 0)
+	require.NoError(t, err)
+	defer b.Close()
+
+	for i := 0; i < 6; i++ {
+		b.Add(m)
+	}
+	// the oldest segment was dropped to stay within the limit.
+	assert.Equal(t, 4, b.Len())
+	assert.Equal(t, int64(2), MetricsDropped.Get())
+}
+
+func TestDiskBufferMaxAge(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b, err := NewDiskBuffer(dir, 0, 0, time.Hour)
+	require.NoError(t, err)
+	b.Add(metricList...)
+	require.NoError(t, b.Close())
+
+	old := time.Now().Add(-2 * time.Hour)
+	segments, err := filepath.Glob(filepath.Join(dir, "*"+segmentExt))
+	require.NoError(t, err)
+	for _, s := range segments {
+		require.NoError(t, os.Chtimes(s, old, old))
+	}
+
+	b, err = NewDiskBuffer(dir, 0, 0, time.Hour)
+	require.NoError(t, err)
+	defer b.Close()
+	assert.True(t, b.IsEmpty())
+}
+
+func TestDiskBufferIgnoresPartialRecord(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b, err := NewDiskBuffer(dir, 0, 0, 0)
+	require.NoError(t, err)
+	b.Add(metricList[0])
+	require.NoError(t, b.Close())
+
+	segments, err := filepath.Glob(filepath.Join(dir, "*"+segmentExt))
+	require.NoError(t, err)
+	require.Len(t, segments, 1)
+	f, err := os.OpenFile(segments[0], os.O_WRONLY|os.O_APPEND, 0640)
+	require.NoError(t, err)
+	_, err = f.WriteString("0 mymetric,tag1=val")
+	require.NoError(t, err)
+	f.Close()
+
+	b, err = NewDiskBuffer(dir, 0, 0, 0)
+	require.NoError(t, err)
+	defer b.Close()
+	assert.Equal(t, 1, b.Len())
+	assert.Equal(t, []string{"mymetric1"}, names(b.Batch(10)))
+}
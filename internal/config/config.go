@@ -2,6 +2,8 @@ package config
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -19,11 +21,13 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/internal/models"
+	"github.com/influxdata/telegraf/internal/redact"
 	"github.com/influxdata/telegraf/plugins/aggregators"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/outputs"
 	"github.com/influxdata/telegraf/plugins/parsers"
 	"github.com/influxdata/telegraf/plugins/processors"
+	"github.com/influxdata/telegraf/plugins/secretstores"
 	"github.com/influxdata/telegraf/plugins/serializers"
 
 	"github.com/influxdata/toml"
@@ -40,8 +44,31 @@ var (
 
 	// envVarRe is a regex to find environment variables in the config file
 	envVarRe = regexp.MustCompile(`\$\w+`)
+
+	// secretRe is a regex to find secret references, ie "@{store:key}"
+	secretRe = regexp.MustCompile(`@\{([^:{}]+):([^{}]+)\}`)
+)
+
+const (
+	// Default maximum size of an output's disk buffer.
+	defaultBufferMaxSize = 1024 * 1024 * 1024
+
+	// Default delays between attempts to write to a failing output.
+	defaultRetryBackoff    = time.Second
+	defaultRetryMaxBackoff = time.Minute
 )
 
+var sizeUnits = map[string]int64{
+	"":    1,
+	"B":   1,
+	"KB":  1000,
+	"MB":  1000 * 1000,
+	"GB":  1000 * 1000 * 1000,
+	"KIB": 1024,
+	"MIB": 1024 * 1024,
+	"GIB": 1024 * 1024 * 1024,
+}
+
 // Config specifies the URL/user/password for the database that telegraf
 // will be logging to, as well as all the plugins that the user has
 // specified
@@ -56,6 +83,14 @@ type Config struct {
 	Aggregators []*models.RunningAggregator
 	// Processors have a slice wrapper type because they need to be sorted
 	Processors models.RunningProcessors
+
+	// SecretStores by id, the secrets they hold are referenced in the
+	// config as "@{id:key}"
+	SecretStores map[string]telegraf.SecretStore
+
+	// Fingerprint of the agent and global tags settings. Plugins can only
+	// be reloaded one by one when it did not change.
+	AgentFingerprint string
 }
 
 func NewConfig() *Config {
@@ -65,12 +100,17 @@ func NewConfig() *Config {
 			Interval:      internal.Duration{Duration: 10 * time.Second},
 			RoundInterval: true,
 			FlushInterval: internal.Duration{Duration: 10 * time.Second},
+
+			HealthWindow:         internal.Duration{Duration: time.Minute},
+			HealthMaxBufferFill:  0.9,
+			HealthMaxWriteErrors: 3,
 		},
 
 		Tags:          make(map[string]string),
 		Inputs:        make([]*models.RunningInput, 0),
 		Outputs:       make([]*models.RunningOutput, 0),
 		Processors:    make([]*models.RunningProcessor, 0),
+		SecretStores:  make(map[string]telegraf.SecretStore),
 		InputFilters:  make([]string, 0),
 		OutputFilters: make([]string, 0),
 	}
@@ -139,6 +179,26 @@ type AgentConfig struct {
 	Quiet        bool
 	Hostname     string
 	OmitHostname bool
+
+	// HealthAddress is the address of the health check HTTP listener, it is
+	// disabled when empty.
+	HealthAddress string `toml:"health_address"`
+
+	// HealthWindow is the period over which errors are counted by the
+	// health checks.
+	HealthWindow internal.Duration `toml:"health_window"`
+
+	// HealthMaxBufferFill is the fraction of its buffer limit an output
+	// buffer can fill before the agent is unhealthy, 0 disables the check.
+	HealthMaxBufferFill float64 `toml:"health_max_buffer_fill"`
+
+	// HealthMaxWriteErrors is the number of failed writes of an output within
+	// HealthWindow making the agent unhealthy, 0 disables the check.
+	HealthMaxWriteErrors int64 `toml:"health_max_write_errors"`
+
+	// HealthMaxGatherErrors is the number of errors of an input within
+	// HealthWindow making the agent unhealthy, 0 disables the check.
+	HealthMaxGatherErrors int64 `toml:"health_max_gather_errors"`
 }
 
 // Inputs returns a list of strings of the configured inputs.
@@ -252,6 +312,21 @@ var header = `# Telegraf Configuration
   ## If set to true, do no set the "host" tag in the telegraf agent.
   omit_hostname = false
 
+  ## Address of the health check listener, answering on /health with 200 when
+  ## the agent is healthy and 503 otherwise. Disabled when empty.
+  # health_address = "localhost:8888"
+  ## Period over which write and gather errors are counted.
+  # health_window = "1m"
+  ## Unhealthy when an output buffer is filled above this fraction of
+  ## metric_buffer_limit, 0 disables the check.
+  # health_max_buffer_fill = 0.9
+  ## Unhealthy when an output fails this many writes within health_window,
+  ## 0 disables the check.
+  # health_max_write_errors = 3
+  ## Unhealthy when an input has this many errors within health_window,
+  ## 0 disables the check.
+  # health_max_gather_errors = 0
+
 
 ###############################################################################
 #                            OUTPUT PLUGINS                                   #
@@ -565,14 +640,59 @@ func (c *Config) LoadConfig(path string) error {
 	if err != nil {
 		return fmt.Errorf("Error parsing %s, %s", path, err)
 	}
+	return c.loadTable(path, tbl)
+}
+
+// LoadConfigData loads a configuration that was read from path, ie from a
+// URL, into c.
+func (c *Config) LoadConfigData(data []byte, path string) error {
+	tbl, err := parseConfig(data)
+	if err != nil {
+		return fmt.Errorf("Error parsing %s, %s", path, err)
+	}
+	return c.loadTable(path, tbl)
+}
+
+func (c *Config) loadTable(path string, tbl *ast.Table) error {
+	var err error
+
+	// Parse secret stores first, the other tables may reference their
+	// secrets:
+	if val, ok := tbl.Fields["secretstores"]; ok {
+		subTable, ok := val.(*ast.Table)
+		if !ok {
+			return fmt.Errorf("%s: invalid configuration", path)
+		}
+		for storeName, storeVal := range subTable.Fields {
+			storeTables, ok := storeVal.([]*ast.Table)
+			if !ok {
+				return fmt.Errorf("Unsupported config format: %s, file %s",
+					storeName, path)
+			}
+			for _, t := range storeTables {
+				if err = c.addSecretStore(storeName, t); err != nil {
+					return fmt.Errorf("Error parsing %s, %s", path, err)
+				}
+			}
+		}
+	}
+	for name, val := range tbl.Fields {
+		if name == "secretstores" {
+			continue
+		}
+		if err = c.resolveSecrets(val); err != nil {
+			return fmt.Errorf("Error parsing %s, %s", path, err)
+		}
+	}
 
-	// Parse tags tables first:
+	// Parse tags tables:
 	for _, tableName := range []string{"tags", "global_tags"} {
 		if val, ok := tbl.Fields[tableName]; ok {
 			subTable, ok := val.(*ast.Table)
 			if !ok {
 				return fmt.Errorf("%s: invalid configuration", path)
 			}
+			c.AgentFingerprint += fingerprint(tableName, subTable)
 			if err = toml.UnmarshalTable(subTable, c.Tags); err != nil {
 				log.Printf("E! Could not parse [global_tags] config\n")
 				return fmt.Errorf("Error parsing %s, %s", path, err)
@@ -586,6 +706,7 @@ func (c *Config) LoadConfig(path string) error {
 		if !ok {
 			return fmt.Errorf("%s: invalid configuration", path)
 		}
+		c.AgentFingerprint += fingerprint("agent", subTable)
 		if err = toml.UnmarshalTable(subTable, c.Agent); err != nil {
 			log.Printf("E! Could not parse [agent] config\n")
 			return fmt.Errorf("Error parsing %s, %s", path, err)
@@ -600,7 +721,7 @@ func (c *Config) LoadConfig(path string) error {
 		}
 
 		switch name {
-		case "agent", "global_tags", "tags":
+		case "agent", "global_tags", "tags", "secretstores":
 		case "outputs":
 			for pluginName, pluginVal := range subTable.Fields {
 				switch pluginSubTable := pluginVal.(type) {
@@ -697,6 +818,11 @@ func parseFile(fpath string) (*ast.Table, error) {
 	if err != nil {
 		return nil, err
 	}
+	return parseConfig(contents)
+}
+
+// parseConfig substitutes environment variables in contents and parses it.
+func parseConfig(contents []byte) (*ast.Table, error) {
 	// ugh windows why
 	contents = trimBOM(contents)
 
@@ -711,12 +837,95 @@ func parseFile(fpath string) (*ast.Table, error) {
 	return toml.Parse(contents)
 }
 
+func (c *Config) addSecretStore(name string, table *ast.Table) error {
+	creator, ok := secretstores.SecretStores[name]
+	if !ok {
+		return fmt.Errorf("Undefined but requested secret store: %s", name)
+	}
+	store := creator()
+
+	var id string
+	if node, ok := table.Fields["id"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				id = str.Value
+			}
+		}
+	}
+	delete(table.Fields, "id")
+	if id == "" {
+		return fmt.Errorf("secret store %s has no id", name)
+	}
+	if _, ok := c.SecretStores[id]; ok {
+		return fmt.Errorf("duplicate secret store id %q", id)
+	}
+
+	if err := toml.UnmarshalTable(table, store); err != nil {
+		return err
+	}
+	c.SecretStores[id] = store
+	return nil
+}
+
+// resolveSecrets replaces the secret references in the strings of val with
+// the secrets. The secrets are registered to be redacted from the output.
+func (c *Config) resolveSecrets(val interface{}) error {
+	switch v := val.(type) {
+	case *ast.Table:
+		for _, field := range v.Fields {
+			if err := c.resolveSecrets(field); err != nil {
+				return err
+			}
+		}
+	case []*ast.Table:
+		for _, t := range v {
+			if err := c.resolveSecrets(t); err != nil {
+				return err
+			}
+		}
+	case *ast.KeyValue:
+		return c.resolveSecrets(v.Value)
+	case *ast.Array:
+		for _, elem := range v.Value {
+			if err := c.resolveSecrets(elem); err != nil {
+				return err
+			}
+		}
+	case *ast.String:
+		var buf bytes.Buffer
+		last := 0
+		for _, loc := range secretRe.FindAllStringSubmatchIndex(v.Value, -1) {
+			id, key := v.Value[loc[2]:loc[3]], v.Value[loc[4]:loc[5]]
+			store, ok := c.SecretStores[id]
+			if !ok {
+				return fmt.Errorf("unknown secret store %q", id)
+			}
+			secret, err := store.Get(key)
+			if err != nil {
+				return fmt.Errorf("unable to get secret %q from store %q, %s",
+					key, id, err)
+			}
+			redact.Add(secret)
+
+			buf.WriteString(v.Value[last:loc[0]])
+			buf.WriteString(secret)
+			last = loc[1]
+		}
+		if last > 0 {
+			buf.WriteString(v.Value[last:])
+			v.Value = buf.String()
+		}
+	}
+	return nil
+}
+
 func (c *Config) addAggregator(name string, table *ast.Table) error {
 	creator, ok := aggregators.Aggregators[name]
 	if !ok {
 		return fmt.Errorf("Undefined but requested aggregator: %s", name)
 	}
 	aggregator := creator()
+	fp := fingerprint("aggregators."+name, table)
 
 	conf, err := buildAggregator(name, table)
 	if err != nil {
@@ -727,7 +936,13 @@ func (c *Config) addAggregator(name string, table *ast.Table) error {
 		return err
 	}
 
-	c.Aggregators = append(c.Aggregators, models.NewRunningAggregator(aggregator, conf))
+	if err := initPlugin("aggregator", name, aggregator); err != nil {
+		return err
+	}
+
+	ra := models.NewRunningAggregator(aggregator, conf)
+	ra.Fingerprint = fp
+	c.Aggregators = append(c.Aggregators, ra)
 	return nil
 }
 
@@ -737,8 +952,10 @@ func (c *Config) addProcessor(name string, table *ast.Table) error {
 		return fmt.Errorf("Undefined but requested processor: %s", name)
 	}
 	processor := creator()
+	fp := fingerprint("processors."+name, table)
 
-	processorConfig, err := buildProcessor(name, table)
+	_, windowed := processor.(telegraf.WindowedProcessor)
+	processorConfig, err := buildProcessor(name, table, windowed)
 	if err != nil {
 		return err
 	}
@@ -747,16 +964,33 @@ func (c *Config) addProcessor(name string, table *ast.Table) error {
 		return err
 	}
 
+	if err := initPlugin("processor", name, processor); err != nil {
+		return err
+	}
+
 	rf := &models.RunningProcessor{
 		Name:      name,
 		Processor: processor,
 		Config:    processorConfig,
+
+		Fingerprint: fp,
 	}
 
 	c.Processors = append(c.Processors, rf)
 	return nil
 }
 
+// initPlugin calls the Init function of the plugins implementing
+// telegraf.Initializer.
+func initPlugin(kind string, name string, plugin interface{}) error {
+	if p, ok := plugin.(telegraf.Initializer); ok {
+		if err := p.Init(); err != nil {
+			return fmt.Errorf("Could not initialize %s %s: %s", kind, name, err)
+		}
+	}
+	return nil
+}
+
 func (c *Config) addOutput(name string, table *ast.Table) error {
 	if len(c.OutputFilters) > 0 && !sliceContains(name, c.OutputFilters) {
 		return nil
@@ -766,6 +1000,7 @@ func (c *Config) addOutput(name string, table *ast.Table) error {
 		return fmt.Errorf("Undefined but requested output: %s", name)
 	}
 	output := creator()
+	fp := fingerprint("outputs."+name, table)
 
 	// If the output has a SetSerializer function, then this means it can write
 	// arbitrary types of output, so build the serializer and set it.
@@ -787,8 +1022,25 @@ func (c *Config) addOutput(name string, table *ast.Table) error {
 		return err
 	}
 
+	if err := initPlugin("output", name, output); err != nil {
+		return err
+	}
+
+	batchSize := c.Agent.MetricBatchSize
+	if outputConfig.MetricBatchSize > 0 {
+		batchSize = outputConfig.MetricBatchSize
+	}
 	ro := models.NewRunningOutput(name, output, outputConfig,
-		c.Agent.MetricBatchSize, c.Agent.MetricBufferLimit)
+		batchSize, c.Agent.MetricBufferLimit)
+	ro.Fingerprint = fp
+	if outputConfig.BufferDirectory != "" {
+		for _, o := range c.Outputs {
+			if o.Config.BufferDirectory == outputConfig.BufferDirectory {
+				return fmt.Errorf("buffer_directory %s is already used by output %s",
+					outputConfig.BufferDirectory, o.Name)
+			}
+		}
+	}
 	c.Outputs = append(c.Outputs, ro)
 	return nil
 }
@@ -807,6 +1059,7 @@ func (c *Config) addInput(name string, table *ast.Table) error {
 		return fmt.Errorf("Undefined but requested input: %s", name)
 	}
 	input := creator()
+	fp := fingerprint("inputs."+name, table)
 
 	// If the input has a SetParser function, then this means it can accept
 	// arbitrary types of input, so build the parser and set it.
@@ -828,7 +1081,12 @@ func (c *Config) addInput(name string, table *ast.Table) error {
 		return err
 	}
 
+	if err := initPlugin("input", name, input); err != nil {
+		return err
+	}
+
 	rp := models.NewRunningInput(input, pluginConfig)
+	rp.Fingerprint = fp
 	c.Inputs = append(c.Inputs, rp)
 	return nil
 }
@@ -889,6 +1147,43 @@ func buildAggregator(name string, tbl *ast.Table) (*models.AggregatorConfig, err
 		}
 	}
 
+	if node, ok := tbl.Fields["align"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if b, ok := kv.Value.(*ast.Boolean); ok {
+				var err error
+				conf.Align, err = strconv.ParseBool(b.Value)
+				if err != nil {
+					log.Printf("Error parsing boolean value for %s: %s\n", name, err)
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["allowed_lateness"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				dur, err := time.ParseDuration(str.Value)
+				if err != nil {
+					return nil, err
+				}
+
+				conf.AllowedLateness = dur
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["late_passthrough"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if b, ok := kv.Value.(*ast.Boolean); ok {
+				var err error
+				conf.LatePassthrough, err = strconv.ParseBool(b.Value)
+				if err != nil {
+					log.Printf("Error parsing boolean value for %s: %s\n", name, err)
+				}
+			}
+		}
+	}
+
 	if node, ok := tbl.Fields["name_prefix"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
 			if str, ok := kv.Value.(*ast.String); ok {
@@ -925,10 +1220,21 @@ func buildAggregator(name string, tbl *ast.Table) (*models.AggregatorConfig, err
 	delete(tbl.Fields, "period")
 	delete(tbl.Fields, "delay")
 	delete(tbl.Fields, "drop_original")
+	delete(tbl.Fields, "align")
+	delete(tbl.Fields, "allowed_lateness")
+	delete(tbl.Fields, "late_passthrough")
 	delete(tbl.Fields, "name_prefix")
 	delete(tbl.Fields, "name_suffix")
 	delete(tbl.Fields, "name_override")
 	delete(tbl.Fields, "tags")
+
+	if conf.Period <= 0 {
+		return nil, fmt.Errorf("period of aggregator %s must be positive", name)
+	}
+	if conf.AllowedLateness < 0 {
+		return nil, fmt.Errorf("allowed_lateness of aggregator %s must not be negative", name)
+	}
+
 	var err error
 	conf.Filter, err = buildFilter(tbl)
 	if err != nil {
@@ -940,7 +1246,7 @@ func buildAggregator(name string, tbl *ast.Table) (*models.AggregatorConfig, err
 // buildProcessor parses Processor specific items from the ast.Table,
 // builds the filter and returns a
 // models.ProcessorConfig to be inserted into models.RunningProcessor
-func buildProcessor(name string, tbl *ast.Table) (*models.ProcessorConfig, error) {
+func buildProcessor(name string, tbl *ast.Table, windowed bool) (*models.ProcessorConfig, error) {
 	conf := &models.ProcessorConfig{Name: name}
 	unsupportedFields := []string{"tagexclude", "taginclude", "fielddrop", "fieldpass"}
 	for _, field := range unsupportedFields {
@@ -963,6 +1269,28 @@ func buildProcessor(name string, tbl *ast.Table) (*models.ProcessorConfig, error
 	}
 
 	delete(tbl.Fields, "order")
+
+	// the period is the window of the processors that hold metrics back.
+	if windowed {
+		conf.Period = time.Second * 10
+		if node, ok := tbl.Fields["period"]; ok {
+			if kv, ok := node.(*ast.KeyValue); ok {
+				if str, ok := kv.Value.(*ast.String); ok {
+					dur, err := time.ParseDuration(str.Value)
+					if err != nil {
+						return nil, err
+					}
+
+					conf.Period = dur
+				}
+			}
+		}
+		delete(tbl.Fields, "period")
+		if conf.Period <= 0 {
+			return nil, fmt.Errorf("period of processor %s must be positive", name)
+		}
+	}
+
 	var err error
 	conf.Filter, err = buildFilter(tbl)
 	if err != nil {
@@ -1225,6 +1553,246 @@ func buildParser(name string, tbl *ast.Table) (parsers.Parser, error) {
 		}
 	}
 
+	if node, ok := tbl.Fields["json_string_fields"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if ary, ok := kv.Value.(*ast.Array); ok {
+				for _, elem := range ary.Value {
+					if str, ok := elem.(*ast.String); ok {
+						c.JSONStringFields = append(c.JSONStringFields, str.Value)
+					}
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["json_query"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.JSONQuery = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["json_name_key"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.JSONNameKey = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["json_time_key"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.JSONTimeKey = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["json_time_format"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.JSONTimeFormat = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["csv_header_row_count"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if integer, ok := kv.Value.(*ast.Integer); ok {
+				v, err := integer.Int()
+				if err != nil {
+					return nil, err
+				}
+				c.CSVHeaderRowCount = int(v)
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["csv_skip_rows"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if integer, ok := kv.Value.(*ast.Integer); ok {
+				v, err := integer.Int()
+				if err != nil {
+					return nil, err
+				}
+				c.CSVSkipRows = int(v)
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["csv_skip_columns"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if integer, ok := kv.Value.(*ast.Integer); ok {
+				v, err := integer.Int()
+				if err != nil {
+					return nil, err
+				}
+				c.CSVSkipColumns = int(v)
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["csv_delimiter"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.CSVDelimiter = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["csv_comment"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.CSVComment = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["csv_trim_space"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if b, ok := kv.Value.(*ast.Boolean); ok {
+				var err error
+				c.CSVTrimSpace, err = strconv.ParseBool(b.Value)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["csv_column_names"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if ary, ok := kv.Value.(*ast.Array); ok {
+				for _, elem := range ary.Value {
+					if str, ok := elem.(*ast.String); ok {
+						c.CSVColumnNames = append(c.CSVColumnNames, str.Value)
+					}
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["csv_column_types"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if ary, ok := kv.Value.(*ast.Array); ok {
+				for _, elem := range ary.Value {
+					if str, ok := elem.(*ast.String); ok {
+						c.CSVColumnTypes = append(c.CSVColumnTypes, str.Value)
+					}
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["csv_tag_columns"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if ary, ok := kv.Value.(*ast.Array); ok {
+				for _, elem := range ary.Value {
+					if str, ok := elem.(*ast.String); ok {
+						c.CSVTagColumns = append(c.CSVTagColumns, str.Value)
+					}
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["csv_measurement_column"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.CSVMeasurementColumn = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["csv_timestamp_column"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.CSVTimestampColumn = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["csv_timestamp_format"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.CSVTimestampFormat = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["grok_patterns"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if ary, ok := kv.Value.(*ast.Array); ok {
+				for _, elem := range ary.Value {
+					if str, ok := elem.(*ast.String); ok {
+						c.GrokPatterns = append(c.GrokPatterns, str.Value)
+					}
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["grok_custom_patterns"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.GrokCustomPatterns = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["grok_custom_pattern_files"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if ary, ok := kv.Value.(*ast.Array); ok {
+				for _, elem := range ary.Value {
+					if str, ok := elem.(*ast.String); ok {
+						c.GrokCustomPatternFiles = append(c.GrokCustomPatternFiles, str.Value)
+					}
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["grok_timezone"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.GrokTimezone = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["dropwizard_metric_registry_path"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.DropwizardMetricRegistryPath = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["dropwizard_time_path"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.DropwizardTimePath = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["dropwizard_time_format"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.DropwizardTimeFormat = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["dropwizard_tags_path"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.DropwizardTagsPath = str.Value
+			}
+		}
+	}
+
 	if node, ok := tbl.Fields["data_type"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
 			if str, ok := kv.Value.(*ast.String); ok {
@@ -1267,6 +1835,31 @@ func buildParser(name string, tbl *ast.Table) (parsers.Parser, error) {
 	delete(tbl.Fields, "separator")
 	delete(tbl.Fields, "templates")
 	delete(tbl.Fields, "tag_keys")
+	delete(tbl.Fields, "json_string_fields")
+	delete(tbl.Fields, "json_query")
+	delete(tbl.Fields, "json_name_key")
+	delete(tbl.Fields, "json_time_key")
+	delete(tbl.Fields, "json_time_format")
+	delete(tbl.Fields, "csv_header_row_count")
+	delete(tbl.Fields, "csv_skip_rows")
+	delete(tbl.Fields, "csv_skip_columns")
+	delete(tbl.Fields, "csv_delimiter")
+	delete(tbl.Fields, "csv_comment")
+	delete(tbl.Fields, "csv_trim_space")
+	delete(tbl.Fields, "csv_column_names")
+	delete(tbl.Fields, "csv_column_types")
+	delete(tbl.Fields, "csv_tag_columns")
+	delete(tbl.Fields, "csv_measurement_column")
+	delete(tbl.Fields, "csv_timestamp_column")
+	delete(tbl.Fields, "csv_timestamp_format")
+	delete(tbl.Fields, "grok_patterns")
+	delete(tbl.Fields, "grok_custom_patterns")
+	delete(tbl.Fields, "grok_custom_pattern_files")
+	delete(tbl.Fields, "grok_timezone")
+	delete(tbl.Fields, "dropwizard_metric_registry_path")
+	delete(tbl.Fields, "dropwizard_time_path")
+	delete(tbl.Fields, "dropwizard_time_format")
+	delete(tbl.Fields, "dropwizard_tags_path")
 	delete(tbl.Fields, "data_type")
 	delete(tbl.Fields, "collectd_auth_file")
 	delete(tbl.Fields, "collectd_security_level")
@@ -1325,10 +1918,23 @@ func buildSerializer(name string, tbl *ast.Table) (serializers.Serializer, error
 		}
 	}
 
+	if node, ok := tbl.Fields["prometheus_export_timestamp"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if b, ok := kv.Value.(*ast.Boolean); ok {
+				var err error
+				c.PrometheusExportTimestamp, err = strconv.ParseBool(b.Value)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
 	delete(tbl.Fields, "data_format")
 	delete(tbl.Fields, "prefix")
 	delete(tbl.Fields, "template")
 	delete(tbl.Fields, "json_timestamp_units")
+	delete(tbl.Fields, "prometheus_export_timestamp")
 	return serializers.NewSerializer(c)
 }
 
@@ -1352,5 +1958,194 @@ func buildOutput(name string, tbl *ast.Table) (*models.OutputConfig, error) {
 	if len(oc.Filter.FieldPass) > 0 {
 		oc.Filter.NamePass = oc.Filter.FieldPass
 	}
+
+	if node, ok := tbl.Fields["buffer_directory"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				oc.BufferDirectory = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["buffer_segment_size"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			oc.BufferSegmentSize, err = parseSize(kv.Value)
+			if err != nil {
+				return nil, fmt.Errorf("buffer_segment_size: %s", err)
+			}
+		}
+	}
+
+	oc.BufferMaxSize = defaultBufferMaxSize
+	if node, ok := tbl.Fields["buffer_max_size"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			oc.BufferMaxSize, err = parseSize(kv.Value)
+			if err != nil {
+				return nil, fmt.Errorf("buffer_max_size: %s", err)
+			}
+		}
+	}
+
+	if err := parseDuration(tbl, "buffer_max_age", &oc.BufferMaxAge); err != nil {
+		return nil, err
+	}
+
+	if err := parseDuration(tbl, "flush_interval", &oc.FlushInterval); err != nil {
+		return nil, err
+	}
+
+	if err := parseDuration(tbl, "flush_jitter", &oc.FlushJitter); err != nil {
+		return nil, err
+	}
+
+	oc.RetryBackoff = defaultRetryBackoff
+	if err := parseDuration(tbl, "retry_backoff", &oc.RetryBackoff); err != nil {
+		return nil, err
+	}
+	oc.RetryMaxBackoff = defaultRetryMaxBackoff
+	if err := parseDuration(tbl, "retry_max_backoff", &oc.RetryMaxBackoff); err != nil {
+		return nil, err
+	}
+	if err := parseDuration(tbl, "retry_jitter", &oc.RetryJitter); err != nil {
+		return nil, err
+	}
+
+	if node, ok := tbl.Fields["retry_max_attempts"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if integer, ok := kv.Value.(*ast.Integer); ok {
+				v, err := integer.Int()
+				if err != nil {
+					return nil, err
+				}
+				oc.RetryMaxAttempts = int(v)
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["metric_batch_size"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if integer, ok := kv.Value.(*ast.Integer); ok {
+				v, err := integer.Int()
+				if err != nil {
+					return nil, err
+				}
+				oc.MetricBatchSize = int(v)
+			}
+		}
+	}
+
+	delete(tbl.Fields, "buffer_directory")
+	delete(tbl.Fields, "buffer_segment_size")
+	delete(tbl.Fields, "buffer_max_size")
+	delete(tbl.Fields, "buffer_max_age")
+	delete(tbl.Fields, "flush_interval")
+	delete(tbl.Fields, "flush_jitter")
+	delete(tbl.Fields, "metric_batch_size")
+	delete(tbl.Fields, "retry_backoff")
+	delete(tbl.Fields, "retry_max_backoff")
+	delete(tbl.Fields, "retry_jitter")
+	delete(tbl.Fields, "retry_max_attempts")
 	return oc, nil
 }
+
+// parseDuration sets d to the duration string in the field key of tbl, if
+// the field is set.
+func parseDuration(tbl *ast.Table, key string, d *time.Duration) error {
+	if node, ok := tbl.Fields[key]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				dur, err := time.ParseDuration(str.Value)
+				if err != nil {
+					return fmt.Errorf("%s: %s", key, err)
+				}
+				*d = dur
+			}
+		}
+	}
+	return nil
+}
+
+// fingerprint identifies the configuration of a plugin, it only changes when
+// the settings in its table change and not with comments or formatting.
+func fingerprint(name string, tbl *ast.Table) string {
+	var buf bytes.Buffer
+	buf.WriteString(name)
+	buf.WriteString("\n")
+	writeTable(&buf, tbl)
+	sum := sha256.Sum256(buf.Bytes())
+	return hex.EncodeToString(sum[:])
+}
+
+func writeTable(buf *bytes.Buffer, tbl *ast.Table) {
+	keys := make([]string, 0, len(tbl.Fields))
+	for key := range tbl.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		switch field := tbl.Fields[key].(type) {
+		case *ast.KeyValue:
+			fmt.Fprintf(buf, "%s=", key)
+			writeValue(buf, field.Value)
+			buf.WriteString("\n")
+		case *ast.Table:
+			fmt.Fprintf(buf, "[%s]\n", key)
+			writeTable(buf, field)
+		case []*ast.Table:
+			for _, t := range field {
+				fmt.Fprintf(buf, "[[%s]]\n", key)
+				writeTable(buf, t)
+			}
+		}
+	}
+	buf.WriteString("[]\n")
+}
+
+func writeValue(buf *bytes.Buffer, val ast.Value) {
+	switch v := val.(type) {
+	case *ast.Array:
+		buf.WriteString("[")
+		for _, elem := range v.Value {
+			writeValue(buf, elem)
+			buf.WriteString(",")
+		}
+		buf.WriteString("]")
+	case *ast.Table:
+		writeTable(buf, v)
+	case *ast.String:
+		// the value, not the source, so a changed secret changes the
+		// fingerprint.
+		buf.WriteString(strconv.Quote(v.Value))
+	default:
+		buf.WriteString(val.Source())
+	}
+}
+
+// parseSize parses a size in bytes given either as an integer or as a string
+// with a unit suffix, ie "512MB" or "1GiB".
+func parseSize(val ast.Value) (int64, error) {
+	switch v := val.(type) {
+	case *ast.Integer:
+		return v.Int()
+	case *ast.String:
+		str := strings.TrimSpace(v.Value)
+		i := strings.IndexFunc(str, func(r rune) bool {
+			return (r < '0' || r > '9') && r != '.'
+		})
+		if i < 0 {
+			i = len(str)
+		}
+		n, err := strconv.ParseFloat(str[:i], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid size %q", v.Value)
+		}
+		mult, ok := sizeUnits[strings.ToUpper(strings.TrimSpace(str[i:]))]
+		if !ok {
+			return 0, fmt.Errorf("invalid size unit in %q", v.Value)
+		}
+		return int64(n * float64(mult)), nil
+	default:
+		return 0, fmt.Errorf("size must be an integer or a string")
+	}
+}
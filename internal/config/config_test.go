@@ -6,13 +6,21 @@ import (
 	"time"
 
 	"github.com/influxdata/telegraf/internal/models"
+	"github.com/influxdata/telegraf/internal/redact"
+	_ "github.com/influxdata/telegraf/plugins/aggregators/minmax"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/inputs/exec"
 	"github.com/influxdata/telegraf/plugins/inputs/memcached"
 	"github.com/influxdata/telegraf/plugins/inputs/procstat"
 	"github.com/influxdata/telegraf/plugins/parsers"
+	_ "github.com/influxdata/telegraf/plugins/processors/printer"
+	"github.com/influxdata/telegraf/plugins/processors/topk"
+	_ "github.com/influxdata/telegraf/plugins/secretstores/env"
 
+	"github.com/influxdata/toml"
+	"github.com/influxdata/toml/ast"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestConfig_LoadSingleInputWithEnvVars(t *testing.T) {
@@ -174,3 +182,148 @@ func TestConfig_LoadDirectory(t *testing.T) {
 	assert.Equal(t, pConfig, c.Inputs[3].Config,
 		"Merged Testdata did not produce correct procstat metadata.")
 }
+
+func TestConfig_LoadSecrets(t *testing.T) {
+	os.Setenv("TELEGRAF_TEST_SECRET_USER", "admin")
+	os.Setenv("TELEGRAF_TEST_SECRET_PASSWORD", "hunter2")
+	defer os.Unsetenv("TELEGRAF_TEST_SECRET_USER")
+	defer os.Unsetenv("TELEGRAF_TEST_SECRET_PASSWORD")
+
+	c := NewConfig()
+	err := c.LoadConfig("./testdata/secrets.toml")
+	require.NoError(t, err)
+	require.Len(t, c.Inputs, 1)
+	assert.Equal(t, []string{"admin:hunter2@localhost", "localhost"},
+		c.Inputs[0].Input.(*memcached.Memcached).Servers)
+	assert.Equal(t, "password ****", redact.String("password hunter2"))
+
+	c = NewConfig()
+	err = c.LoadConfigData([]byte(`
+[[secretstores.env]]
+  id = "env"
+  prefix = "TELEGRAF_TEST_SECRET_"
+
+[[inputs.memcached]]
+  servers = ["@{env:TOKEN}@localhost"]
+`), "missing.toml")
+	assert.Error(t, err)
+
+	c = NewConfig()
+	err = c.LoadConfigData([]byte(`
+[[inputs.memcached]]
+  servers = ["@{vault:PASSWORD}@localhost"]
+`), "unknown.toml")
+	assert.Error(t, err)
+}
+
+func TestConfig_Fingerprint(t *testing.T) {
+	parse := func(s string) *ast.Table {
+		tbl, err := toml.Parse([]byte(s))
+		assert.NoError(t, err)
+		return tbl
+	}
+
+	a := parse(`
+servers = ["localhost"]
+interval = "5s"
+[tags]
+  dc = "us-east-1"
+`)
+	// same settings, different order and comments
+	b := parse(`
+# a comment
+interval = "5s"
+servers = [ "localhost" ]
+[tags]
+  dc = "us-east-1"
+`)
+	c := parse(`
+servers = ["localhost"]
+interval = "5s"
+[tags]
+  dc = "us-west-1"
+`)
+
+	assert.Equal(t, fingerprint("inputs.memcached", a), fingerprint("inputs.memcached", b))
+	assert.NotEqual(t, fingerprint("inputs.memcached", a), fingerprint("inputs.memcached", c))
+	assert.NotEqual(t, fingerprint("inputs.memcached", a), fingerprint("inputs.redis", a))
+}
+
+func TestConfig_WindowedProcessor(t *testing.T) {
+	c := NewConfig()
+	err := c.LoadConfigData([]byte(`
+[[processors.topk]]
+  period = "1m"
+  k = 3
+
+[[processors.topk]]
+`), "telegraf.conf")
+	require.NoError(t, err)
+	require.Len(t, c.Processors, 2)
+
+	assert.Equal(t, time.Minute, c.Processors[0].Config.Period)
+	assert.Equal(t, 3, c.Processors[0].Processor.(*topk.TopK).K)
+	assert.Equal(t, 10*time.Second, c.Processors[1].Config.Period)
+
+	// the processors that aren't windowed don't have a period
+	c = NewConfig()
+	err = c.LoadConfigData([]byte(`
+[[processors.printer]]
+`), "telegraf.conf")
+	require.NoError(t, err)
+	require.Len(t, c.Processors, 1)
+	assert.Equal(t, time.Duration(0), c.Processors[0].Config.Period)
+
+	err = NewConfig().LoadConfigData([]byte(`
+[[processors.topk]]
+  period = "0s"
+`), "telegraf.conf")
+	require.Error(t, err)
+}
+
+func TestConfig_AggregatorWindows(t *testing.T) {
+	c := NewConfig()
+	err := c.LoadConfigData([]byte(`
+[[aggregators.minmax]]
+  period = "30s"
+  align = true
+  allowed_lateness = "2m"
+  late_passthrough = true
+
+[[aggregators.minmax]]
+`), "telegraf.conf")
+	require.NoError(t, err)
+	require.Len(t, c.Aggregators, 2)
+
+	conf := c.Aggregators[0].Config
+	assert.True(t, conf.Align)
+	assert.Equal(t, 2*time.Minute, conf.AllowedLateness)
+	assert.True(t, conf.LatePassthrough)
+
+	conf = c.Aggregators[1].Config
+	assert.False(t, conf.Align)
+	assert.Equal(t, time.Duration(0), conf.AllowedLateness)
+	assert.False(t, conf.LatePassthrough)
+
+	err = NewConfig().LoadConfigData([]byte(`
+[[aggregators.minmax]]
+  allowed_lateness = "-1s"
+`), "telegraf.conf")
+	require.Error(t, err)
+}
+
+func TestConfig_InitPlugin(t *testing.T) {
+	c := NewConfig()
+	err := c.LoadConfigData([]byte(`
+[[processors.topk]]
+  k = 3
+`), "telegraf.conf")
+	require.NoError(t, err)
+	require.Len(t, c.Processors, 1)
+
+	err = NewConfig().LoadConfigData([]byte(`
+[[processors.topk]]
+  k = 0
+`), "telegraf.conf")
+	require.Error(t, err)
+}
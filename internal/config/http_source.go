@@ -0,0 +1,121 @@
+package config
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf/internal"
+)
+
+// IsURL returns true if the config path is an http or https URL.
+func IsURL(path string) bool {
+	return strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://")
+}
+
+// HTTPSource fetches a configuration file from an http(s) URL and tells if
+// it has changed since, using the ETag and Last-Modified headers of the
+// server when they are available.
+type HTTPSource struct {
+	URL string
+
+	token  string
+	client *http.Client
+
+	mu           sync.Mutex
+	etag         string
+	lastModified string
+	sum          [sha256.Size]byte
+}
+
+// NewHTTPSource returns a source for the configuration at url. token is sent
+// as a bearer token if set, sslCA is the path to a CA file used to verify the
+// server in addition to the system CAs.
+func NewHTTPSource(url, token, sslCA string) (*HTTPSource, error) {
+	tlsCfg, err := internal.GetTLSConfig("", "", sslCA, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return &HTTPSource{
+		URL:   url,
+		token: token,
+		client: &http.Client{
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: tlsCfg,
+			},
+			Timeout: 30 * time.Second,
+		},
+	}, nil
+}
+
+// Fetch returns the configuration.
+func (s *HTTPSource) Fetch() ([]byte, error) {
+	data, _, err := s.get(false)
+	return data, err
+}
+
+// Changed returns the configuration if it changed since it was last fetched
+// or checked, and nil otherwise.
+func (s *HTTPSource) Changed() ([]byte, error) {
+	data, changed, err := s.get(true)
+	if err != nil || !changed {
+		return nil, err
+	}
+	return data, nil
+}
+
+// get fetches the configuration, conditionally if it has been fetched before
+// and conditional is true. changed is false when the server reports the
+// configuration was not modified or its content did not change.
+func (s *HTTPSource) get(conditional bool) (data []byte, changed bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	req, err := http.NewRequest("GET", s.URL, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+	if conditional {
+		if s.etag != "" {
+			req.Header.Set("If-None-Match", s.etag)
+		}
+		if s.lastModified != "" {
+			req.Header.Set("If-Modified-Since", s.lastModified)
+		}
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+
+	if conditional && resp.StatusCode == http.StatusNotModified {
+		return nil, false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, false, fmt.Errorf("fetching %s returned status %s",
+			s.URL, resp.Status)
+	}
+
+	data, err = ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, false, err
+	}
+
+	sum := sha256.Sum256(data)
+	changed = sum != s.sum
+	s.sum = sum
+	s.etag = resp.Header.Get("ETag")
+	s.lastModified = resp.Header.Get("Last-Modified")
+	return data, changed, nil
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const remoteConfig = `
+[[inputs.memcached]]
+  servers = ["localhost"]
+`
+
+func TestHTTPSourceETag(t *testing.T) {
+	etag := `"1"`
+	body := remoteConfig
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		fmt.Fprint(w, body)
+	}))
+	defer ts.Close()
+
+	s, err := NewHTTPSource(ts.URL, "secret", "")
+	require.NoError(t, err)
+
+	data, err := s.Fetch()
+	require.NoError(t, err)
+	assert.Equal(t, remoteConfig, string(data))
+
+	data, err = s.Changed()
+	require.NoError(t, err)
+	assert.Nil(t, data)
+
+	etag = `"2"`
+	body = remoteConfig + "  timeout = \"1s\"\n"
+	data, err = s.Changed()
+	require.NoError(t, err)
+	assert.Equal(t, body, string(data))
+
+	// the change is only reported once
+	data, err = s.Changed()
+	require.NoError(t, err)
+	assert.Nil(t, data)
+	assert.Equal(t, 4, requests)
+}
+
+func TestHTTPSourceLastModified(t *testing.T) {
+	lastModified := "Mon, 02 Jan 2006 15:04:05 GMT"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-Modified-Since") == lastModified {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Last-Modified", lastModified)
+		fmt.Fprint(w, remoteConfig)
+	}))
+	defer ts.Close()
+
+	s, err := NewHTTPSource(ts.URL, "", "")
+	require.NoError(t, err)
+	_, err = s.Fetch()
+	require.NoError(t, err)
+
+	data, err := s.Changed()
+	require.NoError(t, err)
+	assert.Nil(t, data)
+
+	lastModified = "Tue, 03 Jan 2006 15:04:05 GMT"
+	data, err = s.Changed()
+	require.NoError(t, err)
+	// same content, so nothing to reload
+	assert.Nil(t, data)
+}
+
+func TestHTTPSourceError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer ts.Close()
+
+	s, err := NewHTTPSource(ts.URL, "", "")
+	require.NoError(t, err)
+	_, err = s.Fetch()
+	require.Error(t, err)
+}
+
+func TestConfig_LoadConfigData(t *testing.T) {
+	c := NewConfig()
+	err := c.LoadConfigData([]byte(remoteConfig), "http://localhost/telegraf.conf")
+	require.NoError(t, err)
+	require.Len(t, c.Inputs, 1)
+	assert.Equal(t, "inputs.memcached", c.Inputs[0].Name())
+}
+
+func TestIsURL(t *testing.T) {
+	assert.True(t, IsURL("http://localhost/telegraf.conf"))
+	assert.True(t, IsURL("https://localhost/telegraf.conf"))
+	assert.False(t, IsURL("/etc/telegraf/telegraf.conf"))
+}
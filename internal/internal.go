@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log"
+	"math"
 	"math/big"
 	"os"
 	"os/exec"
@@ -27,6 +28,53 @@ var (
 	NotImplementedError = errors.New("not implemented yet")
 )
 
+// nonRetryableError wraps an error after which a write should not be retried.
+type nonRetryableError struct {
+	err error
+}
+
+func (e *nonRetryableError) Error() string {
+	return e.err.Error()
+}
+
+// NonRetryable marks err as permanent, outputs return it when writing the same
+// batch again would fail in the same way, ie when the batch is rejected by the
+// server. The batch is dropped instead of retried.
+func NonRetryable(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &nonRetryableError{err: err}
+}
+
+// Unwrap returns the error marked with NonRetryable.
+func (e *nonRetryableError) Unwrap() error {
+	return e.err
+}
+
+// IsNonRetryable returns true if err, or an error it wraps, was marked with
+// NonRetryable. Errors are unwrapped through their Unwrap or Cause method.
+func IsNonRetryable(err error) bool {
+	for err != nil {
+		if _, ok := err.(*nonRetryableError); ok {
+			return true
+		}
+		switch e := err.(type) {
+		case interface {
+			Unwrap() error
+		}:
+			err = e.Unwrap()
+		case interface {
+			Cause() error
+		}:
+			err = e.Cause()
+		default:
+			return false
+		}
+	}
+	return false
+}
+
 // Duration just wraps time.Duration
 type Duration struct {
 	Duration time.Duration
@@ -224,14 +272,8 @@ func RandomSleep(max time.Duration, shutdown chan struct{}) {
 	if max == 0 {
 		return
 	}
-	maxSleep := big.NewInt(max.Nanoseconds())
-
-	var sleepns int64
-	if j, err := rand.Int(rand.Reader, maxSleep); err == nil {
-		sleepns = j.Int64()
-	}
 
-	t := time.NewTimer(time.Nanosecond * time.Duration(sleepns))
+	t := time.NewTimer(RandomDuration(max))
 	select {
 	case <-t.C:
 		return
@@ -240,3 +282,69 @@ func RandomSleep(max time.Duration, shutdown chan struct{}) {
 		return
 	}
 }
+
+// RandomDuration returns a random duration between 0 and max.
+func RandomDuration(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	maxSleep := big.NewInt(max.Nanoseconds())
+
+	var sleepns int64
+	if j, err := rand.Int(rand.Reader, maxSleep); err == nil {
+		sleepns = j.Int64()
+	}
+	return time.Duration(sleepns)
+}
+
+// ParseTimestamp parses a timestamp in the given format: unix, unix_ms,
+// unix_us, unix_ns, RFC3339 or a Go time layout. The unix formats accept
+// numbers and numeric strings, the others only strings.
+func ParseTimestamp(v interface{}, format string) (time.Time, error) {
+	var unit time.Duration
+	switch format {
+	case "unix":
+		unit = time.Second
+	case "unix_ms":
+		unit = time.Millisecond
+	case "unix_us":
+		unit = time.Microsecond
+	case "unix_ns":
+		unit = time.Nanosecond
+	}
+
+	if unit != 0 {
+		var n float64
+		switch t := v.(type) {
+		case float64:
+			n = t
+		case string:
+			// integers are parsed as such, large nanosecond timestamps do
+			// not fit in a float.
+			if i, err := strconv.ParseInt(t, 10, 64); err == nil {
+				return time.Unix(0, i*int64(unit)).UTC(), nil
+			}
+			var err error
+			if n, err = strconv.ParseFloat(t, 64); err != nil {
+				return time.Time{}, fmt.Errorf("invalid %s time %q", format, t)
+			}
+		default:
+			return time.Time{}, fmt.Errorf("invalid %s time %v", format, v)
+		}
+		whole, frac := math.Modf(n)
+		return time.Unix(0, int64(whole)*int64(unit)+int64(frac*float64(unit))).UTC(), nil
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		return time.Time{}, fmt.Errorf("invalid time %v, expected a string", v)
+	}
+	if format == "RFC3339" {
+		format = time.RFC3339Nano
+	}
+	t, err := time.Parse(format, s)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.UTC(), nil
+}
@@ -1,11 +1,13 @@
 package internal
 
 import (
+	"errors"
 	"os/exec"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type SnakeTest struct {
@@ -133,6 +135,25 @@ func TestRandomSleep(t *testing.T) {
 	assert.True(t, elapsed < time.Millisecond*150)
 }
 
+func TestNonRetryable(t *testing.T) {
+	err := errors.New("field type conflict")
+	assert.False(t, IsNonRetryable(err))
+	assert.True(t, IsNonRetryable(NonRetryable(err)))
+	assert.Equal(t, err.Error(), NonRetryable(err).Error())
+	assert.Nil(t, NonRetryable(nil))
+
+	// wrapped errors are unwrapped
+	assert.True(t, IsNonRetryable(&causeError{NonRetryable(err)}))
+	assert.False(t, IsNonRetryable(&causeError{err}))
+}
+
+type causeError struct {
+	cause error
+}
+
+func (e *causeError) Error() string { return "write failed: " + e.cause.Error() }
+func (e *causeError) Cause() error  { return e.cause }
+
 func TestDuration(t *testing.T) {
 	var d Duration
 
@@ -155,3 +176,38 @@ func TestDuration(t *testing.T) {
 	d.UnmarshalTOML([]byte(`1.5`))
 	assert.Equal(t, time.Second, d.Duration)
 }
+
+func TestParseTimestamp(t *testing.T) {
+	tests := []struct {
+		format   string
+		value    interface{}
+		expected time.Time
+	}{
+		{"unix", float64(1514764800), time.Unix(1514764800, 0)},
+		{"unix", 1514764800.25, time.Unix(1514764800, 250000000)},
+		{"unix", "1514764800", time.Unix(1514764800, 0)},
+		{"unix_ms", float64(1514764800123), time.Unix(1514764800, 123000000)},
+		{"unix_us", "1514764800123456", time.Unix(1514764800, 123456000)},
+		{"unix_ns", "1514764800123456789", time.Unix(1514764800, 123456789)},
+		{"RFC3339", "2018-01-01T01:00:00+01:00", time.Unix(1514764800, 0)},
+		{"2006-01-02 15:04:05", "2018-01-01 00:00:00", time.Unix(1514764800, 0)},
+	}
+	for _, tt := range tests {
+		actual, err := ParseTimestamp(tt.value, tt.format)
+		require.NoError(t, err, tt.format)
+		assert.Equal(t, tt.expected.UTC(), actual, tt.format)
+	}
+
+	for _, tt := range []struct {
+		format string
+		value  interface{}
+	}{
+		{"unix", "yesterday"},
+		{"unix", true},
+		{"RFC3339", float64(1514764800)},
+		{"RFC3339", "2018-01-01"},
+	} {
+		_, err := ParseTimestamp(tt.value, tt.format)
+		assert.Error(t, err, tt.format)
+	}
+}
@@ -1,20 +1,29 @@
 package models
 
 import (
+	"sync"
 	"time"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/selfstat"
 )
 
 type RunningAggregator struct {
 	a      telegraf.Aggregator
 	Config *AggregatorConfig
 
-	metrics chan telegraf.Metric
+	metrics chan addedMetric
 
-	periodStart time.Time
-	periodEnd   time.Time
+	// oldest is the start of the oldest open window, the metrics of the
+	// windows before it are late.
+	mu     sync.Mutex
+	oldest time.Time
+
+	MetricsLate selfstat.Stat
+
+	// Fingerprint identifies the configuration of the aggregator.
+	Fingerprint string
 }
 
 func NewRunningAggregator(
@@ -24,10 +33,22 @@ func NewRunningAggregator(
 	return &RunningAggregator{
 		a:       a,
 		Config:  conf,
-		metrics: make(chan telegraf.Metric, 100),
+		metrics: make(chan addedMetric, 100),
+		MetricsLate: selfstat.Register(
+			"aggregate",
+			"metrics_late",
+			map[string]string{"aggregator": conf.Name},
+		),
 	}
 }
 
+// addedMetric is a metric added to the aggregator, with the original metric
+// to pass through if the metric turns out to be late.
+type addedMetric struct {
+	metric   telegraf.Metric
+	original telegraf.Metric
+}
+
 // AggregatorConfig containing configuration parameters for the running
 // aggregator plugin.
 type AggregatorConfig struct {
@@ -42,6 +63,16 @@ type AggregatorConfig struct {
 
 	Period time.Duration
 	Delay  time.Duration
+
+	// Align aligns the windows on multiples of the period, rather than on
+	// the time the aggregator started.
+	Align bool
+	// AllowedLateness is how long the windows are kept open after their
+	// end, in addition to the delay.
+	AllowedLateness time.Duration
+	// LatePassthrough sends the late metrics to the outputs even if the
+	// original metrics are dropped.
+	LatePassthrough bool
 }
 
 func (r *RunningAggregator) Name() string {
@@ -80,7 +111,10 @@ func (r *RunningAggregator) MakeMetric(
 // Add applies the given metric to the aggregator.
 // Before applying to the plugin, it will run any defined filters on the metric.
 // Apply returns true if the original metric should be dropped.
+// The aggregator takes ownership of the given metric, it is dropped once it
+// has been aggregated.
 func (r *RunningAggregator) Add(in telegraf.Metric) bool {
+	m := addedMetric{metric: in, original: in}
 	if r.Config.Filter.IsActive() {
 		// check if the aggregator should apply this metric
 		name := in.Name()
@@ -89,56 +123,128 @@ func (r *RunningAggregator) Add(in telegraf.Metric) bool {
 		t := in.Time()
 		if ok := r.Config.Filter.Apply(name, fields, tags); !ok {
 			// aggregator should not apply this metric
+			in.Drop()
 			return false
 		}
 
-		in, _ = metric.New(name, tags, fields, t)
+		// the original metric is kept to be passed through if it turns out
+		// to be late.
+		if !r.passLate() {
+			in.Drop()
+			m.original = nil
+		}
+		m.metric, _ = metric.New(name, tags, fields, t)
+	}
+
+	if r.isLate(m.metric.Time()) {
+		r.MetricsLate.Incr(1)
+		m.drop()
+		return r.Config.DropOriginal && !r.Config.LatePassthrough
 	}
 
-	r.metrics <- in
+	r.metrics <- m
 	return r.Config.DropOriginal
 }
+
+// passLate returns true if the late metrics are passed through by the
+// aggregator, rather than by the caller of Add.
+func (r *RunningAggregator) passLate() bool {
+	return r.Config.DropOriginal && r.Config.LatePassthrough
+}
+
+func (m addedMetric) drop() {
+	m.metric.Drop()
+	if m.original != nil && m.original != m.metric {
+		m.original.Drop()
+	}
+}
+
+// isLate returns true if the window of a metric with the given time was
+// already pushed.
+func (r *RunningAggregator) isLate(t time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return !r.oldest.IsZero() && t.Before(r.oldest)
+}
+
+func (r *RunningAggregator) setOldest(t time.Time) {
+	r.mu.Lock()
+	r.oldest = t
+	r.mu.Unlock()
+}
+
 func (r *RunningAggregator) add(in telegraf.Metric) {
 	r.a.Add(in)
 }
 
-func (r *RunningAggregator) push(acc telegraf.Accumulator) {
-	r.a.Push(acc)
+// push pushes the aggregates of the window starting at start. When the
+// windows are aligned or kept open for late metrics, the aggregates without a
+// timestamp are given the start of the window, otherwise the time they are
+// pushed.
+func (r *RunningAggregator) push(acc telegraf.Accumulator, start time.Time) {
+	if !r.Config.Align && r.Config.AllowedLateness == 0 {
+		r.a.Push(acc)
+		return
+	}
+	r.a.Push(&windowAccumulator{Accumulator: acc, start: start})
 }
 
 func (r *RunningAggregator) reset() {
 	r.a.Reset()
 }
 
-// Run runs the running aggregator, listens for incoming metrics, and waits
-// for period ticks to tell it when to push and reset the aggregator.
+// windowStart returns the start of the window of a metric with the given
+// time, windows start every period from base.
+func (r *RunningAggregator) windowStart(base, t time.Time) time.Time {
+	if r.Config.Align {
+		return t.Truncate(r.Config.Period)
+	}
+	d := t.Sub(base)
+	n := d / r.Config.Period
+	if d < 0 && d%r.Config.Period != 0 {
+		n--
+	}
+	return base.Add(n * r.Config.Period)
+}
+
+// Run runs the running aggregator, listens for incoming metrics, and pushes
+// and resets the aggregator at the end of each window. The late metrics that
+// are passed through are given to passthrough.
 func (r *RunningAggregator) Run(
 	acc telegraf.Accumulator,
+	passthrough func(telegraf.Metric),
 	now time.Time,
 	shutdown chan struct{},
 ) {
-	// The start of the period is truncated to the nearest second.
+	// The windows are assigned using the timestamps of the metrics. They
+	// start every period from now truncated to the second, or on multiples
+	// of the period when aligned, so that a 30s period starts windows at :00
+	// and :30.
 	//
-	// Every metric then gets it's timestamp checked and is dropped if it
-	// is not within:
+	// A window is pushed once the clock passes its end plus the delay and the
+	// allowed lateness, the metrics of the windows that were pushed are late
+	// and dropped. So with a 10s period, a 0.5s delay and 20s of allowed
+	// lateness, the 00:00 - 00:10 window is pushed at 00:30.5.
 	//
-	//   start < t < end + truncation + delay
+	// The aggregator is fed the metrics of the oldest open window only, so
+	// that windows are aggregated one after the other. The metrics of the
+	// following windows are buffered until the windows before them are
+	// pushed. Metrics more than one window ahead of the clock are dropped.
 	//
-	// So if we start at now = 00:00.2 with a 10s period and 0.3s delay:
-	//   now = 00:00.2
-	//   start = 00:00
-	//   truncation = 00:00.2
-	//   end = 00:10
-	// 1st interval: 00:00 - 00:10.5
-	// 2nd interval: 00:10 - 00:20.5
-	// etc.
-	//
-	r.periodStart = now.Truncate(time.Second)
-	truncation := now.Sub(r.periodStart)
-	r.periodEnd = r.periodStart.Add(r.Config.Period)
-	time.Sleep(r.Config.Delay)
-	periodT := time.NewTicker(r.Config.Period)
-	defer periodT.Stop()
+	// With align or allowed lateness, the aggregates are given the start of
+	// their window as timestamp unless the aggregator sets one, as they can
+	// be pushed long after the window ended.
+	base := now.Truncate(time.Second)
+	oldest := r.windowStart(base, now)
+	r.setOldest(oldest)
+	buffered := make(map[int64][]addedMetric)
+
+	closeAt := func() time.Duration {
+		end := oldest.Add(r.Config.Period).Add(r.Config.Delay).Add(r.Config.AllowedLateness)
+		return end.Sub(time.Now())
+	}
+	timer := time.NewTimer(closeAt())
+	defer timer.Stop()
 
 	for {
 		select {
@@ -147,20 +253,107 @@ func (r *RunningAggregator) Run(
 				// wait until metrics are flushed before exiting
 				continue
 			}
+			for _, metrics := range buffered {
+				for _, m := range metrics {
+					m.drop()
+				}
+			}
 			return
 		case m := <-r.metrics:
-			if m.Time().Before(r.periodStart) ||
-				m.Time().After(r.periodEnd.Add(truncation).Add(r.Config.Delay)) {
-				// the metric is outside the current aggregation period, so
-				// skip it.
-				continue
+			start := r.windowStart(base, m.metric.Time())
+			switch {
+			case start.Before(oldest):
+				// the window was pushed since the metric was added.
+				r.MetricsLate.Incr(1)
+				if r.passLate() && passthrough != nil {
+					if m.metric != m.original {
+						m.metric.Drop()
+					}
+					passthrough(m.original)
+					continue
+				}
+				m.drop()
+			case start.Equal(oldest):
+				r.add(m.metric)
+				m.drop()
+			case start.After(r.windowStart(base, time.Now()).Add(r.Config.Period)):
+				// the metric is too far in the future, so skip it.
+				m.drop()
+			default:
+				buffered[start.UnixNano()] = append(buffered[start.UnixNano()], m)
 			}
-			r.add(m)
-		case <-periodT.C:
-			r.periodStart = r.periodEnd
-			r.periodEnd = r.periodStart.Add(r.Config.Period)
-			r.push(acc)
+		case <-timer.C:
+			r.push(acc, oldest)
 			r.reset()
+
+			oldest = oldest.Add(r.Config.Period)
+			r.setOldest(oldest)
+			for _, m := range buffered[oldest.UnixNano()] {
+				r.add(m.metric)
+				m.drop()
+			}
+			delete(buffered, oldest.UnixNano())
+
+			timer.Reset(closeAt())
 		}
 	}
 }
+
+// windowAccumulator gives the start of the window as timestamp to the
+// aggregates without one.
+type windowAccumulator struct {
+	telegraf.Accumulator
+	start time.Time
+}
+
+func (w *windowAccumulator) AddFields(
+	measurement string,
+	fields map[string]interface{},
+	tags map[string]string,
+	t ...time.Time,
+) {
+	w.Accumulator.AddFields(measurement, fields, tags, w.time(t)...)
+}
+
+func (w *windowAccumulator) AddGauge(
+	measurement string,
+	fields map[string]interface{},
+	tags map[string]string,
+	t ...time.Time,
+) {
+	w.Accumulator.AddGauge(measurement, fields, tags, w.time(t)...)
+}
+
+func (w *windowAccumulator) AddCounter(
+	measurement string,
+	fields map[string]interface{},
+	tags map[string]string,
+	t ...time.Time,
+) {
+	w.Accumulator.AddCounter(measurement, fields, tags, w.time(t)...)
+}
+
+func (w *windowAccumulator) AddSummary(
+	measurement string,
+	fields map[string]interface{},
+	tags map[string]string,
+	t ...time.Time,
+) {
+	w.Accumulator.AddSummary(measurement, fields, tags, w.time(t)...)
+}
+
+func (w *windowAccumulator) AddHistogram(
+	measurement string,
+	fields map[string]interface{},
+	tags map[string]string,
+	t ...time.Time,
+) {
+	w.Accumulator.AddHistogram(measurement, fields, tags, w.time(t)...)
+}
+
+func (w *windowAccumulator) time(t []time.Time) []time.Time {
+	if len(t) > 0 {
+		return t
+	}
+	return []time.Time{w.start}
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/testutil"
 
 	"github.com/stretchr/testify/assert"
@@ -24,7 +25,7 @@ func TestAdd(t *testing.T) {
 	})
 	assert.NoError(t, ra.Config.Filter.Compile())
 	acc := testutil.Accumulator{}
-	go ra.Run(&acc, time.Now(), make(chan struct{}))
+	go ra.Run(&acc, nil, time.Now(), make(chan struct{}))
 
 	m := ra.MakeMetric(
 		"RITest",
@@ -55,7 +56,7 @@ func TestAddMetricsOutsideCurrentPeriod(t *testing.T) {
 	})
 	assert.NoError(t, ra.Config.Filter.Compile())
 	acc := testutil.Accumulator{}
-	go ra.Run(&acc, time.Now(), make(chan struct{}))
+	go ra.Run(&acc, nil, time.Now(), make(chan struct{}))
 
 	// metric before current period
 	m := ra.MakeMetric(
@@ -113,7 +114,7 @@ func TestAddAndPushOnePeriod(t *testing.T) {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		ra.Run(&acc, time.Now(), shutdown)
+		ra.Run(&acc, nil, time.Now(), shutdown)
 	}()
 
 	m := ra.MakeMetric(
@@ -167,6 +168,167 @@ func TestAddDropOriginal(t *testing.T) {
 	assert.False(t, ra.Add(m2))
 }
 
+func TestAddAlignedWindowsWithLateness(t *testing.T) {
+	a := &TestAggregator{}
+	ra := NewRunningAggregator(a, &AggregatorConfig{
+		Name:            "TestRunningAggregator",
+		Period:          time.Millisecond * 200,
+		Align:           true,
+		AllowedLateness: time.Millisecond * 300,
+	})
+	acc := testutil.Accumulator{}
+	shutdown := make(chan struct{})
+
+	now := time.Now()
+	start := now.Truncate(ra.Config.Period)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ra.Run(&acc, nil, now, shutdown)
+	}()
+
+	// the metric of the next window is kept until the current one is pushed.
+	for _, m := range []telegraf.Metric{
+		ra.MakeMetric("RITest", map[string]interface{}{"value": int(100)},
+			map[string]string{}, telegraf.Untyped, start.Add(ra.Config.Period)),
+		ra.MakeMetric("RITest", map[string]interface{}{"value": int(1)},
+			map[string]string{}, telegraf.Untyped, start),
+	} {
+		assert.False(t, ra.Add(m))
+	}
+
+	acc.Wait(2)
+	close(shutdown)
+	wg.Wait()
+
+	acc.AssertContainsFields(t, "TestMetric", map[string]interface{}{"sum": int64(1)})
+	assert.Equal(t, map[string]interface{}{"sum": int64(100)}, acc.Metrics[1].Fields)
+	// the aggregates are given the start of their window
+	assert.Equal(t, start, acc.Metrics[0].Time)
+	assert.Equal(t, start.Add(ra.Config.Period), acc.Metrics[1].Time)
+}
+
+func TestAddWindowsPushTime(t *testing.T) {
+	a := &TestAggregator{}
+	ra := NewRunningAggregator(a, &AggregatorConfig{
+		Name:   "TestRunningAggregator",
+		Period: time.Millisecond * 100,
+	})
+	acc := testutil.Accumulator{}
+	shutdown := make(chan struct{})
+
+	now := time.Now()
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ra.Run(&acc, nil, now, shutdown)
+	}()
+
+	m := ra.MakeMetric("RITest", map[string]interface{}{"value": int(1)},
+		map[string]string{}, telegraf.Untyped, now)
+	assert.False(t, ra.Add(m))
+
+	acc.Wait(1)
+	close(shutdown)
+	wg.Wait()
+
+	// without align and allowed_lateness the aggregates are given the time
+	// they are pushed.
+	start := ra.windowStart(now.Truncate(time.Second), now)
+	assert.False(t, acc.Metrics[0].Time.Before(start.Add(ra.Config.Period)))
+}
+
+func TestAddLateMetric(t *testing.T) {
+	ra := NewRunningAggregator(&TestAggregator{}, &AggregatorConfig{
+		Name:            "TestLateAggregator",
+		Period:          time.Millisecond * 500,
+		DropOriginal:    true,
+		LatePassthrough: true,
+	})
+	shutdown := make(chan struct{})
+	defer close(shutdown)
+	go ra.Run(&testutil.Accumulator{}, nil, time.Now(), shutdown)
+	for !ra.isLate(time.Now().Add(-time.Hour)) {
+		time.Sleep(time.Millisecond)
+	}
+
+	late := ra.MetricsLate.Get()
+	m := ra.MakeMetric(
+		"RITest",
+		map[string]interface{}{"value": int(101)},
+		map[string]string{},
+		telegraf.Untyped,
+		time.Now().Add(-time.Hour),
+	)
+	assert.False(t, ra.Add(m))
+	assert.Equal(t, late+1, ra.MetricsLate.Get())
+
+	ra.Config.LatePassthrough = false
+	assert.True(t, ra.Add(m.Copy()))
+	assert.Equal(t, late+2, ra.MetricsLate.Get())
+}
+
+func TestRunLateMetric(t *testing.T) {
+	ra := NewRunningAggregator(&TestAggregator{}, &AggregatorConfig{
+		Name:            "TestLateAggregator",
+		Period:          time.Millisecond * 500,
+		DropOriginal:    true,
+		LatePassthrough: true,
+	})
+	passed := make(chan telegraf.Metric, 1)
+	shutdown := make(chan struct{})
+	defer close(shutdown)
+	go ra.Run(&testutil.Accumulator{}, func(m telegraf.Metric) {
+		passed <- m
+	}, time.Now(), shutdown)
+
+	// the metric was added before its window was pushed, it is passed
+	// through by the aggregator.
+	m := ra.MakeMetric(
+		"RITest",
+		map[string]interface{}{"value": int(101)},
+		map[string]string{},
+		telegraf.Untyped,
+		time.Now().Add(-time.Hour),
+	)
+	ra.metrics <- addedMetric{metric: m.Copy(), original: m}
+
+	select {
+	case out := <-passed:
+		assert.True(t, out == m)
+	case <-time.After(time.Second):
+		t.Fatal("the late metric was not passed through")
+	}
+}
+
+func TestAddKeepsOriginal(t *testing.T) {
+	ra := NewRunningAggregator(&TestAggregator{}, &AggregatorConfig{
+		Name: "TestLateAggregator",
+		Filter: Filter{
+			FieldDrop: []string{"dropped"},
+		},
+		DropOriginal:    true,
+		LatePassthrough: true,
+	})
+	assert.NoError(t, ra.Config.Filter.Compile())
+
+	// the original metric is kept in case it is late, the aggregator is given
+	// the filtered one.
+	m, err := metric.New("RITest",
+		map[string]string{},
+		map[string]interface{}{"value": int64(101), "dropped": int64(1)},
+		time.Now(),
+	)
+	assert.NoError(t, err)
+	assert.True(t, ra.Add(m))
+	added := <-ra.metrics
+	assert.True(t, added.original == m)
+	assert.Equal(t, map[string]interface{}{"value": int64(101), "dropped": int64(1)}, added.original.Fields())
+	assert.Equal(t, map[string]interface{}{"value": int64(101)}, added.metric.Fields())
+}
+
 // make an untyped, counter, & gauge metric
 func TestMakeMetricA(t *testing.T) {
 	now := time.Now()
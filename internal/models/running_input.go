@@ -2,9 +2,11 @@ package models
 
 import (
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/redact"
 	"github.com/influxdata/telegraf/selfstat"
 )
 
@@ -18,6 +20,14 @@ type RunningInput struct {
 	defaultTags map[string]string
 
 	MetricsGathered selfstat.Stat
+	GatherErrors    selfstat.Stat
+
+	// Instance tells the inputs of the same type apart in the stats that
+	// aren't shared between them.
+	Instance int
+
+	// Fingerprint identifies the configuration of the input.
+	Fingerprint string
 }
 
 func NewRunningInput(
@@ -32,9 +42,25 @@ func NewRunningInput(
 			"metrics_gathered",
 			map[string]string{"input": config.Name},
 		),
+		GatherErrors: gatherErrors(config.Name, 0),
 	}
 }
 
+// SetInstance sets the instance number of the input, which tags its error
+// counter.
+func (r *RunningInput) SetInstance(instance int) {
+	r.Instance = instance
+	r.GatherErrors = gatherErrors(r.Config.Name, instance)
+}
+
+func gatherErrors(name string, instance int) selfstat.Stat {
+	return selfstat.Register(
+		"gather",
+		"errors",
+		map[string]string{"input": name, "instance": strconv.Itoa(instance)},
+	)
+}
+
 // InputConfig containing a name, interval, and filter
 type InputConfig struct {
 	Name              string
@@ -75,7 +101,7 @@ func (r *RunningInput) MakeMetric(
 	)
 
 	if r.trace && m != nil {
-		fmt.Print("> " + m.String())
+		fmt.Print("> " + redact.String(m.String()))
 	}
 
 	r.MetricsGathered.Incr(1)
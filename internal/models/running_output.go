@@ -1,11 +1,14 @@
 package models
 
 import (
+	"fmt"
 	"log"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/internal/buffer"
 	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/selfstat"
@@ -32,11 +35,33 @@ type RunningOutput struct {
 	BufferSize      selfstat.Stat
 	BufferLimit     selfstat.Stat
 	WriteTime       selfstat.Stat
+	WriteErrors     selfstat.Stat
+
+	// Instance tells the outputs of the same type apart in the stats that
+	// aren't shared between them.
+	Instance int
+
+	// BatchReady receives a value when a full batch of metrics is waiting to
+	// be written.
+	BatchReady chan struct{}
 
 	metrics     *buffer.Buffer
 	failMetrics *buffer.Buffer
 
-	// Guards against concurrent calls to the Output as described in #3009
+	// diskBuffer replaces both in-memory buffers when the output is
+	// configured with a buffer_directory.
+	diskBuffer *buffer.DiskBuffer
+
+	// number of failed attempts to write the oldest batch, and the time
+	// before which no write is attempted.
+	retries int
+	retryAt time.Time
+
+	// Fingerprint identifies the configuration of the output.
+	Fingerprint string
+
+	// Guards the buffers during a write and against concurrent calls to the
+	// Output as described in #3009
 	sync.Mutex
 }
 
@@ -55,12 +80,13 @@ func NewRunningOutput(
 	}
 	ro := &RunningOutput{
 		Name:              name,
-		metrics:           buffer.NewBuffer(batchSize),
+		metrics:           buffer.NewBuffer(bufferLimit),
 		failMetrics:       buffer.NewBuffer(bufferLimit),
 		Output:            output,
 		Config:            conf,
 		MetricBufferLimit: bufferLimit,
 		MetricBatchSize:   batchSize,
+		BatchReady:        make(chan struct{}, 1),
 		MetricsWritten: selfstat.Register(
 			"write",
 			"metrics_written",
@@ -86,13 +112,67 @@ func NewRunningOutput(
 			"write_time_ns",
 			map[string]string{"output": name},
 		),
+		WriteErrors: writeErrors(name, 0),
 	}
 	ro.BufferLimit.Incr(int64(ro.MetricBufferLimit))
 	return ro
 }
 
-// AddMetric adds a metric to the output. This function can also write cached
-// points if FlushBufferWhenFull is true.
+// SetInstance sets the instance number of the output, which tags its error
+// counter.
+func (ro *RunningOutput) SetInstance(instance int) {
+	ro.Instance = instance
+	ro.WriteErrors = writeErrors(ro.Name, instance)
+}
+
+func writeErrors(name string, instance int) selfstat.Stat {
+	return selfstat.Register(
+		"write",
+		"errors",
+		map[string]string{"output": name, "instance": strconv.Itoa(instance)},
+	)
+}
+
+// OpenDiskBuffer switches the output over to the on-disk buffer configured in
+// its OutputConfig. Any metrics left in the buffer by a previous run are
+// replayed on the next write.
+func (ro *RunningOutput) OpenDiskBuffer() error {
+	db, err := buffer.NewDiskBuffer(
+		ro.Config.BufferDirectory,
+		ro.Config.BufferSegmentSize,
+		ro.Config.BufferMaxSize,
+		ro.Config.BufferMaxAge,
+	)
+	if err != nil {
+		return fmt.Errorf("could not open disk buffer for output %s: %s",
+			ro.Name, err)
+	}
+	if n := db.Len(); n > 0 {
+		log.Printf("I! Output [%s] replaying %d metrics from disk buffer %s",
+			ro.Name, n, ro.Config.BufferDirectory)
+	}
+	ro.diskBuffer = db
+	return nil
+}
+
+// ReleaseDiskBuffer closes the disk buffer so that another output can open
+// the same directory, the metrics in it are left to that output. The output
+// buffers in memory from then on.
+func (ro *RunningOutput) ReleaseDiskBuffer() error {
+	ro.Lock()
+	defer ro.Unlock()
+
+	if ro.diskBuffer == nil {
+		return nil
+	}
+	err := ro.diskBuffer.Close()
+	ro.diskBuffer = nil
+	return err
+}
+
+// AddMetric adds a metric to the output. Once a full batch has been added
+// BatchReady is signalled, the write itself is left to the caller of Write so
+// a slow output never blocks AddMetric.
 func (ro *RunningOutput) AddMetric(m telegraf.Metric) {
 	if m == nil {
 		return
@@ -107,24 +187,99 @@ func (ro *RunningOutput) AddMetric(m telegraf.Metric) {
 		t := m.Time()
 		if ok := ro.Config.Filter.Apply(name, fields, tags); !ok {
 			ro.MetricsFiltered.Incr(1)
+			m.Drop()
 			return
 		}
 		// error is not possible if creating from another metric, so ignore.
-		m, _ = metric.New(name, tags, fields, t)
+		filtered, _ := metric.New(name, tags, fields, t, m.Type())
+		filtered = metric.CarryTracking(m, filtered)
+		m.Drop()
+		m = filtered
 	}
 
-	ro.metrics.Add(m)
-	if ro.metrics.Len() == ro.MetricBatchSize {
-		batch := ro.metrics.Batch(ro.MetricBatchSize)
-		err := ro.write(batch)
-		if err != nil {
-			ro.failMetrics.Add(batch...)
+	if ro.diskBuffer != nil {
+		ro.diskBuffer.Add(m)
+		if ro.diskBuffer.Len() >= ro.MetricBatchSize {
+			ro.batchReady()
 		}
+		return
+	}
+
+	ro.metrics.Add(m)
+	if ro.metrics.Len() >= ro.MetricBatchSize {
+		ro.batchReady()
+	}
+}
+
+func (ro *RunningOutput) batchReady() {
+	select {
+	case ro.BatchReady <- struct{}{}:
+	default:
 	}
 }
 
-// Write writes all cached points to this output.
+// Write writes all cached points to this output. After a failed write no
+// other write is attempted until the retry backoff has passed.
 func (ro *RunningOutput) Write() error {
+	return ro.flush(false)
+}
+
+// Flush writes all cached points to this output even while backing off, it
+// is called one last time when the output is stopped. The metrics that could
+// not be written are rejected unless the output has a disk buffer.
+func (ro *RunningOutput) Flush() error {
+	err := ro.flush(true)
+	if err != nil {
+		ro.discard()
+	}
+	return err
+}
+
+// discard rejects the metrics left in the in-memory buffers, the metrics in
+// a disk buffer are kept for the next run.
+func (ro *RunningOutput) discard() {
+	ro.Lock()
+	defer ro.Unlock()
+
+	if ro.diskBuffer != nil {
+		return
+	}
+	n := ro.failMetrics.Len() + ro.metrics.Len()
+	if n == 0 {
+		return
+	}
+	log.Printf("E! Output [%s] dropping %d metrics left in the buffer",
+		ro.Name, n)
+	ro.drop(ro.failMetrics.Batch(ro.failMetrics.Len()))
+	ro.drop(ro.metrics.Batch(ro.metrics.Len()))
+	ro.BufferSize.Set(0)
+}
+
+func (ro *RunningOutput) flush(force bool) error {
+	ro.Lock()
+	defer ro.Unlock()
+
+	if wait := ro.retryAt.Sub(time.Now()); wait > 0 && !force {
+		if ro.diskBuffer != nil {
+			ro.BufferSize.Set(int64(ro.diskBuffer.Len()))
+		} else {
+			ro.BufferSize.Set(int64(ro.failMetrics.Len() + ro.metrics.Len()))
+		}
+		log.Printf("D! Output [%s] backing off, next write attempt in %s",
+			ro.Name, wait)
+		return nil
+	}
+
+	if ro.diskBuffer != nil {
+		return ro.writeDiskBuffer()
+	}
+
+	// the full batches added since the last write are queued behind the
+	// failed ones, only the last partial batch is left in ro.metrics.
+	for ro.metrics.Len() >= ro.MetricBatchSize {
+		ro.failMetrics.Add(ro.metrics.Batch(ro.MetricBatchSize)...)
+	}
+
 	nFails, nMetrics := ro.failMetrics.Len(), ro.metrics.Len()
 	ro.BufferSize.Set(int64(nFails + nMetrics))
 	log.Printf("D! Output [%s] buffer fullness: %d / %d metrics. ",
@@ -146,7 +301,7 @@ func (ro *RunningOutput) Write() error {
 			// write to this output again. We are not exiting the loop just so
 			// that we can rotate the metrics to preserve order.
 			if err == nil {
-				err = ro.write(batch)
+				err = ro.writeBatch(batch)
 			}
 			if err != nil {
 				ro.failMetrics.Add(batch...)
@@ -158,7 +313,7 @@ func (ro *RunningOutput) Write() error {
 	// see comment above about not trying to write to an already failed output.
 	// if ro.failMetrics is empty then err will always be nil at this point.
 	if err == nil {
-		err = ro.write(batch)
+		err = ro.writeBatch(batch)
 	}
 
 	if err != nil {
@@ -168,13 +323,103 @@ func (ro *RunningOutput) Write() error {
 	return nil
 }
 
+// writeDiskBuffer writes the metrics in the disk buffer in batches, stopping
+// at the first failed write. Only metrics that were in the buffer when the
+// write started are written, so a fast producer can't keep it going forever.
+func (ro *RunningOutput) writeDiskBuffer() error {
+	nMetrics := ro.diskBuffer.Len()
+	ro.BufferSize.Set(int64(nMetrics))
+	log.Printf("D! Output [%s] disk buffer fullness: %d metrics. ",
+		ro.Name, nMetrics)
+
+	for nMetrics > 0 {
+		batch := ro.diskBuffer.Batch(ro.MetricBatchSize)
+		if err := ro.writeBatch(batch); err != nil {
+			ro.diskBuffer.Reject()
+			return err
+		}
+		// dropped batches are removed from the disk buffer as well.
+		ro.diskBuffer.Accept()
+		nMetrics -= ro.MetricBatchSize
+	}
+	return nil
+}
+
+// writeBatch writes a batch and applies the retry policy when the write fails.
+// It returns nil if the batch was written or has been dropped, because the
+// error is not retryable or because it failed retry_max_attempts times.
+func (ro *RunningOutput) writeBatch(batch []telegraf.Metric) error {
+	err := ro.write(batch)
+	if err == nil {
+		ro.retries = 0
+		ro.retryAt = time.Time{}
+		return nil
+	}
+
+	if internal.IsNonRetryable(err) {
+		log.Printf("E! Output [%s] dropping batch of %d metrics, error is not retryable: %s",
+			ro.Name, len(batch), err)
+		ro.drop(batch)
+		return nil
+	}
+
+	ro.retries++
+	if ro.Config.RetryMaxAttempts > 0 && ro.retries >= ro.Config.RetryMaxAttempts {
+		log.Printf("E! Output [%s] dropping batch of %d metrics after %d failed attempts: %s",
+			ro.Name, len(batch), ro.retries, err)
+		ro.drop(batch)
+		return nil
+	}
+
+	ro.retryAt = time.Now().Add(ro.backoff())
+	return err
+}
+
+// backoff returns the time to wait before the next write attempt. It doubles
+// with every failed attempt up to retry_max_backoff, plus a random jitter.
+func (ro *RunningOutput) backoff() time.Duration {
+	backoff := ro.Config.RetryBackoff
+	for i := 1; i < ro.retries && i < 32; i++ {
+		if ro.Config.RetryMaxBackoff > 0 && backoff >= ro.Config.RetryMaxBackoff {
+			break
+		}
+		backoff *= 2
+	}
+	if ro.Config.RetryMaxBackoff > 0 && backoff > ro.Config.RetryMaxBackoff {
+		backoff = ro.Config.RetryMaxBackoff
+	}
+	return backoff + internal.RandomDuration(ro.Config.RetryJitter)
+}
+
+// drop discards a batch that won't be retried.
+func (ro *RunningOutput) drop(batch []telegraf.Metric) {
+	ro.retries = 0
+	ro.retryAt = time.Time{}
+	buffer.MetricsDropped.Incr(int64(len(batch)))
+	for _, m := range batch {
+		m.Reject()
+	}
+}
+
+// Close closes the output plugin and the disk buffer, if there is one. The
+// metrics still buffered in memory are rejected.
+func (ro *RunningOutput) Close() error {
+	ro.discard()
+	err := ro.Output.Close()
+	if ro.diskBuffer != nil {
+		if berr := ro.diskBuffer.Close(); berr != nil {
+			log.Printf("E! Could not close disk buffer for output %s: %s",
+				ro.Name, berr)
+		}
+	}
+	return err
+}
+
 func (ro *RunningOutput) write(metrics []telegraf.Metric) error {
 	nMetrics := len(metrics)
 	if nMetrics == 0 {
 		return nil
 	}
-	ro.Lock()
-	defer ro.Unlock()
 	start := time.Now()
 	err := ro.Output.Write(metrics)
 	elapsed := time.Since(start)
@@ -183,6 +428,11 @@ func (ro *RunningOutput) write(metrics []telegraf.Metric) error {
 			ro.Name, nMetrics, elapsed)
 		ro.MetricsWritten.Incr(int64(nMetrics))
 		ro.WriteTime.Incr(elapsed.Nanoseconds())
+		for _, m := range metrics {
+			m.Accept()
+		}
+	} else {
+		ro.WriteErrors.Incr(1)
 	}
 	return err
 }
@@ -191,4 +441,23 @@ func (ro *RunningOutput) write(metrics []telegraf.Metric) error {
 type OutputConfig struct {
 	Name   string
 	Filter Filter
+
+	// BufferDirectory enables the on-disk buffer when set.
+	BufferDirectory   string
+	BufferSegmentSize int64
+	BufferMaxSize     int64
+	BufferMaxAge      time.Duration
+
+	// Retry policy for failed writes. The delay before the next attempt
+	// starts at RetryBackoff and doubles up to RetryMaxBackoff, a batch is
+	// dropped after RetryMaxAttempts failed writes unless it is zero.
+	RetryBackoff     time.Duration
+	RetryMaxBackoff  time.Duration
+	RetryJitter      time.Duration
+	RetryMaxAttempts int
+
+	// Override the agent's flush settings for this output when non-zero.
+	FlushInterval   time.Duration
+	FlushJitter     time.Duration
+	MetricBatchSize int
 }
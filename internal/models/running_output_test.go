@@ -2,10 +2,15 @@ package models
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/testutil"
 
 	"github.com/stretchr/testify/assert"
@@ -267,8 +272,10 @@ func TestRunningOutputFlushWhenFull(t *testing.T) {
 
 	// add one more metric
 	ro.AddMetric(next5[0])
-	// now it flushed
-	assert.Len(t, m.Metrics(), 6)
+	// a batch is ready, but it is not written or moved by AddMetric
+	assert.Len(t, ro.BatchReady, 1)
+	assert.Len(t, m.Metrics(), 0)
+	assert.Equal(t, 0, ro.failMetrics.Len())
 
 	// add one more metric and write it manually
 	ro.AddMetric(next5[1])
@@ -294,8 +301,39 @@ func TestRunningOutputMultiFlushWhenFull(t *testing.T) {
 	for _, metric := range next5 {
 		ro.AddMetric(metric)
 	}
-	// flushed twice
-	assert.Len(t, m.Metrics(), 8)
+	// signalled once until the output is written
+	assert.Len(t, ro.BatchReady, 1)
+
+	err := ro.Write()
+	assert.NoError(t, err)
+	assert.Len(t, m.Metrics(), 10)
+	// verify that the batches were kept in order
+	assert.Equal(t, append(first5, next5...), m.Metrics())
+}
+
+// Test that metrics can be added while the output is written.
+func TestRunningOutputAddDuringWrite(t *testing.T) {
+	conf := &OutputConfig{
+		Filter: Filter{},
+	}
+
+	m := &mockOutput{}
+	ro := NewRunningOutput("test", m, conf, 2, 1000)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			ro.AddMetric(testutil.TestMetric(i, "metric"))
+		}
+	}()
+	for i := 0; i < 10; i++ {
+		require.NoError(t, ro.Write())
+	}
+	wg.Wait()
+	require.NoError(t, ro.Write())
+	assert.Len(t, m.Metrics(), 100)
 }
 
 func TestRunningOutputWriteFail(t *testing.T) {
@@ -479,6 +517,216 @@ func TestRunningOutputWriteFailOrder3(t *testing.T) {
 	assert.Equal(t, expected, m.Metrics())
 }
 
+// Verify that no write is attempted while backing off after a failure.
+func TestRunningOutputRetryBackoff(t *testing.T) {
+	conf := &OutputConfig{
+		Filter:       Filter{},
+		RetryBackoff: time.Hour,
+	}
+
+	m := &mockOutput{}
+	m.failWrite = true
+	ro := NewRunningOutput("test", m, conf, 5, 100)
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+	err := ro.Write()
+	require.Error(t, err)
+
+	// still backing off, the write is skipped
+	m.failWrite = false
+	err = ro.Write()
+	require.NoError(t, err)
+	assert.Len(t, m.Metrics(), 0)
+
+	// the buffer size is still reported
+	assert.Equal(t, int64(5), ro.BufferSize.Get())
+
+	// once the backoff has passed the metrics are written
+	ro.retryAt = time.Now()
+	err = ro.Write()
+	require.NoError(t, err)
+	assert.Equal(t, first5, m.Metrics())
+}
+
+// Verify that the final flush is not skipped while backing off.
+func TestRunningOutputFlushWhileBackingOff(t *testing.T) {
+	conf := &OutputConfig{
+		Filter:       Filter{},
+		RetryBackoff: time.Hour,
+	}
+
+	m := &mockOutput{}
+	m.failWrite = true
+	ro := NewRunningOutput("test", m, conf, 5, 100)
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+	require.Error(t, ro.Write())
+
+	m.failWrite = false
+	require.NoError(t, ro.Flush())
+	assert.Equal(t, first5, m.Metrics())
+}
+
+func TestRunningOutputRetryBackoffGrows(t *testing.T) {
+	conf := &OutputConfig{
+		Filter:          Filter{},
+		RetryBackoff:    time.Second,
+		RetryMaxBackoff: 5 * time.Second,
+	}
+	ro := NewRunningOutput("test", &mockOutput{}, conf, 5, 100)
+
+	expected := []time.Duration{
+		time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second,
+	}
+	for i, backoff := range expected {
+		ro.retries = i + 1
+		assert.Equal(t, backoff, ro.backoff())
+	}
+}
+
+// Verify that a batch is dropped after retry_max_attempts failed writes.
+func TestRunningOutputRetryMaxAttempts(t *testing.T) {
+	conf := &OutputConfig{
+		Filter:           Filter{},
+		RetryMaxAttempts: 2,
+	}
+
+	m := &mockOutput{}
+	m.failWrite = true
+	ro := NewRunningOutput("test", m, conf, 5, 100)
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+	err := ro.Write()
+	require.Error(t, err)
+	// second failure drops the batch
+	err = ro.Write()
+	require.NoError(t, err)
+
+	m.failWrite = false
+	for _, metric := range next5 {
+		ro.AddMetric(metric)
+	}
+	err = ro.Write()
+	require.NoError(t, err)
+	assert.Equal(t, next5, m.Metrics())
+}
+
+// Verify that a batch is dropped right away on a non retryable error.
+func TestRunningOutputNonRetryable(t *testing.T) {
+	conf := &OutputConfig{
+		Filter: Filter{},
+	}
+
+	m := &mockOutput{}
+	m.failWrite = true
+	m.nonRetryable = true
+	ro := NewRunningOutput("test", m, conf, 5, 100)
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+	err := ro.Write()
+	require.NoError(t, err)
+
+	m.failWrite = false
+	for _, metric := range next5 {
+		ro.AddMetric(metric)
+	}
+	err = ro.Write()
+	require.NoError(t, err)
+	assert.Equal(t, next5, m.Metrics())
+}
+
+// Verify that the metrics left after the last failed flush are rejected.
+func TestRunningOutputFlushFailRejects(t *testing.T) {
+	conf := &OutputConfig{
+		Filter: Filter{},
+	}
+
+	m := &mockOutput{}
+	m.failWrite = true
+	ro := NewRunningOutput("test", m, conf, 5, 100)
+
+	var rejected int
+	for _, metric := range first5 {
+		tm, _ := tracked(metric, &rejected)
+		ro.AddMetric(tm)
+	}
+	require.Error(t, ro.Flush())
+	assert.Equal(t, 5, rejected)
+	assert.Equal(t, 0, ro.failMetrics.Len()+ro.metrics.Len())
+}
+
+// Verify that the metrics still buffered in memory are rejected on Close.
+func TestRunningOutputCloseRejects(t *testing.T) {
+	conf := &OutputConfig{
+		Filter: Filter{},
+	}
+
+	m := &mockOutput{}
+	ro := NewRunningOutput("test", m, conf, 5, 100)
+
+	var rejected int
+	for _, metric := range first5[:3] {
+		tm, _ := tracked(metric, &rejected)
+		ro.AddMetric(tm)
+	}
+	require.NoError(t, ro.Close())
+	assert.Equal(t, 3, rejected)
+	assert.Empty(t, m.Metrics())
+}
+
+func tracked(m telegraf.Metric, rejected *int) (telegraf.Metric, telegraf.TrackingID) {
+	return metric.WithTracking(m.Copy(), func(info telegraf.DeliveryInfo) {
+		if !info.Delivered() {
+			*rejected++
+		}
+	})
+}
+
+// Verify that metrics in the disk buffer survive a failed write and a restart.
+func TestRunningOutputDiskBuffer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "telegraf-running-output")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	conf := &OutputConfig{
+		Filter:          Filter{},
+		BufferDirectory: dir,
+	}
+
+	m := &mockOutput{}
+	m.failWrite = true
+	ro := NewRunningOutput("test", m, conf, 4, 12)
+	require.NoError(t, ro.OpenDiskBuffer())
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+	require.Error(t, ro.Write())
+	require.NoError(t, ro.Close())
+
+	m.failWrite = false
+	ro = NewRunningOutput("test", m, conf, 4, 12)
+	require.NoError(t, ro.OpenDiskBuffer())
+	for _, metric := range next5 {
+		ro.AddMetric(metric)
+	}
+	require.NoError(t, ro.Write())
+	require.NoError(t, ro.Close())
+
+	require.Len(t, m.Metrics(), 10)
+	for i, metric := range append(first5, next5...) {
+		assert.Equal(t, metric.Name(), m.Metrics()[i].Name())
+	}
+}
+
 type mockOutput struct {
 	sync.Mutex
 
@@ -486,6 +734,8 @@ type mockOutput struct {
 
 	// if true, mock a write failure
 	failWrite bool
+	// if true, the mocked write failure is not retryable
+	nonRetryable bool
 }
 
 func (m *mockOutput) Connect() error {
@@ -507,6 +757,9 @@ func (m *mockOutput) SampleConfig() string {
 func (m *mockOutput) Write(metrics []telegraf.Metric) error {
 	m.Lock()
 	defer m.Unlock()
+	if m.failWrite && m.nonRetryable {
+		return internal.NonRetryable(fmt.Errorf("Failed Write!"))
+	}
 	if m.failWrite {
 		return fmt.Errorf("Failed Write!")
 	}
@@ -2,8 +2,10 @@ package models
 
 import (
 	"sync"
+	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
 )
 
 type RunningProcessor struct {
@@ -12,6 +14,13 @@ type RunningProcessor struct {
 	sync.Mutex
 	Processor telegraf.Processor
 	Config    *ProcessorConfig
+
+	// Fingerprint identifies the configuration of the processor.
+	Fingerprint string
+
+	// closed is set once a windowed processor has been flushed for the last
+	// time, the metrics it receives afterwards are released right away.
+	closed bool
 }
 
 type RunningProcessors []*RunningProcessor
@@ -25,6 +34,9 @@ type ProcessorConfig struct {
 	Name   string
 	Order  int64
 	Filter Filter
+
+	// Period is the window of a windowed processor.
+	Period time.Duration
 }
 
 func (rp *RunningProcessor) Apply(in ...telegraf.Metric) []telegraf.Metric {
@@ -44,8 +56,69 @@ func (rp *RunningProcessor) Apply(in ...telegraf.Metric) []telegraf.Metric {
 		}
 		// This metric should pass through the filter, so call the filter Apply
 		// function and append results to the output slice.
-		ret = append(ret, rp.Processor.Apply(metric)...)
+		if rp.IsWindowed() {
+			// windowed processors keep the metrics until they are flushed,
+			// they report the delivery of the metrics they drop.
+			ret = append(ret, rp.Processor.Apply(metric)...)
+			continue
+		}
+		ret = append(ret, carryTracking(metric, rp.Processor.Apply(metric))...)
 	}
 
+	if rp.closed {
+		ret = append(ret, rp.Processor.(telegraf.WindowedProcessor).Flush()...)
+	}
 	return ret
 }
+
+// IsWindowed returns true if the processor holds metrics back until it is
+// flushed.
+func (rp *RunningProcessor) IsWindowed() bool {
+	_, ok := rp.Processor.(telegraf.WindowedProcessor)
+	return ok
+}
+
+// Flush returns the metrics held back by a windowed processor during the
+// window that ended.
+func (rp *RunningProcessor) Flush() []telegraf.Metric {
+	p, ok := rp.Processor.(telegraf.WindowedProcessor)
+	if !ok {
+		return nil
+	}
+
+	rp.Lock()
+	defer rp.Unlock()
+	return p.Flush()
+}
+
+// Close flushes a windowed processor for the last time, the metrics it
+// receives afterwards are not held back anymore.
+func (rp *RunningProcessor) Close() []telegraf.Metric {
+	p, ok := rp.Processor.(telegraf.WindowedProcessor)
+	if !ok {
+		return nil
+	}
+
+	rp.Lock()
+	defer rp.Unlock()
+	rp.closed = true
+	return p.Flush()
+}
+
+// carryTracking makes sure the delivery of a tracked metric is still
+// reported when a processor drops it or replaces it with new metrics.
+func carryTracking(in telegraf.Metric, out []telegraf.Metric) []telegraf.Metric {
+	if !metric.IsTracking(in) {
+		return out
+	}
+	for _, m := range out {
+		if m == in {
+			return out
+		}
+	}
+	for i, m := range out {
+		out[i] = metric.CarryTracking(in, m)
+	}
+	in.Drop()
+	return out
+}
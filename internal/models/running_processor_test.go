@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/testutil"
 
 	"github.com/stretchr/testify/assert"
@@ -115,3 +116,54 @@ func TestRunningProcessor_DroppedMetric(t *testing.T) {
 	}
 	assert.Equal(t, expectedNames, actualNames)
 }
+
+// WindowedProcessor holds the metrics back until it is flushed.
+type WindowedProcessor struct {
+	metrics []telegraf.Metric
+}
+
+func (f *WindowedProcessor) SampleConfig() string { return "" }
+func (f *WindowedProcessor) Description() string  { return "" }
+
+func (f *WindowedProcessor) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	f.metrics = append(f.metrics, in...)
+	return nil
+}
+
+func (f *WindowedProcessor) Flush() []telegraf.Metric {
+	out := f.metrics
+	f.metrics = nil
+	return out
+}
+
+func TestRunningProcessor_Windowed(t *testing.T) {
+	var delivered int
+	m, _ := metric.WithTracking(testutil.TestMetric(1, "foo"),
+		func(telegraf.DeliveryInfo) { delivered++ })
+
+	rp := &RunningProcessor{
+		Name:      "windowed",
+		Processor: &WindowedProcessor{},
+		Config:    &ProcessorConfig{Filter: Filter{}},
+	}
+	assert.True(t, rp.IsWindowed())
+	assert.False(t, NewTestRunningProcessor().IsWindowed())
+	assert.Nil(t, NewTestRunningProcessor().Flush())
+
+	// the metric is held back, it is not reported as dropped.
+	assert.Len(t, rp.Apply(m), 0)
+	assert.Equal(t, 0, delivered)
+
+	flushed := rp.Flush()
+	assert.Len(t, flushed, 1)
+	assert.True(t, flushed[0] == m)
+	assert.Len(t, rp.Flush(), 0)
+
+	flushed[0].Accept()
+	assert.Equal(t, 1, delivered)
+
+	// once closed, the metrics are not held back anymore
+	assert.Len(t, rp.Apply(testutil.TestMetric(1, "foo")), 0)
+	assert.Len(t, rp.Close(), 1)
+	assert.Len(t, rp.Apply(testutil.TestMetric(1, "foo")), 1)
+}
@@ -0,0 +1,60 @@
+// Package redact keeps track of the secrets resolved from the configuration
+// so they can be masked before anything is printed or logged.
+package redact
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Mask replaces the secrets in redacted output.
+const Mask = "****"
+
+var (
+	mu       sync.RWMutex
+	secrets  = make(map[string]bool)
+	replacer = strings.NewReplacer()
+)
+
+// Add registers a secret to be masked.
+func Add(secret string) {
+	if secret == "" {
+		return
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if secrets[secret] {
+		return
+	}
+	secrets[secret] = true
+
+	// longer secrets first so a secret containing another one is masked as
+	// a whole.
+	sorted := make([]string, 0, len(secrets))
+	for s := range secrets {
+		sorted = append(sorted, s)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return len(sorted[i]) > len(sorted[j])
+	})
+	oldnew := make([]string, 0, 2*len(sorted))
+	for _, s := range sorted {
+		oldnew = append(oldnew, s, Mask)
+	}
+	replacer = strings.NewReplacer(oldnew...)
+}
+
+// String returns s with all the registered secrets masked.
+func String(s string) string {
+	mu.RLock()
+	r := replacer
+	mu.RUnlock()
+	return r.Replace(s)
+}
+
+// Bytes returns b with all the registered secrets masked.
+func Bytes(b []byte) []byte {
+	return []byte(String(string(b)))
+}
@@ -0,0 +1,19 @@
+package redact
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedact(t *testing.T) {
+	assert.Equal(t, "password=hunter2", String("password=hunter2"))
+
+	Add("hunter2")
+	Add("hunter2-admin")
+	Add("")
+	assert.Equal(t, "password=****", String("password=hunter2"))
+	assert.Equal(t, "user=**** password=****",
+		String("user=hunter2-admin password=hunter2"))
+	assert.Equal(t, []byte("****"), Bytes([]byte("hunter2")))
+}
@@ -7,6 +7,7 @@ import (
 	"regexp"
 	"time"
 
+	"github.com/influxdata/telegraf/internal/redact"
 	"github.com/influxdata/wlog"
 )
 
@@ -24,6 +25,7 @@ type telegrafLog struct {
 }
 
 func (t *telegrafLog) Write(b []byte) (n int, err error) {
+	b = redact.Bytes(b)
 	var line []byte
 	if !prefixRegex.Match(b) {
 		line = append([]byte(time.Now().UTC().Format(time.RFC3339)+" I! "), b...)
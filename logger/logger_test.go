@@ -7,6 +7,7 @@ import (
 	"os"
 	"testing"
 
+	"github.com/influxdata/telegraf/internal/redact"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -64,6 +65,20 @@ func TestAddDefaultLogLevel(t *testing.T) {
 	assert.Equal(t, f[19:], []byte("Z I! TEST\n"))
 }
 
+func TestRedactSecrets(t *testing.T) {
+	tmpfile, err := ioutil.TempFile("", "")
+	assert.NoError(t, err)
+	defer func() { os.Remove(tmpfile.Name()) }()
+
+	redact.Add("hunter2")
+	SetupLogging(false, false, tmpfile.Name())
+	log.Printf("I! password hunter2")
+
+	f, err := ioutil.ReadFile(tmpfile.Name())
+	assert.NoError(t, err)
+	assert.Equal(t, f[19:], []byte("Z I! password ****\n"))
+}
+
 func BenchmarkTelegrafLogWrite(b *testing.B) {
 	var msg = []byte("test")
 	var buf bytes.Buffer
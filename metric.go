@@ -13,6 +13,14 @@ const (
 	Counter
 	Gauge
 	Untyped
+	// Histogram metrics have a field per bucket, named after the upper bound
+	// of the bucket and holding its cumulative count, plus "count" and "sum"
+	// fields for the number and sum of the observations.
+	Histogram
+	// Summary metrics have a field per quantile, named after the quantile
+	// and holding its value, plus "count" and "sum" fields for the number and
+	// sum of the observations.
+	Summary
 )
 
 type Metric interface {
@@ -38,6 +46,8 @@ type Metric interface {
 
 	// Field functions
 	HasField(key string) bool
+	// AddField adds the field, replacing the field with the same key if the
+	// metric has one.
 	AddField(key string, value interface{})
 	RemoveField(key string) error
 
@@ -59,4 +69,15 @@ type Metric interface {
 	// aggregator things:
 	SetAggregate(bool)
 	IsAggregate() bool
+
+	// Accept marks the metric as processed successfully and written to an
+	// output.
+	Accept()
+
+	// Reject marks the metric as processed unsuccessfully.
+	Reject()
+
+	// Drop marks the metric as processed successfully without being written
+	// to any output.
+	Drop()
 }
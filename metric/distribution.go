@@ -0,0 +1,102 @@
+package metric
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/influxdata/telegraf"
+)
+
+// validateDistribution checks that the fields of a Histogram or Summary
+// metric are the count and sum of the observations, and numeric buckets or
+// quantiles named after their upper bound or quantile.
+func validateDistribution(
+	name string,
+	fields map[string]interface{},
+	mType telegraf.ValueType,
+) error {
+	for k, v := range fields {
+		if _, ok := toFloat(v); !ok {
+			return fmt.Errorf("%s: field %s must be numeric", name, k)
+		}
+		if k == "count" || k == "sum" {
+			continue
+		}
+
+		bound, err := strconv.ParseFloat(k, 64)
+		if err != nil || math.IsNaN(bound) {
+			return fmt.Errorf("%s: field %s is not a bucket upper bound or quantile",
+				name, k)
+		}
+		if mType == telegraf.Summary && (bound < 0 || bound > 1) {
+			return fmt.Errorf("%s: quantile %s is not between 0 and 1", name, k)
+		}
+	}
+	return nil
+}
+
+// Histogram returns the number and sum of the observations of a Histogram
+// metric, and the cumulative count of its buckets by upper bound.
+func Histogram(m telegraf.Metric) (count uint64, sum float64, buckets map[float64]uint64) {
+	buckets = make(map[float64]uint64)
+	for k, v := range m.Fields() {
+		f, _ := toFloat(v)
+		switch k {
+		case "count":
+			count = uint64(f)
+		case "sum":
+			sum = f
+		default:
+			bound, err := strconv.ParseFloat(k, 64)
+			if err != nil {
+				continue
+			}
+			buckets[bound] = uint64(f)
+		}
+	}
+	return count, sum, buckets
+}
+
+// Summary returns the number and sum of the observations of a Summary metric,
+// and the value of its quantiles.
+func Summary(m telegraf.Metric) (count uint64, sum float64, quantiles map[float64]float64) {
+	quantiles = make(map[float64]float64)
+	for k, v := range m.Fields() {
+		f, _ := toFloat(v)
+		switch k {
+		case "count":
+			count = uint64(f)
+		case "sum":
+			sum = f
+		default:
+			q, err := strconv.ParseFloat(k, 64)
+			if err != nil {
+				continue
+			}
+			quantiles[q] = f
+		}
+	}
+	return count, sum, quantiles
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,93 @@
+package metric
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistogram(t *testing.T) {
+	m, err := New("http_request_duration_seconds",
+		map[string]string{"handler": "query"},
+		map[string]interface{}{
+			"0.1":   float64(2),
+			"0.5":   int64(5),
+			"+Inf":  float64(7),
+			"count": float64(7),
+			"sum":   float64(3.2),
+		},
+		time.Unix(0, 0), telegraf.Histogram)
+	require.NoError(t, err)
+	assert.Equal(t, telegraf.Histogram, m.Type())
+
+	count, sum, buckets := Histogram(m)
+	assert.Equal(t, uint64(7), count)
+	assert.Equal(t, 3.2, sum)
+	assert.Equal(t, map[float64]uint64{0.1: 2, 0.5: 5, math.Inf(1): 7}, buckets)
+}
+
+func TestSummary(t *testing.T) {
+	m, err := New("rpc_duration_seconds",
+		map[string]string{},
+		map[string]interface{}{
+			"0.5":   float64(0.2),
+			"0.99":  float64(1.5),
+			"count": float64(10),
+			"sum":   float64(4),
+		},
+		time.Unix(0, 0), telegraf.Summary)
+	require.NoError(t, err)
+	assert.Equal(t, telegraf.Summary, m.Type())
+
+	count, sum, quantiles := Summary(m)
+	assert.Equal(t, uint64(10), count)
+	assert.Equal(t, float64(4), sum)
+	assert.Equal(t, map[float64]float64{0.5: 0.2, 0.99: 1.5}, quantiles)
+}
+
+func TestDistributionErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields map[string]interface{}
+		mType  telegraf.ValueType
+	}{
+		{
+			name:   "bucket name",
+			fields: map[string]interface{}{"le_0.5": float64(1)},
+			mType:  telegraf.Histogram,
+		},
+		{
+			name:   "string value",
+			fields: map[string]interface{}{"count": "1"},
+			mType:  telegraf.Histogram,
+		},
+		{
+			name:   "quantile out of range",
+			fields: map[string]interface{}{"99": float64(1)},
+			mType:  telegraf.Summary,
+		},
+		{
+			name:   "NaN quantile",
+			fields: map[string]interface{}{"NaN": float64(1)},
+			mType:  telegraf.Summary,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New("test", map[string]string{}, tt.fields,
+				time.Unix(0, 0), tt.mType)
+			assert.Error(t, err)
+		})
+	}
+
+	// the same fields are fine on untyped metrics
+	_, err := New("test", map[string]string{},
+		map[string]interface{}{"le_0.5": float64(1), "count": "1"},
+		time.Unix(0, 0))
+	assert.NoError(t, err)
+}
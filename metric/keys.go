@@ -0,0 +1,63 @@
+package metric
+
+import "bytes"
+
+// The tag and field keys are matched as a whole, a key is not found in the
+// keys it is a suffix of nor in string field values.
+
+// tagIndex returns the index of the comma preceding the tag key, or -1 if
+// the metric does not have the tag.
+func (m *metric) tagIndex(key string) int {
+	return bytes.Index(m.tags, []byte(","+escape(key, "tagkey")+"="))
+}
+
+// fieldIndex returns the start and end index of the field with the given key
+// in the fields of the metric, or -1 if there is no such field. Fields are
+// walked one by one, as string values can hold the key.
+func (m *metric) fieldIndex(key string) (int, int) {
+	k := []byte(escape(key, "tagkey"))
+	i := 0
+	for i < len(m.fields) {
+		// end index of field key
+		i1 := indexUnescapedByte(m.fields[i:], '=')
+		if i1 == -1 || i1+1 >= len(m.fields[i:]) {
+			break
+		}
+
+		// end index of field value
+		var i3 int
+		if m.fields[i:][i1+1] == '"' {
+			i3 = indexUnescapedByteBackslashEscaping(m.fields[i:][i1+2:], '"')
+			if i3 == -1 {
+				i3 = len(m.fields[i:])
+			} else {
+				i3 += i1 + 3
+			}
+		} else {
+			i3 = indexUnescapedByte(m.fields[i:], ',')
+			if i3 == -1 {
+				i3 = len(m.fields[i:])
+			}
+		}
+
+		if bytes.Equal(m.fields[i:i+i1], k) {
+			return i, i + i3
+		}
+		i += i3 + 1
+	}
+	return -1, -1
+}
+
+// removeField removes the field between start and end from fields, along
+// with its separating comma.
+func removeField(fields []byte, start, end int) []byte {
+	switch {
+	case end < len(fields):
+		// the comma after the field
+		end++
+	case start > 0:
+		// the comma before the last field
+		start--
+	}
+	return append(fields[:start], fields[end:]...)
+}
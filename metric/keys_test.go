@@ -0,0 +1,56 @@
+package metric
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewMetric_FieldModifiersKeyPrefix(t *testing.T) {
+	now := time.Unix(0, 0)
+	tags := map[string]string{
+		"resp_code": "200",
+	}
+	fields := map[string]interface{}{
+		"a":       "x,b=1",
+		"xb":      int64(1),
+		"b":       int64(2),
+		"request": "/",
+	}
+	m, err := New("cpu", tags, fields, now)
+	assert.NoError(t, err)
+
+	assert.False(t, m.HasTag("code"))
+	m.RemoveTag("code")
+	assert.Equal(t, "200", m.Tags()["resp_code"])
+
+	assert.False(t, m.HasField("x"))
+	assert.NoError(t, m.RemoveField("b"))
+	assert.Equal(t,
+		map[string]interface{}{"a": "x,b=1", "xb": int64(1), "request": "/"},
+		m.Fields())
+
+}
+
+func TestNewMetric_AddFieldReplaces(t *testing.T) {
+	m, err := New("cpu",
+		map[string]string{"resp_code": "200"},
+		map[string]interface{}{"xb": int64(1)},
+		time.Unix(0, 0),
+	)
+	assert.NoError(t, err)
+
+	m.AddField("b", int64(2))
+	assert.Equal(t, "cpu,resp_code=200 xb=1i,b=2i 0\n", m.String())
+
+	// replaces the existing field, the field with a suffix of the key is
+	// kept
+	m.AddField("b", "foo")
+	assert.Equal(t, "cpu,resp_code=200 xb=1i,b=\"foo\" 0\n", m.String())
+
+	// the only field can be replaced
+	assert.NoError(t, m.RemoveField("xb"))
+	m.AddField("b", int64(3))
+	assert.Equal(t, "cpu,resp_code=200 b=3i 0\n", m.String())
+}
@@ -38,6 +38,12 @@ func New(
 		thisType = telegraf.Untyped
 	}
 
+	if thisType == telegraf.Histogram || thisType == telegraf.Summary {
+		if err := validateDistribution(name, fields, thisType); err != nil {
+			return nil, err
+		}
+	}
+
 	m := &metric{
 		name:  []byte(escape(name, "name")),
 		t:     []byte(fmt.Sprint(t.UnixNano())),
@@ -189,6 +195,17 @@ func (m *metric) IsAggregate() bool {
 	return m.aggregate
 }
 
+// Accept, Reject and Drop are no-ops for untracked metrics, see
+// WithTracking.
+func (m *metric) Accept() {
+}
+
+func (m *metric) Reject() {
+}
+
+func (m *metric) Drop() {
+}
+
 func (m *metric) Type() telegraf.ValueType {
 	return m.mType
 }
@@ -271,7 +288,7 @@ func (m *metric) Split(maxSize int) []telegraf.Metric {
 		if i >= len(m.fields) {
 			// hit the end of the field byte slice
 			if len(fields) > 0 {
-				out = append(out, copyWith(m.name, m.tags, fields, m.t))
+				out = append(out, m.copyWith(fields))
 			}
 			break
 		}
@@ -291,7 +308,7 @@ func (m *metric) Split(maxSize int) []telegraf.Metric {
 			// selected field anyways. This means that the given maxSize is too
 			// small for a single field to fit.
 			if len(fields) > 0 {
-				out = append(out, copyWith(m.name, m.tags, fields, m.t))
+				out = append(out, m.copyWith(fields))
 			}
 
 			fields = make([]byte, 0, maxSize)
@@ -447,20 +464,17 @@ func (m *metric) AddTag(key, value string) {
 }
 
 func (m *metric) HasTag(key string) bool {
-	i := bytes.Index(m.tags, []byte(escape(key, "tagkey")+"="))
-	if i == -1 {
-		return false
-	}
-	return true
+	return m.tagIndex(key) != -1
 }
 
 func (m *metric) RemoveTag(key string) {
 	m.hashID = 0
 
-	i := bytes.Index(m.tags, []byte(escape(key, "tagkey")+"="))
+	i := m.tagIndex(key)
 	if i == -1 {
 		return
 	}
+	i++
 
 	tmp := m.tags[0 : i-1]
 	j := indexUnescapedByte(m.tags[i:], ',')
@@ -471,57 +485,56 @@ func (m *metric) RemoveTag(key string) {
 	return
 }
 
+// AddField adds the field to the metric, replacing the field with the same
+// key if there is one.
 func (m *metric) AddField(key string, value interface{}) {
-	m.fields = append(m.fields, ',')
+	if start, end := m.fieldIndex(key); start != -1 {
+		m.fields = removeField(m.fields, start, end)
+	}
+	if len(m.fields) > 0 {
+		m.fields = append(m.fields, ',')
+	}
 	m.fields = appendField(m.fields, key, value)
 }
 
 func (m *metric) HasField(key string) bool {
-	i := bytes.Index(m.fields, []byte(escape(key, "tagkey")+"="))
-	if i == -1 {
-		return false
-	}
-	return true
+	start, _ := m.fieldIndex(key)
+	return start != -1
 }
 
 func (m *metric) RemoveField(key string) error {
-	i := bytes.Index(m.fields, []byte(escape(key, "tagkey")+"="))
-	if i == -1 {
+	start, end := m.fieldIndex(key)
+	if start == -1 {
 		return nil
 	}
 
-	var tmp []byte
-	if i != 0 {
-		tmp = m.fields[0 : i-1]
-	}
-	j := indexUnescapedByte(m.fields[i:], ',')
-	if j != -1 {
-		tmp = append(tmp, m.fields[i+j:]...)
-	}
-
-	if len(tmp) == 0 {
+	if start == 0 && end == len(m.fields) {
 		return fmt.Errorf("Metric cannot remove final field: %s", m.fields)
 	}
 
-	m.fields = tmp
+	m.fields = removeField(m.fields, start, end)
 	return nil
 }
 
 func (m *metric) Copy() telegraf.Metric {
-	return copyWith(m.name, m.tags, m.fields, m.t)
+	return m.copyWith(m.fields)
 }
 
-func copyWith(name, tags, fields, t []byte) telegraf.Metric {
+// copyWith returns a copy of the metric with the given fields, the type and
+// aggregate flag are kept.
+func (m *metric) copyWith(fields []byte) telegraf.Metric {
 	out := metric{
-		name:   make([]byte, len(name)),
-		tags:   make([]byte, len(tags)),
-		fields: make([]byte, len(fields)),
-		t:      make([]byte, len(t)),
-	}
-	copy(out.name, name)
-	copy(out.tags, tags)
+		name:      make([]byte, len(m.name)),
+		tags:      make([]byte, len(m.tags)),
+		fields:    make([]byte, len(fields)),
+		t:         make([]byte, len(m.t)),
+		mType:     m.mType,
+		aggregate: m.aggregate,
+	}
+	copy(out.name, m.name)
+	copy(out.tags, m.tags)
 	copy(out.fields, fields)
-	copy(out.t, t)
+	copy(out.t, m.t)
 	return &out
 }
 
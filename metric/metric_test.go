@@ -310,6 +310,24 @@ func TestNewMetric_Copy(t *testing.T) {
 		m2.String())
 }
 
+func TestNewMetric_CopyKeepsType(t *testing.T) {
+	now := time.Now()
+	fields := map[string]interface{}{
+		"count": int64(3),
+		"sum":   float64(1.5),
+	}
+	for _, mType := range []telegraf.ValueType{telegraf.Histogram, telegraf.Summary} {
+		m, err := New("rpc", map[string]string{}, fields, now, mType)
+		assert.NoError(t, err)
+		m.SetAggregate(true)
+
+		m2 := m.Copy()
+		assert.Equal(t, mType, m2.Type())
+		assert.True(t, m2.IsAggregate())
+		assert.Equal(t, m.String(), m2.String())
+	}
+}
+
 func TestNewMetric_AllTypes(t *testing.T) {
 	now := time.Now()
 	tags := map[string]string{}
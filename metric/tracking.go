@@ -0,0 +1,122 @@
+package metric
+
+import (
+	"sync/atomic"
+
+	"github.com/influxdata/telegraf"
+)
+
+// NotifyFunc is called once every metric of a tracked group has been
+// accepted, rejected or dropped.
+type NotifyFunc func(telegraf.DeliveryInfo)
+
+var lastID uint64
+
+func newTrackingID() telegraf.TrackingID {
+	return telegraf.TrackingID(atomic.AddUint64(&lastID, 1))
+}
+
+type trackingData struct {
+	id       telegraf.TrackingID
+	rc       int32
+	rejected int32
+	notify   NotifyFunc
+}
+
+func (d *trackingData) incr() {
+	atomic.AddInt32(&d.rc, 1)
+}
+
+func (d *trackingData) decr() {
+	if atomic.AddInt32(&d.rc, -1) == 0 {
+		d.notify(&deliveryInfo{
+			id:        d.id,
+			delivered: atomic.LoadInt32(&d.rejected) == 0,
+		})
+	}
+}
+
+type deliveryInfo struct {
+	id        telegraf.TrackingID
+	delivered bool
+}
+
+func (r *deliveryInfo) ID() telegraf.TrackingID {
+	return r.id
+}
+
+func (r *deliveryInfo) Delivered() bool {
+	return r.delivered
+}
+
+// trackingMetric wraps a metric and reports to its group once it reaches
+// the end of the pipeline. Copies share the tracking data, so the group is
+// only notified after every copy has been finished.
+type trackingMetric struct {
+	telegraf.Metric
+	d *trackingData
+}
+
+// WithTracking wraps m so that notify is called once it has been accepted,
+// rejected or dropped.
+func WithTracking(m telegraf.Metric, notify NotifyFunc) (telegraf.Metric, telegraf.TrackingID) {
+	group, id := WithGroupTracking([]telegraf.Metric{m}, notify)
+	return group[0], id
+}
+
+// WithGroupTracking wraps all metrics of the group so that notify is called
+// once, after every one of them has been accepted, rejected or dropped. An
+// empty group is reported as delivered right away.
+func WithGroupTracking(group []telegraf.Metric, notify NotifyFunc) ([]telegraf.Metric, telegraf.TrackingID) {
+	d := &trackingData{
+		id:     newTrackingID(),
+		rc:     int32(len(group)),
+		notify: notify,
+	}
+	if len(group) == 0 {
+		notify(&deliveryInfo{id: d.id, delivered: true})
+		return group, d.id
+	}
+
+	out := make([]telegraf.Metric, len(group))
+	for i, m := range group {
+		out[i] = &trackingMetric{Metric: m, d: d}
+	}
+	return out, d.id
+}
+
+// IsTracking returns true if m is tracked.
+func IsTracking(m telegraf.Metric) bool {
+	_, ok := m.(*trackingMetric)
+	return ok
+}
+
+// CarryTracking is used when a new metric replaces a tracked one, it returns
+// to wrapped with the tracking of from. from still has to be accepted,
+// rejected or dropped by the caller.
+func CarryTracking(from, to telegraf.Metric) telegraf.Metric {
+	tm, ok := from.(*trackingMetric)
+	if !ok || IsTracking(to) {
+		return to
+	}
+	tm.d.incr()
+	return &trackingMetric{Metric: to, d: tm.d}
+}
+
+func (m *trackingMetric) Copy() telegraf.Metric {
+	m.d.incr()
+	return &trackingMetric{Metric: m.Metric.Copy(), d: m.d}
+}
+
+func (m *trackingMetric) Accept() {
+	m.d.decr()
+}
+
+func (m *trackingMetric) Reject() {
+	atomic.AddInt32(&m.d.rejected, 1)
+	m.d.decr()
+}
+
+func (m *trackingMetric) Drop() {
+	m.d.decr()
+}
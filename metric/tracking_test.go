@@ -0,0 +1,91 @@
+package metric
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type deliveries struct {
+	infos []telegraf.DeliveryInfo
+}
+
+func (d *deliveries) notify(info telegraf.DeliveryInfo) {
+	d.infos = append(d.infos, info)
+}
+
+func mustMetric(t *testing.T, name string) telegraf.Metric {
+	m, err := New(name, map[string]string{}, map[string]interface{}{"value": 42}, time.Unix(0, 0))
+	require.NoError(t, err)
+	return m
+}
+
+func TestTrackingAccept(t *testing.T) {
+	d := &deliveries{}
+	m, id := WithTracking(mustMetric(t, "cpu"), d.notify)
+	assert.True(t, IsTracking(m))
+
+	m.Accept()
+	require.Len(t, d.infos, 1)
+	assert.Equal(t, id, d.infos[0].ID())
+	assert.True(t, d.infos[0].Delivered())
+}
+
+func TestTrackingGroupWaitsForAll(t *testing.T) {
+	d := &deliveries{}
+	group, _ := WithGroupTracking(
+		[]telegraf.Metric{mustMetric(t, "cpu"), mustMetric(t, "mem")}, d.notify)
+
+	group[0].Accept()
+	assert.Len(t, d.infos, 0)
+	group[1].Drop()
+	require.Len(t, d.infos, 1)
+	assert.True(t, d.infos[0].Delivered())
+}
+
+func TestTrackingReject(t *testing.T) {
+	d := &deliveries{}
+	group, _ := WithGroupTracking(
+		[]telegraf.Metric{mustMetric(t, "cpu"), mustMetric(t, "mem")}, d.notify)
+
+	group[0].Reject()
+	group[1].Accept()
+	require.Len(t, d.infos, 1)
+	assert.False(t, d.infos[0].Delivered())
+}
+
+func TestTrackingCopy(t *testing.T) {
+	d := &deliveries{}
+	m, _ := WithTracking(mustMetric(t, "cpu"), d.notify)
+
+	c := m.Copy()
+	assert.True(t, IsTracking(c))
+	m.Accept()
+	assert.Len(t, d.infos, 0)
+	c.Accept()
+	assert.Len(t, d.infos, 1)
+}
+
+func TestTrackingCarry(t *testing.T) {
+	d := &deliveries{}
+	m, _ := WithTracking(mustMetric(t, "cpu"), d.notify)
+
+	n := CarryTracking(m, mustMetric(t, "cpu_new"))
+	assert.True(t, IsTracking(n))
+	m.Drop()
+	assert.Len(t, d.infos, 0)
+	n.Accept()
+	assert.Len(t, d.infos, 1)
+}
+
+func TestTrackingEmptyGroup(t *testing.T) {
+	d := &deliveries{}
+	_, id := WithGroupTracking(nil, d.notify)
+	require.Len(t, d.infos, 1)
+	assert.Equal(t, id, d.infos[0].ID())
+	assert.True(t, d.infos[0].Delivered())
+}
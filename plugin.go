@@ -0,0 +1,9 @@
+package telegraf
+
+// Initializer is implemented by the plugins that have to check or prepare
+// their configuration before they are used.
+type Initializer interface {
+	// Init is called once the configuration of the plugin is loaded, an
+	// error fails the loading of the configuration.
+	Init() error
+}
@@ -0,0 +1,273 @@
+package derivative
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/aggregators"
+)
+
+var sampleConfig = `
+  ## The period on which to flush & clear the aggregator.
+  period = "30s"
+  ## If true, the original metric will be dropped by the
+  ## aggregator and will not get sent to the output plugins.
+  drop_original = false
+
+  ## Fields of which the rate is computed, globs can be used.
+  fields = ["bytes_recv", "bytes_sent"]
+
+  ## Suffix added to the name of the fields to name the rates.
+  # suffix = "_rate"
+
+  ## The rates are emitted per unit of time.
+  # unit = "1s"
+
+  ## Maximum time between two points of a series for the rate to be
+  ## computed, no rate is emitted for longer gaps. 0 disables the limit.
+  # max_gap = "0s"
+
+  ## Time after which the last points of a series that was not seen are
+  ## forgotten. The series not seen for the max_gap are forgotten as well.
+  # expiry = "1h"
+
+  ## Size in bits of the counters, 32 or 64, for the rate to be computed when
+  ## they wrap around. A decrease of the counter is a wraparound if the counter
+  ## increased by less than half its range, otherwise it is considered a
+  ## counter reset and no rate is emitted. When unset, decreases are always
+  ## counter resets.
+  # counter_bits = 0
+`
+
+// Derivative emits the rate of change of the fields of each series, between
+// consecutive points of the series.
+type Derivative struct {
+	Fields      []string
+	Suffix      string
+	Unit        internal.Duration
+	Expiry      internal.Duration
+	MaxGap      internal.Duration `toml:"max_gap"`
+	CounterBits uint              `toml:"counter_bits"`
+
+	fieldFilter filter.Filter
+
+	// series holds the last point of each series, it is kept across periods.
+	series map[uint64]*series
+	rates  []rate
+	// now returns the current time.
+	now func() time.Time
+}
+
+// series holds the last point of each field of a series, and when the series
+// was last seen.
+type series struct {
+	points   map[string]point
+	lastSeen time.Time
+}
+
+type point struct {
+	value interface{}
+	time  time.Time
+}
+
+// rate is a set of rates of a series, waiting to be pushed.
+type rate struct {
+	name   string
+	tags   map[string]string
+	fields map[string]interface{}
+	time   time.Time
+}
+
+func NewDerivative() telegraf.Aggregator {
+	return &Derivative{
+		Suffix: "_rate",
+		Unit:   internal.Duration{Duration: time.Second},
+		Expiry: internal.Duration{Duration: time.Hour},
+		series: make(map[uint64]*series),
+		now:    time.Now,
+	}
+}
+
+func (d *Derivative) SampleConfig() string {
+	return sampleConfig
+}
+
+func (d *Derivative) Description() string {
+	return "Emit the rate of change of counters between consecutive points."
+}
+
+// Init checks the settings and compiles the fields filter, so that an
+// invalid configuration fails to load.
+func (d *Derivative) Init() error {
+	switch d.CounterBits {
+	case 0, 32, 64:
+	default:
+		return fmt.Errorf("counter_bits must be 32 or 64, got %d", d.CounterBits)
+	}
+	if d.Unit.Duration <= 0 {
+		return fmt.Errorf("unit must be positive, got %s", d.Unit.Duration)
+	}
+	if d.Expiry.Duration <= 0 {
+		return fmt.Errorf("expiry must be positive, got %s", d.Expiry.Duration)
+	}
+
+	var err error
+	d.fieldFilter, err = filter.Compile(d.Fields)
+	if err != nil {
+		return fmt.Errorf("invalid fields: %s", err)
+	}
+	if d.fieldFilter == nil {
+		return fmt.Errorf("no fields to compute the rate of")
+	}
+	if d.series == nil {
+		d.series = make(map[uint64]*series)
+	}
+	if d.now == nil {
+		d.now = time.Now
+	}
+	return nil
+}
+
+func (d *Derivative) Add(in telegraf.Metric) {
+	id := in.HashID()
+	s, ok := d.series[id]
+	if !ok {
+		s = &series{points: make(map[string]point)}
+		d.series[id] = s
+	}
+	// the series expire using the clock, the rates are computed using the
+	// times of the metrics.
+	s.lastSeen = d.now()
+
+	t := in.Time()
+	fields := make(map[string]interface{})
+	for k, v := range in.Fields() {
+		if !d.fieldFilter.Match(k) {
+			continue
+		}
+		switch v.(type) {
+		case int64, uint64, float64:
+		default:
+			continue
+		}
+
+		// the points older than the last point of the field are ignored.
+		prev, seen := s.points[k]
+		if seen && !t.After(prev.time) {
+			continue
+		}
+		s.points[k] = point{value: v, time: t}
+
+		dt := t.Sub(prev.time)
+		if !seen || (d.MaxGap.Duration > 0 && dt > d.MaxGap.Duration) {
+			continue
+		}
+		if delta, ok := d.delta(prev.value, v); ok {
+			fields[k+d.Suffix] = delta / (float64(dt) / float64(d.Unit.Duration))
+		}
+	}
+
+	if len(fields) > 0 {
+		d.rates = append(d.rates, rate{
+			name:   in.Name(),
+			tags:   in.Tags(),
+			fields: fields,
+			time:   t,
+		})
+	}
+}
+
+func (d *Derivative) Push(acc telegraf.Accumulator) {
+	for _, r := range d.rates {
+		acc.AddFields(r.name, r.fields, r.tags, r.time)
+	}
+}
+
+// Reset clears the rates that were pushed, the last points of the series are
+// kept to compute the rates of the next period. The series that were not seen
+// for the expiry or the max gap are forgotten.
+func (d *Derivative) Reset() {
+	d.rates = nil
+
+	expiry := d.Expiry.Duration
+	if d.MaxGap.Duration > 0 && d.MaxGap.Duration < expiry {
+		expiry = d.MaxGap.Duration
+	}
+
+	now := d.now()
+	for id, s := range d.series {
+		if now.Sub(s.lastSeen) > expiry {
+			delete(d.series, id)
+		}
+	}
+}
+
+// delta returns the increase of a counter from prev to cur. ok is false if the
+// counter was reset. Integer counters are subtracted as integers so that
+// large 64 bits counters do not lose precision.
+func (d *Derivative) delta(prev, cur interface{}) (float64, bool) {
+	p, pok := toUnsigned(prev)
+	c, cok := toUnsigned(cur)
+	if !pok || !cok {
+		pf, cf := toFloat(prev), toFloat(cur)
+		if cf >= pf {
+			return cf - pf, true
+		}
+		return 0, false
+	}
+
+	if c >= p {
+		return float64(c - p), true
+	}
+	if d.CounterBits == 0 {
+		return 0, false
+	}
+
+	// a counter that wrapped around should have made less than half a turn
+	// since the previous point, a larger decrease is a reset.
+	half := uint64(1) << (d.CounterBits - 1)
+	if d.CounterBits < 64 && p >= 2*half {
+		return 0, false
+	}
+	delta := c - p
+	if d.CounterBits < 64 {
+		delta += 2 * half
+	}
+	if delta >= half {
+		return 0, false
+	}
+	return float64(delta), true
+}
+
+func toUnsigned(in interface{}) (uint64, bool) {
+	switch v := in.(type) {
+	case int64:
+		return uint64(v), v >= 0
+	case uint64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
+
+func toFloat(in interface{}) float64 {
+	switch v := in.(type) {
+	case float64:
+		return v
+	case int64:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	default:
+		return 0
+	}
+}
+
+func init() {
+	aggregators.Add("derivative", func() telegraf.Aggregator {
+		return NewDerivative()
+	})
+}
@@ -0,0 +1,200 @@
+package derivative
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var epoch = time.Now().Truncate(time.Second)
+
+func newMetric(iface string, fields map[string]interface{}, offset time.Duration) telegraf.Metric {
+	m, err := metric.New("net", map[string]string{"interface": iface}, fields, epoch.Add(offset))
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+func newDerivative(t *testing.T, fields ...string) *Derivative {
+	d := NewDerivative().(*Derivative)
+	d.Fields = fields
+	require.NoError(t, d.Init())
+	return d
+}
+
+func TestDerivative(t *testing.T) {
+	acc := testutil.Accumulator{}
+	d := newDerivative(t, "bytes_*")
+
+	d.Add(newMetric("eth0", map[string]interface{}{"bytes_recv": int64(100), "bytes_sent": uint64(10), "drops": int64(1)}, 0))
+	d.Add(newMetric("eth0", map[string]interface{}{"bytes_recv": int64(300), "bytes_sent": uint64(40), "drops": int64(3)}, 10*time.Second))
+	d.Add(newMetric("eth1", map[string]interface{}{"bytes_recv": int64(5)}, 10*time.Second))
+	d.Add(newMetric("eth0", map[string]interface{}{"bytes_recv": 400.0}, 20*time.Second))
+	d.Push(&acc)
+
+	require.Len(t, acc.Metrics, 2)
+	acc.AssertContainsTaggedFields(t, "net",
+		map[string]interface{}{"bytes_recv_rate": 20.0, "bytes_sent_rate": 3.0},
+		map[string]string{"interface": "eth0"})
+	assert.Equal(t, epoch.Add(10*time.Second), acc.Metrics[0].Time)
+	assert.Equal(t, map[string]interface{}{"bytes_recv_rate": 10.0}, acc.Metrics[1].Fields)
+
+	// the last points are kept across periods
+	acc.ClearMetrics()
+	d.Reset()
+	d.Add(newMetric("eth1", map[string]interface{}{"bytes_recv": int64(65)}, 40*time.Second))
+	d.Push(&acc)
+
+	require.Len(t, acc.Metrics, 1)
+	acc.AssertContainsTaggedFields(t, "net",
+		map[string]interface{}{"bytes_recv_rate": 2.0},
+		map[string]string{"interface": "eth1"})
+}
+
+func TestDerivativeUnit(t *testing.T) {
+	acc := testutil.Accumulator{}
+	d := newDerivative(t, "bytes_recv")
+	d.Suffix = "_per_minute"
+	d.Unit = internal.Duration{Duration: time.Minute}
+
+	d.Add(newMetric("eth0", map[string]interface{}{"bytes_recv": int64(100)}, 0))
+	d.Add(newMetric("eth0", map[string]interface{}{"bytes_recv": int64(200)}, 10*time.Second))
+	d.Push(&acc)
+
+	acc.AssertContainsFields(t, "net", map[string]interface{}{"bytes_recv_per_minute": 600.0})
+}
+
+func TestDerivativeCounterReset(t *testing.T) {
+	tests := []struct {
+		name     string
+		bits     uint
+		prev     interface{}
+		cur      interface{}
+		expected interface{}
+	}{
+		{"reset", 0, int64(1000), int64(10), nil},
+		{"32 bits wraparound", 32, int64(math.MaxUint32 - 9), int64(10), 2.0},
+		{"32 bits reset", 32, int64(1000), int64(10), nil},
+		{"32 bits larger than counter", 32, int64(math.MaxUint32 + 100), int64(10), nil},
+		{"64 bits reset", 64, int64(math.MaxInt64 - 2047), int64(2048), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			acc := testutil.Accumulator{}
+			d := newDerivative(t, "counter")
+			d.CounterBits = tt.bits
+
+			d.Add(newMetric("eth0", map[string]interface{}{"counter": tt.prev}, 0))
+			d.Add(newMetric("eth0", map[string]interface{}{"counter": tt.cur}, 10*time.Second))
+			d.Push(&acc)
+
+			if tt.expected == nil {
+				assert.Len(t, acc.Metrics, 0)
+				return
+			}
+			require.Len(t, acc.Metrics, 1)
+			assert.InDelta(t, tt.expected, acc.Metrics[0].Fields["counter_rate"], 1e-6)
+		})
+	}
+}
+
+func TestDerivativeDelta64Bits(t *testing.T) {
+	d := newDerivative(t, "counter")
+	d.CounterBits = 64
+
+	delta, ok := d.delta(uint64(math.MaxUint64-2047), uint64(2048))
+	assert.True(t, ok)
+	assert.Equal(t, 4096.0, delta)
+
+	_, ok = d.delta(uint64(math.MaxUint64/2), uint64(2048))
+	assert.False(t, ok)
+}
+
+func TestDerivativeMaxGap(t *testing.T) {
+	acc := testutil.Accumulator{}
+	d := newDerivative(t, "bytes_recv")
+	d.MaxGap = internal.Duration{Duration: time.Minute}
+
+	d.Add(newMetric("eth0", map[string]interface{}{"bytes_recv": int64(100)}, 0))
+	d.Add(newMetric("eth0", map[string]interface{}{"bytes_recv": int64(200)}, 2*time.Minute))
+	d.Add(newMetric("eth0", map[string]interface{}{"bytes_recv": int64(800)}, 3*time.Minute))
+	d.Push(&acc)
+
+	require.Len(t, acc.Metrics, 1)
+	assert.Equal(t, map[string]interface{}{"bytes_recv_rate": 10.0}, acc.Metrics[0].Fields)
+}
+
+func TestDerivativeFieldTimes(t *testing.T) {
+	acc := testutil.Accumulator{}
+	d := newDerivative(t, "a", "b")
+
+	// the rate of a field is computed since the last point of the field,
+	// not of the series.
+	d.Add(newMetric("eth0", map[string]interface{}{"a": int64(0)}, 0))
+	d.Add(newMetric("eth0", map[string]interface{}{"b": int64(0)}, 90*time.Second))
+	d.Add(newMetric("eth0", map[string]interface{}{"a": int64(100)}, 100*time.Second))
+	// older points are ignored
+	d.Add(newMetric("eth0", map[string]interface{}{"a": int64(50)}, 50*time.Second))
+	d.Add(newMetric("eth0", map[string]interface{}{"a": int64(120)}, 110*time.Second))
+	d.Push(&acc)
+
+	require.Len(t, acc.Metrics, 2)
+	assert.Equal(t, map[string]interface{}{"a_rate": 1.0}, acc.Metrics[0].Fields)
+	assert.Equal(t, map[string]interface{}{"a_rate": 2.0}, acc.Metrics[1].Fields)
+}
+
+func TestDerivativeExpiry(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name   string
+		maxGap time.Duration
+		idle   time.Duration
+		kept   bool
+	}{
+		{"seen", 0, 30 * time.Minute, true},
+		{"expired", 0, 2 * time.Hour, false},
+		{"max gap", time.Minute, 2 * time.Minute, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newDerivative(t, "bytes_recv")
+			d.MaxGap = internal.Duration{Duration: tt.maxGap}
+			d.now = func() time.Time {
+				return now
+			}
+
+			// the times of the metrics are not used for the expiry
+			d.Add(newMetric("eth0", map[string]interface{}{"bytes_recv": int64(100)}, -24*time.Hour))
+			d.now = func() time.Time {
+				return now.Add(tt.idle)
+			}
+			d.Reset()
+			assert.Equal(t, tt.kept, len(d.series) == 1)
+		})
+	}
+}
+
+func TestDerivativeInvalidConfig(t *testing.T) {
+	noFields := NewDerivative().(*Derivative)
+	badFields := NewDerivative().(*Derivative)
+	badFields.Fields = []string{"[a"}
+	for _, d := range []*Derivative{
+		noFields,
+		badFields,
+		{Fields: []string{"a"}, CounterBits: 16, Unit: internal.Duration{Duration: time.Second}},
+		{Fields: []string{"a"}},
+		{Fields: []string{"a"}, Unit: internal.Duration{Duration: time.Second}},
+	} {
+		assert.Error(t, d.Init())
+	}
+}
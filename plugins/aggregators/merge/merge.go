@@ -0,0 +1,88 @@
+package merge
+
+import (
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/aggregators"
+)
+
+var sampleConfig = `
+  ## The period on which to flush & clear the aggregator.
+  period = "30s"
+  ## If true, the original metric will be dropped by the
+  ## aggregator and will not get sent to the output plugins.
+  drop_original = true
+`
+
+// Merge combines the metrics of a period that have the same measurement, tags
+// and timestamp into a single metric.
+type Merge struct {
+	cache map[seriesTime]*aggregate
+	order []seriesTime
+}
+
+// seriesTime identifies the metrics of a series at a given time.
+type seriesTime struct {
+	id uint64
+	t  int64
+}
+
+type aggregate struct {
+	name   string
+	tags   map[string]string
+	fields map[string]interface{}
+	t      time.Time
+}
+
+func NewMerge() telegraf.Aggregator {
+	m := &Merge{}
+	m.Reset()
+	return m
+}
+
+func (m *Merge) SampleConfig() string {
+	return sampleConfig
+}
+
+func (m *Merge) Description() string {
+	return "Merge metrics with the same series and timestamp into a single metric."
+}
+
+func (m *Merge) Add(in telegraf.Metric) {
+	key := seriesTime{id: in.HashID(), t: in.Time().UnixNano()}
+	a, ok := m.cache[key]
+	if !ok {
+		a = &aggregate{
+			name:   in.Name(),
+			tags:   in.Tags(),
+			fields: make(map[string]interface{}),
+			t:      in.Time(),
+		}
+		m.cache[key] = a
+		m.order = append(m.order, key)
+	}
+
+	for k, v := range in.Fields() {
+		a.fields[k] = v
+	}
+}
+
+// Push emits the merged metrics in the order their first metric was added.
+func (m *Merge) Push(acc telegraf.Accumulator) {
+	for _, key := range m.order {
+		a := m.cache[key]
+		acc.AddFields(a.name, a.fields, a.tags, a.t)
+	}
+}
+
+func (m *Merge) Reset() {
+	m.cache = make(map[seriesTime]*aggregate)
+	m.order = nil
+}
+
+func init() {
+	aggregators.Add("merge", func() telegraf.Aggregator {
+		return NewMerge()
+	})
+}
@@ -0,0 +1,51 @@
+package merge
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMetric(tags map[string]string, fields map[string]interface{}, t time.Time) telegraf.Metric {
+	m, err := metric.New("ifTable", tags, fields, t)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+func TestMerge(t *testing.T) {
+	acc := testutil.Accumulator{}
+	merge := NewMerge()
+	now := time.Unix(1514764800, 0)
+	eth0 := map[string]string{"ifDescr": "eth0"}
+	eth1 := map[string]string{"ifDescr": "eth1"}
+
+	merge.Add(newMetric(eth0, map[string]interface{}{"ifInOctets": int64(1)}, now))
+	merge.Add(newMetric(eth1, map[string]interface{}{"ifInOctets": int64(10)}, now))
+	merge.Add(newMetric(eth0, map[string]interface{}{"ifOutOctets": int64(2)}, now))
+	merge.Add(newMetric(eth0, map[string]interface{}{"ifInOctets": int64(3)}, now.Add(time.Second)))
+	merge.Add(newMetric(eth0, map[string]interface{}{"ifInOctets": int64(4), "ifSpeed": int64(1000)}, now))
+	merge.Push(&acc)
+
+	require.Len(t, acc.Metrics, 3)
+	assert.Equal(t, eth0, acc.Metrics[0].Tags)
+	assert.Equal(t, now, acc.Metrics[0].Time)
+	assert.Equal(t,
+		map[string]interface{}{"ifInOctets": int64(4), "ifOutOctets": int64(2), "ifSpeed": int64(1000)},
+		acc.Metrics[0].Fields)
+	assert.Equal(t, eth1, acc.Metrics[1].Tags)
+	assert.Equal(t, map[string]interface{}{"ifInOctets": int64(10)}, acc.Metrics[1].Fields)
+	assert.Equal(t, now.Add(time.Second), acc.Metrics[2].Time)
+	assert.Equal(t, map[string]interface{}{"ifInOctets": int64(3)}, acc.Metrics[2].Fields)
+
+	acc.ClearMetrics()
+	merge.Reset()
+	merge.Push(&acc)
+	assert.Len(t, acc.Metrics, 0)
+}
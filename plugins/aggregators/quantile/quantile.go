@@ -0,0 +1,230 @@
+package quantile
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/plugins/aggregators"
+)
+
+var sampleConfig = `
+  ## The period on which to flush & clear the aggregator.
+  period = "30s"
+  ## If true, the original metric will be dropped by the
+  ## aggregator and will not get sent to the output plugins.
+  drop_original = false
+
+  ## Fields of which the quantiles are computed, globs can be used. By
+  ## default the quantiles of all the numeric fields are computed.
+  # fields = ["*"]
+
+  ## Quantiles to compute, between 0 and 1.
+  # quantiles = [0.5, 0.95, 0.99]
+
+  ## Algorithm used to estimate the quantiles:
+  ##   t-digest: streaming sketch using a bounded amount of memory
+  ##   exact:    keeps all the values of the period, for small periods
+  # algorithm = "t-digest"
+
+  ## Compression of the t-digest, higher values are more accurate but use
+  ## more memory.
+  # compression = 100.0
+`
+
+type Quantile struct {
+	Fields      []string
+	Quantiles   []float64
+	Algorithm   string
+	Compression float64
+
+	fieldFilter filter.Filter
+	suffixes    []string
+
+	cache map[uint64]aggregate
+}
+
+type aggregate struct {
+	name   string
+	tags   map[string]string
+	fields map[string]estimator
+}
+
+// estimator estimates the quantiles of the values added to it.
+type estimator interface {
+	Add(v float64)
+	Quantile(q float64) float64
+}
+
+// exact keeps all the values to compute the quantiles.
+type exact struct {
+	values []float64
+	sorted bool
+}
+
+func NewQuantile() telegraf.Aggregator {
+	q := &Quantile{
+		Fields:      []string{"*"},
+		Quantiles:   []float64{0.5, 0.95, 0.99},
+		Algorithm:   "t-digest",
+		Compression: 100,
+	}
+	q.Reset()
+	return q
+}
+
+func (q *Quantile) SampleConfig() string {
+	return sampleConfig
+}
+
+func (q *Quantile) Description() string {
+	return "Keep the aggregate quantiles of each metric passing through."
+}
+
+// Init checks the settings and compiles the fields filter, so that an
+// invalid configuration fails to load.
+func (q *Quantile) Init() error {
+	switch q.Algorithm {
+	case "t-digest", "exact":
+	default:
+		return fmt.Errorf("unknown algorithm %q", q.Algorithm)
+	}
+	if q.Algorithm == "t-digest" && q.Compression < 1 {
+		return fmt.Errorf("compression must be at least 1, got %v", q.Compression)
+	}
+	if len(q.Quantiles) == 0 {
+		return fmt.Errorf("no quantiles to compute")
+	}
+
+	q.suffixes = make([]string, len(q.Quantiles))
+	seen := make(map[string]bool)
+	for i, quantile := range q.Quantiles {
+		if quantile < 0 || quantile > 1 {
+			return fmt.Errorf("quantile %v is not between 0 and 1", quantile)
+		}
+		q.suffixes[i] = suffix(quantile)
+		if seen[q.suffixes[i]] {
+			return fmt.Errorf("quantile %v is given more than once", quantile)
+		}
+		seen[q.suffixes[i]] = true
+	}
+
+	var err error
+	q.fieldFilter, err = filter.Compile(q.Fields)
+	if err != nil {
+		return fmt.Errorf("invalid fields: %s", err)
+	}
+	if q.fieldFilter == nil {
+		return fmt.Errorf("no fields to compute the quantiles of")
+	}
+	return nil
+}
+
+func (q *Quantile) Add(in telegraf.Metric) {
+	id := in.HashID()
+	a, ok := q.cache[id]
+	if !ok {
+		a = aggregate{
+			name:   in.Name(),
+			tags:   in.Tags(),
+			fields: make(map[string]estimator),
+		}
+		q.cache[id] = a
+	}
+
+	for k, v := range in.Fields() {
+		if !q.fieldFilter.Match(k) {
+			continue
+		}
+		fv, ok := convert(v)
+		if !ok || math.IsNaN(fv) {
+			continue
+		}
+
+		e, ok := a.fields[k]
+		if !ok {
+			e = q.newEstimator()
+			a.fields[k] = e
+		}
+		e.Add(fv)
+	}
+}
+
+func (q *Quantile) Push(acc telegraf.Accumulator) {
+	for _, a := range q.cache {
+		fields := make(map[string]interface{})
+		for k, e := range a.fields {
+			for i, quantile := range q.Quantiles {
+				fields[k+q.suffixes[i]] = e.Quantile(quantile)
+			}
+		}
+		if len(fields) > 0 {
+			acc.AddFields(a.name, fields, a.tags)
+		}
+	}
+}
+
+func (q *Quantile) Reset() {
+	q.cache = make(map[uint64]aggregate)
+}
+
+func (q *Quantile) newEstimator() estimator {
+	if q.Algorithm == "exact" {
+		return &exact{}
+	}
+	return newTDigest(q.Compression)
+}
+
+// suffix returns the suffix of the fields of a quantile, in percents: _p50 for
+// 0.5 and _p99_9 for 0.999.
+func suffix(quantile float64) string {
+	p := strconv.FormatFloat(quantile*100, 'g', 10, 64)
+	return "_p" + strings.Replace(p, ".", "_", 1)
+}
+
+func (e *exact) Add(v float64) {
+	e.values = append(e.values, v)
+	e.sorted = false
+}
+
+// Quantile returns the quantile of the values, linearly interpolated between
+// the closest ranks.
+func (e *exact) Quantile(q float64) float64 {
+	if len(e.values) == 0 {
+		return math.NaN()
+	}
+	if !e.sorted {
+		sort.Float64s(e.values)
+		e.sorted = true
+	}
+
+	rank := q * float64(len(e.values)-1)
+	i := int(rank)
+	if i >= len(e.values)-1 {
+		return e.values[len(e.values)-1]
+	}
+	return e.values[i] + (rank-float64(i))*(e.values[i+1]-e.values[i])
+}
+
+func convert(in interface{}) (float64, bool) {
+	switch v := in.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
+func init() {
+	aggregators.Add("quantile", func() telegraf.Aggregator {
+		return NewQuantile()
+	})
+}
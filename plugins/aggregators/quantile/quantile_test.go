@@ -0,0 +1,113 @@
+package quantile
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func addValues(q *Quantile, host string, values ...float64) {
+	for _, v := range values {
+		m, _ := metric.New("http_response",
+			map[string]string{"server": host},
+			map[string]interface{}{"response_time": v, "result": "success"},
+			time.Now(),
+		)
+		q.Add(m)
+	}
+}
+
+func TestQuantileExact(t *testing.T) {
+	acc := testutil.Accumulator{}
+	q := NewQuantile().(*Quantile)
+	q.Algorithm = "exact"
+	q.Quantiles = []float64{0, 0.25, 0.5, 0.999, 1}
+	require.NoError(t, q.Init())
+
+	addValues(q, "a", 4, 1, 3, 2, 5)
+	addValues(q, "b", 42)
+	q.Push(&acc)
+
+	require.Len(t, acc.Metrics, 2)
+	acc.AssertContainsTaggedFields(t, "http_response",
+		map[string]interface{}{
+			"response_time_p0":    1.0,
+			"response_time_p25":   2.0,
+			"response_time_p50":   3.0,
+			"response_time_p99_9": 4.996,
+			"response_time_p100":  5.0,
+		},
+		map[string]string{"server": "a"})
+	acc.AssertContainsTaggedFields(t, "http_response",
+		map[string]interface{}{
+			"response_time_p0":    42.0,
+			"response_time_p25":   42.0,
+			"response_time_p50":   42.0,
+			"response_time_p99_9": 42.0,
+			"response_time_p100":  42.0,
+		},
+		map[string]string{"server": "b"})
+
+	acc.ClearMetrics()
+	q.Reset()
+	q.Push(&acc)
+	assert.Len(t, acc.Metrics, 0)
+}
+
+func TestQuantileTDigest(t *testing.T) {
+	acc := testutil.Accumulator{}
+	q := NewQuantile().(*Quantile)
+	require.NoError(t, q.Init())
+
+	r := rand.New(rand.NewSource(42))
+	for _, i := range r.Perm(10001) {
+		addValues(q, "a", float64(i))
+	}
+	q.Push(&acc)
+
+	require.Len(t, acc.Metrics, 1)
+	fields := acc.Metrics[0].Fields
+	assert.Len(t, fields, 3)
+	assert.InDelta(t, 5000, fields["response_time_p50"], 50)
+	assert.InDelta(t, 9500, fields["response_time_p95"], 50)
+	assert.InDelta(t, 9900, fields["response_time_p99"], 50)
+}
+
+func TestQuantileFields(t *testing.T) {
+	acc := testutil.Accumulator{}
+	q := NewQuantile().(*Quantile)
+	q.Fields = []string{"rtt"}
+	q.Quantiles = []float64{0.5}
+	require.NoError(t, q.Init())
+
+	addValues(q, "a", 1, 2, 3)
+	m, _ := metric.New("ping",
+		map[string]string{},
+		map[string]interface{}{"rtt": int64(10), "packets": int64(5)},
+		time.Now(),
+	)
+	q.Add(m)
+	q.Push(&acc)
+
+	require.Len(t, acc.Metrics, 1)
+	acc.AssertContainsFields(t, "ping", map[string]interface{}{"rtt_p50": 10.0})
+}
+
+func TestQuantileInvalidConfig(t *testing.T) {
+	for _, q := range []*Quantile{
+		{Fields: []string{"*"}, Quantiles: []float64{0.5}, Algorithm: "median"},
+		{Fields: []string{"*"}, Quantiles: []float64{0.5}, Algorithm: "t-digest"},
+		{Fields: []string{"*"}, Algorithm: "exact"},
+		{Fields: []string{"*"}, Quantiles: []float64{1.5}, Algorithm: "exact"},
+		{Fields: []string{"*"}, Quantiles: []float64{0.5, 0.5}, Algorithm: "exact"},
+		{Fields: []string{"[a"}, Quantiles: []float64{0.5}, Algorithm: "exact"},
+		{Quantiles: []float64{0.5}, Algorithm: "exact"},
+	} {
+		assert.Error(t, q.Init())
+	}
+}
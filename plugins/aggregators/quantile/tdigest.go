@@ -0,0 +1,135 @@
+package quantile
+
+import (
+	"math"
+	"sort"
+)
+
+// bufferFactor is the number of values buffered before they are merged into
+// the centroids, as a multiple of the compression.
+const bufferFactor = 5
+
+// tdigest is a merging t-digest, as described in "Computing Extremely
+// Accurate Quantiles Using t-Digests" by T. Dunning and O. Ertl. The values
+// are summarized by centroids, which are kept small near the tails so that the
+// extreme quantiles stay accurate. The number of centroids is bounded by the
+// compression.
+type tdigest struct {
+	compression float64
+	centroids   []centroid
+	buffer      []centroid
+	count       float64
+	min         float64
+	max         float64
+}
+
+type centroid struct {
+	mean   float64
+	weight float64
+}
+
+func newTDigest(compression float64) *tdigest {
+	return &tdigest{
+		compression: compression,
+		min:         math.Inf(1),
+		max:         math.Inf(-1),
+	}
+}
+
+func (t *tdigest) Add(v float64) {
+	t.buffer = append(t.buffer, centroid{mean: v, weight: 1})
+	t.min = math.Min(t.min, v)
+	t.max = math.Max(t.max, v)
+	if len(t.buffer) >= bufferFactor*int(t.compression) {
+		t.merge()
+	}
+}
+
+// merge merges the buffered values into the centroids. Neighbouring centroids
+// are merged as long as the merged centroid spans at most one unit of the
+// scale function.
+func (t *tdigest) merge() {
+	if len(t.buffer) == 0 {
+		return
+	}
+
+	all := make([]centroid, 0, len(t.centroids)+len(t.buffer))
+	all = append(all, t.centroids...)
+	all = append(all, t.buffer...)
+	sort.Slice(all, func(i, j int) bool { return all[i].mean < all[j].mean })
+	total := t.count + float64(len(t.buffer))
+
+	merged := make([]centroid, 0, len(t.centroids)+1)
+	cur := all[0]
+	// weight of the centroids before cur, and the largest weight cur can
+	// reach.
+	before := 0.0
+	limit := t.quantile(t.scale(0)+1) * total
+	for _, c := range all[1:] {
+		if before+cur.weight+c.weight <= limit {
+			cur.weight += c.weight
+			cur.mean += (c.mean - cur.mean) * c.weight / cur.weight
+			continue
+		}
+		merged = append(merged, cur)
+		before += cur.weight
+		limit = t.quantile(t.scale(before/total)+1) * total
+		cur = c
+	}
+	merged = append(merged, cur)
+
+	t.centroids = merged
+	t.count = total
+	t.buffer = t.buffer[:0]
+}
+
+// scale is the k1 scale function of the t-digest, it maps the quantile q to
+// the index of the centroid it falls in.
+func (t *tdigest) scale(q float64) float64 {
+	return t.compression / (2 * math.Pi) * math.Asin(2*q-1)
+}
+
+// quantile is the inverse of scale.
+func (t *tdigest) quantile(k float64) float64 {
+	if k >= t.compression/4 {
+		return 1
+	}
+	return (math.Sin(k*2*math.Pi/t.compression) + 1) / 2
+}
+
+// Quantile returns the estimated quantile q of the values. The values of a
+// centroid are taken to be spread around its mean, so the quantiles between
+// the means of two centroids are interpolated linearly.
+func (t *tdigest) Quantile(q float64) float64 {
+	t.merge()
+	if len(t.centroids) == 0 {
+		return math.NaN()
+	}
+	if q <= 0 {
+		return t.min
+	}
+	if q >= 1 {
+		return t.max
+	}
+
+	index := q * t.count
+	first := t.centroids[0]
+	if index < first.weight/2 {
+		return t.min + index/(first.weight/2)*(first.mean-t.min)
+	}
+
+	before := 0.0
+	for i := 0; i < len(t.centroids)-1; i++ {
+		c, next := t.centroids[i], t.centroids[i+1]
+		mid := before + c.weight/2
+		nextMid := before + c.weight + next.weight/2
+		if index < nextMid {
+			return c.mean + (index-mid)/(nextMid-mid)*(next.mean-c.mean)
+		}
+		before += c.weight
+	}
+
+	last := t.centroids[len(t.centroids)-1]
+	mid := t.count - last.weight/2
+	return last.mean + (index-mid)/(last.weight/2)*(t.max-last.mean)
+}
@@ -0,0 +1,47 @@
+package quantile
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTDigestAccuracy(t *testing.T) {
+	td := newTDigest(100)
+	r := rand.New(rand.NewSource(42))
+	values := make([]float64, 100000)
+	for i := range values {
+		values[i] = r.NormFloat64()
+		td.Add(values[i])
+	}
+	sort.Float64s(values)
+
+	for _, q := range []float64{0.001, 0.01, 0.25, 0.5, 0.75, 0.99, 0.999} {
+		// the error is bounded in ranks, it is smaller near the tails.
+		rank := sort.SearchFloat64s(values, td.Quantile(q))
+		assert.InDelta(t, q, float64(rank)/float64(len(values)), 0.005, "q=%v", q)
+	}
+	assert.Equal(t, values[0], td.Quantile(0))
+	assert.Equal(t, values[len(values)-1], td.Quantile(1))
+	assert.True(t, len(td.centroids) <= 100, "%d centroids", len(td.centroids))
+}
+
+func TestTDigestFewValues(t *testing.T) {
+	td := newTDigest(100)
+	assert.True(t, math.IsNaN(td.Quantile(0.5)))
+
+	td.Add(42)
+	assert.Equal(t, 42.0, td.Quantile(0))
+	assert.Equal(t, 42.0, td.Quantile(0.5))
+	assert.Equal(t, 42.0, td.Quantile(1))
+
+	for _, v := range []float64{3, 1, 2} {
+		td.Add(v)
+	}
+	assert.Equal(t, 1.0, td.Quantile(0))
+	assert.Equal(t, 2.5, td.Quantile(0.5))
+	assert.Equal(t, 42.0, td.Quantile(1))
+}
@@ -0,0 +1,120 @@
+package valuecounter
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/aggregators"
+)
+
+var sampleConfig = `
+  ## The period on which to flush & clear the aggregator.
+  period = "30s"
+  ## If true, the original metric will be dropped by the
+  ## aggregator and will not get sent to the output plugins.
+  drop_original = false
+
+  ## The fields of which the values are counted.
+  fields = ["status"]
+
+  ## Maximum number of distinct values counted per field of each series over
+  ## a period, the values seen once it is reached are not counted.
+  # max_distinct_values = 100
+`
+
+type ValueCounter struct {
+	Fields            []string
+	MaxDistinctValues int `toml:"max_distinct_values"`
+
+	cache map[uint64]aggregate
+}
+
+type aggregate struct {
+	name string
+	tags map[string]string
+	// counts holds the number of occurrences of each value of the fields.
+	counts map[string]map[string]int64
+	// capped holds the fields for which values were not counted.
+	capped map[string]bool
+}
+
+func NewValueCounter() telegraf.Aggregator {
+	vc := &ValueCounter{
+		MaxDistinctValues: 100,
+	}
+	vc.Reset()
+	return vc
+}
+
+func (vc *ValueCounter) SampleConfig() string {
+	return sampleConfig
+}
+
+func (vc *ValueCounter) Description() string {
+	return "Count the occurrences of the values of fields within each period."
+}
+
+func (vc *ValueCounter) Add(in telegraf.Metric) {
+	id := in.HashID()
+	a, ok := vc.cache[id]
+	if !ok {
+		a = aggregate{
+			name:   in.Name(),
+			tags:   in.Tags(),
+			counts: make(map[string]map[string]int64),
+			capped: make(map[string]bool),
+		}
+		vc.cache[id] = a
+	}
+
+	fields := in.Fields()
+	for _, field := range vc.Fields {
+		v, ok := fields[field]
+		if !ok {
+			continue
+		}
+		value := fmt.Sprint(v)
+
+		counts, ok := a.counts[field]
+		if !ok {
+			counts = make(map[string]int64)
+			a.counts[field] = counts
+		}
+		if _, ok := counts[value]; !ok && vc.MaxDistinctValues > 0 &&
+			len(counts) >= vc.MaxDistinctValues {
+			if !a.capped[field] {
+				a.capped[field] = true
+				log.Printf("W! [aggregators.valuecounter] Field %s of %s has more "+
+					"than %d distinct values, the other values are not counted",
+					field, in.Name(), vc.MaxDistinctValues)
+			}
+			continue
+		}
+		counts[value]++
+	}
+}
+
+func (vc *ValueCounter) Push(acc telegraf.Accumulator) {
+	for _, a := range vc.cache {
+		fields := make(map[string]interface{})
+		for field, counts := range a.counts {
+			for value, count := range counts {
+				fields[field+"_"+value] = count
+			}
+		}
+		if len(fields) > 0 {
+			acc.AddFields(a.name, fields, a.tags)
+		}
+	}
+}
+
+func (vc *ValueCounter) Reset() {
+	vc.cache = make(map[uint64]aggregate)
+}
+
+func init() {
+	aggregators.Add("valuecounter", func() telegraf.Aggregator {
+		return NewValueCounter()
+	})
+}
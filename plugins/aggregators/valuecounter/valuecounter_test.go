@@ -0,0 +1,72 @@
+package valuecounter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func addStatus(vc *ValueCounter, status ...interface{}) {
+	for _, s := range status {
+		m, _ := metric.New("http_response",
+			map[string]string{"server": "localhost"},
+			map[string]interface{}{"status": s, "response_time": 0.1},
+			time.Now(),
+		)
+		vc.Add(m)
+	}
+}
+
+func TestValueCounter(t *testing.T) {
+	acc := testutil.Accumulator{}
+	vc := NewValueCounter().(*ValueCounter)
+	vc.Fields = []string{"status", "missing"}
+
+	addStatus(vc, int64(200), int64(200), int64(404), "OK", true)
+	vc.Push(&acc)
+
+	require.Len(t, acc.Metrics, 1)
+	assert.Equal(t,
+		map[string]interface{}{
+			"status_200":  int64(2),
+			"status_404":  int64(1),
+			"status_OK":   int64(1),
+			"status_true": int64(1),
+		},
+		acc.Metrics[0].Fields)
+	assert.Equal(t, map[string]string{"server": "localhost"}, acc.Metrics[0].Tags)
+
+	acc.ClearMetrics()
+	vc.Reset()
+	vc.Push(&acc)
+	assert.Len(t, acc.Metrics, 0)
+}
+
+func TestValueCounterMaxDistinctValues(t *testing.T) {
+	acc := testutil.Accumulator{}
+	vc := NewValueCounter().(*ValueCounter)
+	vc.Fields = []string{"status"}
+	vc.MaxDistinctValues = 2
+
+	addStatus(vc, int64(200), int64(404), int64(500), int64(200), int64(503))
+	vc.Push(&acc)
+
+	require.Len(t, acc.Metrics, 1)
+	assert.Equal(t,
+		map[string]interface{}{"status_200": int64(2), "status_404": int64(1)},
+		acc.Metrics[0].Fields)
+}
+
+func TestValueCounterNoFields(t *testing.T) {
+	acc := testutil.Accumulator{}
+	vc := NewValueCounter().(*ValueCounter)
+
+	addStatus(vc, int64(200))
+	vc.Push(&acc)
+
+	assert.Len(t, acc.Metrics, 0)
+}
@@ -29,6 +29,10 @@ type AMQPConsumer struct {
 	// for consumers before receiving delivery acks.
 	PrefetchCount int
 
+	// Maximum number of messages that are read but not yet written to the
+	// outputs
+	MaxUndeliveredMessages int `toml:"max_undelivered_messages"`
+
 	// AMQP Auth method
 	AuthMethod string
 	// Path to CA file
@@ -57,6 +61,8 @@ func (a *externalAuth) Response() string {
 const (
 	DefaultAuthMethod    = "PLAIN"
 	DefaultPrefetchCount = 50
+
+	DefaultMaxUndeliveredMessages = 1000
 )
 
 func (a *AMQPConsumer) SampleConfig() string {
@@ -73,6 +79,11 @@ func (a *AMQPConsumer) SampleConfig() string {
   ## Maximum number of messages server should give to the worker.
   prefetch_count = 50
 
+  ## Maximum number of messages that are read but not yet written to the
+  ## outputs. Messages are only acknowledged once all of their metrics have
+  ## been written and are rejected if an output discards them.
+  # max_undelivered_messages = 1000
+
   ## Auth method. PLAIN and EXTERNAL are supported
   ## Using EXTERNAL requires enabling the rabbitmq_auth_mechanism_ssl plugin as
   ## described here: https://www.rabbitmq.com/plugins.html
@@ -242,22 +253,61 @@ func (a *AMQPConsumer) connect(amqpConf *amqp.Config) (<-chan amqp.Delivery, err
 	return msgs, err
 }
 
-// Read messages from queue and add them to the Accumulator
-func (a *AMQPConsumer) process(msgs <-chan amqp.Delivery, acc telegraf.Accumulator) {
+// Read messages from queue and add them to the Accumulator, messages are
+// acknowledged once their metrics have been delivered to the outputs.
+func (a *AMQPConsumer) process(msgs <-chan amqp.Delivery, ac telegraf.Accumulator) {
 	defer a.wg.Done()
-	for d := range msgs {
-		metrics, err := a.parser.Parse(d.Body)
-		if err != nil {
-			log.Printf("E! %v: error parsing metric - %v", err, string(d.Body))
-		} else {
-			for _, m := range metrics {
-				acc.AddFields(m.Name(), m.Fields(), m.Tags(), m.Time())
+
+	maxUndelivered := a.MaxUndeliveredMessages
+	if maxUndelivered <= 0 {
+		maxUndelivered = DefaultMaxUndeliveredMessages
+	}
+	acc := ac.WithTracking(maxUndelivered)
+	undelivered := make(map[telegraf.TrackingID]amqp.Delivery)
+
+	for {
+		in := msgs
+		if len(undelivered) >= maxUndelivered {
+			// stop reading until some of the messages have been delivered
+			in = nil
+		}
+
+		select {
+		case info := <-acc.Delivered():
+			d, ok := undelivered[info.ID()]
+			if !ok {
+				continue
+			}
+			delete(undelivered, info.ID())
+			a.onDelivery(d, info.Delivered())
+		case d, ok := <-in:
+			if !ok {
+				log.Printf("I! AMQP consumer queue closed")
+				return
 			}
+
+			metrics, err := a.parser.Parse(d.Body)
+			if err != nil {
+				log.Printf("E! %v: error parsing metric - %v", err, string(d.Body))
+				a.onDelivery(d, true)
+				continue
+			}
+			id := acc.AddTrackingMetricGroup(metrics)
+			undelivered[id] = d
 		}
+	}
+}
 
-		d.Ack(false)
+func (a *AMQPConsumer) onDelivery(d amqp.Delivery, delivered bool) {
+	var err error
+	if delivered {
+		err = d.Ack(false)
+	} else {
+		err = d.Reject(false)
+	}
+	if err != nil {
+		log.Printf("E! Unable to acknowledge AMQP message: %s", err)
 	}
-	log.Printf("I! AMQP consumer queue closed")
 }
 
 func (a *AMQPConsumer) Stop() {
@@ -275,6 +325,8 @@ func init() {
 		return &AMQPConsumer{
 			AuthMethod:    DefaultAuthMethod,
 			PrefetchCount: DefaultPrefetchCount,
+
+			MaxUndeliveredMessages: DefaultMaxUndeliveredMessages,
 		}
 	})
 }
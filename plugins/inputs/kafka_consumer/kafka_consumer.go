@@ -42,6 +42,10 @@ type Kafka struct {
 	// TODO remove PointBuffer, legacy support
 	PointBuffer int
 
+	// Maximum number of messages that are read but not yet written to
+	// the outputs
+	MaxUndeliveredMessages int `toml:"max_undelivered_messages"`
+
 	Offset string
 	parser parsers.Parser
 
@@ -92,8 +96,16 @@ var sampleConfig = `
   ## Maximum length of a message to consume, in bytes (default 0/unlimited);
   ## larger messages are dropped
   max_message_len = 65536
+
+  ## Maximum number of messages that are read but not yet written to the
+  ## outputs. The offset of a message is only committed once all of its
+  ## metrics have been written, when the limit is reached no more messages
+  ## are read until some have been delivered.
+  # max_undelivered_messages = 1000
 `
 
+const defaultMaxUndeliveredMessages = 1000
+
 func (k *Kafka) SampleConfig() string {
 	return sampleConfig
 }
@@ -176,40 +188,69 @@ func (k *Kafka) Start(acc telegraf.Accumulator) error {
 // receiver() reads all incoming messages from the consumer, and parses them into
 // influxdb metric points.
 func (k *Kafka) receiver() {
+	maxUndelivered := k.MaxUndeliveredMessages
+	if maxUndelivered <= 0 {
+		maxUndelivered = defaultMaxUndeliveredMessages
+	}
+	acc := k.acc.WithTracking(maxUndelivered)
+	undelivered := make(map[telegraf.TrackingID]*pendingMessage)
+	offsets := newOffsetTracker()
+
 	for {
+		in := k.in
+		if offsets.Len() >= maxUndelivered {
+			// stop reading until some of the messages have been delivered
+			in = nil
+		}
+
 		select {
 		case <-k.done:
 			return
 		case err := <-k.errs:
 			if err != nil {
-				k.acc.AddError(fmt.Errorf("Consumer Error: %s\n", err))
+				acc.AddError(fmt.Errorf("Consumer Error: %s\n", err))
 			}
-		case msg := <-k.in:
+		case info := <-acc.Delivered():
+			pm, ok := undelivered[info.ID()]
+			if !ok {
+				continue
+			}
+			delete(undelivered, info.ID())
+			if msg := offsets.Done(pm, info.Delivered()); msg != nil {
+				k.markOffset(msg)
+			}
+		case msg := <-in:
+			pm := offsets.Add(msg)
 			if k.MaxMessageLen != 0 && len(msg.Value) > k.MaxMessageLen {
-				k.acc.AddError(fmt.Errorf("Message longer than max_message_len (%d > %d)",
+				acc.AddError(fmt.Errorf("Message longer than max_message_len (%d > %d)",
 					len(msg.Value), k.MaxMessageLen))
-			} else {
-				metrics, err := k.parser.Parse(msg.Value)
-				if err != nil {
-					k.acc.AddError(fmt.Errorf("Message Parse Error\nmessage: %s\nerror: %s",
-						string(msg.Value), err.Error()))
-				}
-				for _, metric := range metrics {
-					k.acc.AddFields(metric.Name(), metric.Fields(), metric.Tags(), metric.Time())
+				if msg := offsets.Done(pm, true); msg != nil {
+					k.markOffset(msg)
 				}
+				continue
 			}
 
-			if !k.doNotCommitMsgs {
-				// TODO(cam) this locking can be removed if this PR gets merged:
-				// https://github.com/wvanbergen/kafka/pull/84
-				k.Lock()
-				k.Cluster.MarkOffset(msg, "")
-				k.Unlock()
+			metrics, err := k.parser.Parse(msg.Value)
+			if err != nil {
+				acc.AddError(fmt.Errorf("Message Parse Error\nmessage: %s\nerror: %s",
+					string(msg.Value), err.Error()))
 			}
+			id := acc.AddTrackingMetricGroup(metrics)
+			undelivered[id] = pm
 		}
 	}
 }
 
+func (k *Kafka) markOffset(msg *sarama.ConsumerMessage) {
+	if !k.doNotCommitMsgs {
+		// TODO(cam) this locking can be removed if this PR gets merged:
+		// https://github.com/wvanbergen/kafka/pull/84
+		k.Lock()
+		k.Cluster.MarkOffset(msg, "")
+		k.Unlock()
+	}
+}
+
 func (k *Kafka) Stop() {
 	k.Lock()
 	defer k.Unlock()
@@ -148,3 +148,31 @@ func saramaMsg(val string) *sarama.ConsumerMessage {
 		Partition: 0,
 	}
 }
+
+// Test that the offsets are committed only up to the delivered messages
+func TestOffsetTracker(t *testing.T) {
+	tracker := newOffsetTracker()
+	msg := func(partition int32, offset int64) *sarama.ConsumerMessage {
+		return &sarama.ConsumerMessage{Topic: "telegraf", Partition: partition, Offset: offset}
+	}
+
+	p0 := []*pendingMessage{tracker.Add(msg(0, 0)), tracker.Add(msg(0, 1)), tracker.Add(msg(0, 2))}
+	p1 := tracker.Add(msg(1, 0))
+	assert.Equal(t, 4, tracker.Len())
+
+	// a later message is delivered first
+	assert.Nil(t, tracker.Done(p0[1], true))
+	assert.Equal(t, int64(1), tracker.Done(p0[0], true).Offset)
+	assert.Equal(t, int64(0), tracker.Done(p1, true).Offset)
+	assert.Equal(t, 1, tracker.Len())
+
+	// nothing is committed past a rejected message
+	assert.Nil(t, tracker.Done(p0[2], false))
+	p0 = append(p0, tracker.Add(msg(0, 3)))
+	assert.Nil(t, tracker.Done(p0[3], true))
+	assert.Equal(t, 0, tracker.Len())
+
+	// other partitions are not blocked
+	p1 = tracker.Add(msg(1, 1))
+	assert.Equal(t, int64(1), tracker.Done(p1, true).Offset)
+}
@@ -0,0 +1,103 @@
+package kafka_consumer
+
+import (
+	"log"
+
+	"github.com/Shopify/sarama"
+)
+
+// The offset committed for a partition means that every message before it
+// has been handled, so the offset of a message can only be committed once
+// all the messages read before it from the same partition are delivered.
+
+type topicPartition struct {
+	topic     string
+	partition int32
+}
+
+type pendingMessage struct {
+	msg  *sarama.ConsumerMessage
+	done bool
+}
+
+type partitionQueue struct {
+	// pending are the messages that are not committed, in the order they
+	// were read.
+	pending []*pendingMessage
+	// blocked is set when a message was not delivered, the offsets of the
+	// partition are not committed anymore so that the message is read
+	// again when the consumer restarts.
+	blocked bool
+}
+
+// offsetTracker keeps the messages read from each partition until their
+// offset can be committed.
+type offsetTracker struct {
+	partitions map[topicPartition]*partitionQueue
+	// n is the number of messages that are not done yet or that are done
+	// but wait for an earlier message of their partition.
+	n int
+}
+
+func newOffsetTracker() *offsetTracker {
+	return &offsetTracker{partitions: make(map[topicPartition]*partitionQueue)}
+}
+
+// Len returns the number of messages read whose offset is not committed,
+// excluding the delivered messages of blocked partitions.
+func (t *offsetTracker) Len() int {
+	return t.n
+}
+
+// Add adds a message read from the consumer.
+func (t *offsetTracker) Add(msg *sarama.ConsumerMessage) *pendingMessage {
+	key := topicPartition{topic: msg.Topic, partition: msg.Partition}
+	q, ok := t.partitions[key]
+	if !ok {
+		q = &partitionQueue{}
+		t.partitions[key] = q
+	}
+
+	pm := &pendingMessage{msg: msg}
+	if !q.blocked {
+		q.pending = append(q.pending, pm)
+	}
+	t.n++
+	return pm
+}
+
+// Done marks a message as handled, delivered is false if its metrics were
+// rejected by an output. It returns the message whose offset can be
+// committed, if any.
+func (t *offsetTracker) Done(pm *pendingMessage, delivered bool) *sarama.ConsumerMessage {
+	q := t.partitions[topicPartition{topic: pm.msg.Topic, partition: pm.msg.Partition}]
+	pm.done = true
+
+	if q.blocked {
+		t.n--
+		return nil
+	}
+
+	if !delivered {
+		log.Printf("W! Kafka consumer: message at offset %d of %s/%d was not delivered, "+
+			"offsets of the partition are not committed until restart\n",
+			pm.msg.Offset, pm.msg.Topic, pm.msg.Partition)
+		q.blocked = true
+		for _, p := range q.pending {
+			if p.done {
+				t.n--
+			}
+		}
+		q.pending = nil
+		return nil
+	}
+
+	var last *sarama.ConsumerMessage
+	for len(q.pending) > 0 && q.pending[0].done {
+		last = q.pending[0].msg
+		q.pending[0] = nil
+		q.pending = q.pending[1:]
+		t.n--
+	}
+	return last
+}
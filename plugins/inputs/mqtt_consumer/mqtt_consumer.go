@@ -18,6 +18,8 @@ import (
 // 30 Seconds is the default used by paho.mqtt.golang
 var defaultConnectionTimeout = internal.Duration{Duration: 30 * time.Second}
 
+const defaultMaxUndeliveredMessages = 1000
+
 type MQTTConsumer struct {
 	Servers           []string
 	Topics            []string
@@ -26,6 +28,10 @@ type MQTTConsumer struct {
 	QoS               int               `toml:"qos"`
 	ConnectionTimeout internal.Duration `toml:"connection_timeout"`
 
+	// Maximum number of messages that are read but not yet written to
+	// the outputs
+	MaxUndeliveredMessages int `toml:"max_undelivered_messages"`
+
 	parser parsers.Parser
 
 	// Legacy metric buffer support
@@ -62,6 +68,11 @@ var sampleConfig = `
   ## Connection timeout for initial connection in seconds
   connection_timeout = "30s"
 
+  ## Maximum number of messages that are read but not yet written to the
+  ## outputs, when the limit is reached no more messages are read until some
+  ## have been delivered. Messages are acknowledged to the broker on receipt.
+  # max_undelivered_messages = 1000
+
   ## Topics to subscribe to
   topics = [
     "telegraf/host01/cpu",
@@ -178,23 +189,40 @@ func (m *MQTTConsumer) onConnectionLost(c mqtt.Client, err error) {
 // receiver() reads all incoming messages from the consumer, and parses them into
 // influxdb metric points.
 func (m *MQTTConsumer) receiver() {
+	maxUndelivered := m.MaxUndeliveredMessages
+	if maxUndelivered <= 0 {
+		maxUndelivered = defaultMaxUndeliveredMessages
+	}
+	acc := m.acc.WithTracking(maxUndelivered)
+	undelivered := 0
+
 	for {
+		in := m.in
+		if undelivered >= maxUndelivered {
+			// stop reading until some of the messages have been delivered
+			in = nil
+		}
+
 		select {
 		case <-m.done:
 			return
-		case msg := <-m.in:
+		case <-acc.Delivered():
+			// The messages have been acknowledged by the client library on
+			// receipt, the tracking only limits the messages in flight.
+			undelivered--
+		case msg := <-in:
 			topic := msg.Topic()
 			metrics, err := m.parser.Parse(msg.Payload())
 			if err != nil {
-				m.acc.AddError(fmt.Errorf("E! MQTT Parse Error\nmessage: %s\nerror: %s",
+				acc.AddError(fmt.Errorf("E! MQTT Parse Error\nmessage: %s\nerror: %s",
 					string(msg.Payload()), err.Error()))
 			}
 
 			for _, metric := range metrics {
-				tags := metric.Tags()
-				tags["topic"] = topic
-				m.acc.AddFields(metric.Name(), metric.Fields(), tags, metric.Time())
+				metric.AddTag("topic", topic)
 			}
+			acc.AddTrackingMetricGroup(metrics)
+			undelivered++
 		}
 	}
 }
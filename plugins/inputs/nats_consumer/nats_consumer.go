@@ -32,6 +32,10 @@ type natsConsumer struct {
 	PendingMessageLimit int
 	PendingBytesLimit   int
 
+	// Maximum number of messages that are read but not yet written to
+	// the outputs
+	MaxUndeliveredMessages int `toml:"max_undelivered_messages"`
+
 	// Legacy metric buffer support
 	MetricBuffer int
 
@@ -50,6 +54,8 @@ type natsConsumer struct {
 	acc  telegraf.Accumulator
 }
 
+const defaultMaxUndeliveredMessages = 1000
+
 var sampleConfig = `
   ## urls of NATS servers
   # servers = ["nats://localhost:4222"]
@@ -65,6 +71,11 @@ var sampleConfig = `
   # pending_message_limit = 65536
   # pending_bytes_limit = 67108864
 
+  ## Maximum number of messages that are read but not yet written to the
+  ## outputs, when the limit is reached no more messages are read until some
+  ## have been delivered.
+  # max_undelivered_messages = 1000
+
   ## Data format to consume.
   ## Each data format has its own unique set of configuration options, read
   ## more about them here:
@@ -157,21 +168,36 @@ func (n *natsConsumer) Start(acc telegraf.Accumulator) error {
 // telegraf metrics.
 func (n *natsConsumer) receiver() {
 	defer n.wg.Done()
+
+	maxUndelivered := n.MaxUndeliveredMessages
+	if maxUndelivered <= 0 {
+		maxUndelivered = defaultMaxUndeliveredMessages
+	}
+	acc := n.acc.WithTracking(maxUndelivered)
+	undelivered := 0
+
 	for {
+		in := n.in
+		if undelivered >= maxUndelivered {
+			// stop reading until some of the messages have been delivered
+			in = nil
+		}
+
 		select {
 		case <-n.done:
 			return
 		case err := <-n.errs:
-			n.acc.AddError(fmt.Errorf("E! error reading from %s\n", err.Error()))
-		case msg := <-n.in:
+			acc.AddError(fmt.Errorf("E! error reading from %s\n", err.Error()))
+		case <-acc.Delivered():
+			undelivered--
+		case msg := <-in:
 			metrics, err := n.parser.Parse(msg.Data)
 			if err != nil {
-				n.acc.AddError(fmt.Errorf("E! subject: %s, error: %s", msg.Subject, err.Error()))
+				acc.AddError(fmt.Errorf("E! subject: %s, error: %s", msg.Subject, err.Error()))
 			}
 
-			for _, metric := range metrics {
-				n.acc.AddFields(metric.Name(), metric.Fields(), metric.Tags(), metric.Time())
-			}
+			acc.AddTrackingMetricGroup(metrics)
+			undelivered++
 		}
 	}
 }
@@ -110,6 +110,7 @@ func TestLineProtocolConverter_Record(t *testing.T) {
 			want: []testutil.Metric{
 				testutil.Metric{
 					Measurement: "zipkin",
+					Type:        telegraf.Untyped,
 					Tags: map[string]string{
 						"id":           "8090652509916334619",
 						"parent_id":    "22964302721410078",
@@ -124,6 +125,7 @@ func TestLineProtocolConverter_Record(t *testing.T) {
 				},
 				testutil.Metric{
 					Measurement: "zipkin",
+					Type:        telegraf.Untyped,
 					Tags: map[string]string{
 						"id":             "8090652509916334619",
 						"parent_id":      "22964302721410078",
@@ -141,6 +143,7 @@ func TestLineProtocolConverter_Record(t *testing.T) {
 				},
 				testutil.Metric{
 					Measurement: "zipkin",
+					Type:        telegraf.Untyped,
 					Tags: map[string]string{
 						"id":           "103618986556047333",
 						"parent_id":    "22964302721410078",
@@ -155,6 +158,7 @@ func TestLineProtocolConverter_Record(t *testing.T) {
 				},
 				testutil.Metric{
 					Measurement: "zipkin",
+					Type:        telegraf.Untyped,
 					Tags: map[string]string{
 						"id":             "103618986556047333",
 						"parent_id":      "22964302721410078",
@@ -172,6 +176,7 @@ func TestLineProtocolConverter_Record(t *testing.T) {
 				},
 				testutil.Metric{
 					Measurement: "zipkin",
+					Type:        telegraf.Untyped,
 					Tags: map[string]string{
 						"id":           "22964302721410078",
 						"parent_id":    "22964302721410078",
@@ -186,6 +191,7 @@ func TestLineProtocolConverter_Record(t *testing.T) {
 				},
 				testutil.Metric{
 					Measurement: "zipkin",
+					Type:        telegraf.Untyped,
 					Tags: map[string]string{
 						"service_name":  "trivial",
 						"annotation":    "Starting child #0",
@@ -202,6 +208,7 @@ func TestLineProtocolConverter_Record(t *testing.T) {
 				},
 				testutil.Metric{
 					Measurement: "zipkin",
+					Type:        telegraf.Untyped,
 					Tags: map[string]string{
 						"service_name":  "trivial",
 						"annotation":    "Starting child #1",
@@ -218,6 +225,7 @@ func TestLineProtocolConverter_Record(t *testing.T) {
 				},
 				testutil.Metric{
 					Measurement: "zipkin",
+					Type:        telegraf.Untyped,
 					Tags: map[string]string{
 						"parent_id":     "22964302721410078",
 						"trace_id":      "2505404965370368069",
@@ -234,6 +242,7 @@ func TestLineProtocolConverter_Record(t *testing.T) {
 				},
 				testutil.Metric{
 					Measurement: "zipkin",
+					Type:        telegraf.Untyped,
 					Tags: map[string]string{
 						"trace_id":       "2505404965370368069",
 						"service_name":   "trivial",
@@ -285,6 +294,7 @@ func TestLineProtocolConverter_Record(t *testing.T) {
 			want: []testutil.Metric{
 				testutil.Metric{
 					Measurement: "zipkin",
+					Type:        telegraf.Untyped,
 					Tags: map[string]string{
 						"id":           "6802735349851856000",
 						"parent_id":    "6802735349851856000",
@@ -299,6 +309,7 @@ func TestLineProtocolConverter_Record(t *testing.T) {
 				},
 				testutil.Metric{
 					Measurement: "zipkin",
+					Type:        telegraf.Untyped,
 					Tags: map[string]string{
 						"annotation":    "cs",
 						"endpoint_host": "0:9410",
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/testutil"
 )
 
@@ -29,6 +30,7 @@ func TestZipkinPlugin(t *testing.T) {
 			want: []testutil.Metric{
 				testutil.Metric{
 					Measurement: "zipkin",
+					Type:        telegraf.Untyped,
 					Tags: map[string]string{
 						"id":           "8090652509916334619",
 						"parent_id":    "22964302721410078",
@@ -43,6 +45,7 @@ func TestZipkinPlugin(t *testing.T) {
 				},
 				testutil.Metric{
 					Measurement: "zipkin",
+					Type:        telegraf.Untyped,
 					Tags: map[string]string{
 						"id":             "8090652509916334619",
 						"parent_id":      "22964302721410078",
@@ -60,6 +63,7 @@ func TestZipkinPlugin(t *testing.T) {
 				},
 				testutil.Metric{
 					Measurement: "zipkin",
+					Type:        telegraf.Untyped,
 					Tags: map[string]string{
 						"id":           "103618986556047333",
 						"parent_id":    "22964302721410078",
@@ -74,6 +78,7 @@ func TestZipkinPlugin(t *testing.T) {
 				},
 				testutil.Metric{
 					Measurement: "zipkin",
+					Type:        telegraf.Untyped,
 					Tags: map[string]string{
 						"id":             "103618986556047333",
 						"parent_id":      "22964302721410078",
@@ -91,6 +96,7 @@ func TestZipkinPlugin(t *testing.T) {
 				},
 				testutil.Metric{
 					Measurement: "zipkin",
+					Type:        telegraf.Untyped,
 					Tags: map[string]string{
 						"id":           "22964302721410078",
 						"parent_id":    "22964302721410078",
@@ -105,6 +111,7 @@ func TestZipkinPlugin(t *testing.T) {
 				},
 				testutil.Metric{
 					Measurement: "zipkin",
+					Type:        telegraf.Untyped,
 					Tags: map[string]string{
 						"service_name":  "trivial",
 						"annotation":    "Starting child #0",
@@ -121,6 +128,7 @@ func TestZipkinPlugin(t *testing.T) {
 				},
 				testutil.Metric{
 					Measurement: "zipkin",
+					Type:        telegraf.Untyped,
 					Tags: map[string]string{
 						"service_name":  "trivial",
 						"annotation":    "Starting child #1",
@@ -137,6 +145,7 @@ func TestZipkinPlugin(t *testing.T) {
 				},
 				testutil.Metric{
 					Measurement: "zipkin",
+					Type:        telegraf.Untyped,
 					Tags: map[string]string{
 						"parent_id":     "22964302721410078",
 						"trace_id":      "22c4fc8ab3669045",
@@ -153,6 +162,7 @@ func TestZipkinPlugin(t *testing.T) {
 				},
 				testutil.Metric{
 					Measurement: "zipkin",
+					Type:        telegraf.Untyped,
 					Tags: map[string]string{
 						"trace_id":       "22c4fc8ab3669045",
 						"service_name":   "trivial",
@@ -178,6 +188,7 @@ func TestZipkinPlugin(t *testing.T) {
 			want: []testutil.Metric{
 				testutil.Metric{
 					Measurement: "zipkin",
+					Type:        telegraf.Untyped,
 					Tags: map[string]string{
 						"id":           "6802735349851856000",
 						"parent_id":    "6802735349851856000",
@@ -192,6 +203,7 @@ func TestZipkinPlugin(t *testing.T) {
 				},
 				testutil.Metric{
 					Measurement: "zipkin",
+					Type:        telegraf.Untyped,
 					Tags: map[string]string{
 						"annotation":    "cs",
 						"endpoint_host": "0.0.0.0:9410",
@@ -208,6 +220,7 @@ func TestZipkinPlugin(t *testing.T) {
 				},
 				testutil.Metric{
 					Measurement: "zipkin",
+					Type:        telegraf.Untyped,
 					Tags: map[string]string{
 						"annotation":    "cr",
 						"endpoint_host": "0.0.0.0:9410",
@@ -231,6 +244,7 @@ func TestZipkinPlugin(t *testing.T) {
 			want: []testutil.Metric{
 				{
 					Measurement: "zipkin",
+					Type:        telegraf.Untyped,
 					Tags: map[string]string{
 						"id":           "12854419928166856317",
 						"name":         "http:/hi2",
@@ -244,6 +258,7 @@ func TestZipkinPlugin(t *testing.T) {
 				},
 				{
 					Measurement: "zipkin",
+					Type:        telegraf.Untyped,
 					Tags: map[string]string{
 						"annotation":    "sr",
 						"endpoint_host": "192.168.0.8:8010",
@@ -260,6 +275,7 @@ func TestZipkinPlugin(t *testing.T) {
 				},
 				{
 					Measurement: "zipkin",
+					Type:        telegraf.Untyped,
 					Tags: map[string]string{
 						"annotation":    "ss",
 						"endpoint_host": "192.168.0.8:8010",
@@ -276,6 +292,7 @@ func TestZipkinPlugin(t *testing.T) {
 				},
 				{
 					Measurement: "zipkin",
+					Type:        telegraf.Untyped,
 					Tags: map[string]string{
 						"annotation":     "Demo2Application",
 						"annotation_key": "mvc.controller.class",
@@ -293,6 +310,7 @@ func TestZipkinPlugin(t *testing.T) {
 				},
 				{
 					Measurement: "zipkin",
+					Type:        telegraf.Untyped,
 					Tags: map[string]string{
 						"annotation":     "hi2",
 						"annotation_key": "mvc.controller.method",
@@ -310,6 +328,7 @@ func TestZipkinPlugin(t *testing.T) {
 				},
 				{
 					Measurement: "zipkin",
+					Type:        telegraf.Untyped,
 					Tags: map[string]string{
 						"annotation":     "192.168.0.8:test:8010",
 						"annotation_key": "spring.instance_id",
@@ -327,6 +346,7 @@ func TestZipkinPlugin(t *testing.T) {
 				},
 				{
 					Measurement: "zipkin",
+					Type:        telegraf.Untyped,
 					Tags: map[string]string{
 						"id":           "12854419928166856317",
 						"name":         "http:/hi2",
@@ -341,6 +361,7 @@ func TestZipkinPlugin(t *testing.T) {
 				},
 				{
 					Measurement: "zipkin",
+					Type:        telegraf.Untyped,
 					Tags: map[string]string{
 						"annotation":    "cs",
 						"endpoint_host": "192.168.0.8:8010",
@@ -357,6 +378,7 @@ func TestZipkinPlugin(t *testing.T) {
 				},
 				{
 					Measurement: "zipkin",
+					Type:        telegraf.Untyped,
 					Tags: map[string]string{
 						"annotation":    "cr",
 						"endpoint_host": "192.168.0.8:8010",
@@ -373,6 +395,7 @@ func TestZipkinPlugin(t *testing.T) {
 				},
 				{
 					Measurement: "zipkin",
+					Type:        telegraf.Untyped,
 					Tags: map[string]string{
 						"annotation":     "localhost",
 						"annotation_key": "http.host",
@@ -390,6 +413,7 @@ func TestZipkinPlugin(t *testing.T) {
 				},
 				{
 					Measurement: "zipkin",
+					Type:        telegraf.Untyped,
 					Tags: map[string]string{
 						"annotation":     "GET",
 						"annotation_key": "http.method",
@@ -407,6 +431,7 @@ func TestZipkinPlugin(t *testing.T) {
 				},
 				{
 					Measurement: "zipkin",
+					Type:        telegraf.Untyped,
 					Tags: map[string]string{
 						"annotation":     "/hi2",
 						"annotation_key": "http.path",
@@ -424,6 +449,7 @@ func TestZipkinPlugin(t *testing.T) {
 				},
 				{
 					Measurement: "zipkin",
+					Type:        telegraf.Untyped,
 					Tags: map[string]string{
 						"annotation":     "http://localhost:8010/hi2",
 						"annotation_key": "http.url",
@@ -441,6 +467,7 @@ func TestZipkinPlugin(t *testing.T) {
 				},
 				{
 					Measurement: "zipkin",
+					Type:        telegraf.Untyped,
 					Tags: map[string]string{
 						"annotation":     "192.168.0.8:test:8010",
 						"annotation_key": "spring.instance_id",
@@ -458,6 +485,7 @@ func TestZipkinPlugin(t *testing.T) {
 				},
 				{
 					Measurement: "zipkin",
+					Type:        telegraf.Untyped,
 					Tags: map[string]string{
 						"id":           "8291962692415852504",
 						"name":         "http:/hi",
@@ -472,6 +500,7 @@ func TestZipkinPlugin(t *testing.T) {
 				},
 				{
 					Measurement: "zipkin",
+					Type:        telegraf.Untyped,
 					Tags: map[string]string{
 						"annotation":    "sr",
 						"endpoint_host": "192.168.0.8:8010",
@@ -488,6 +517,7 @@ func TestZipkinPlugin(t *testing.T) {
 				},
 				testutil.Metric{
 					Measurement: "zipkin",
+					Type:        telegraf.Untyped,
 					Tags: map[string]string{
 						"annotation":    "ss",
 						"endpoint_host": "192.168.0.8:8010",
@@ -504,6 +534,7 @@ func TestZipkinPlugin(t *testing.T) {
 				},
 				testutil.Metric{
 					Measurement: "zipkin",
+					Type:        telegraf.Untyped,
 					Tags: map[string]string{
 						"annotation":     "Demo2Application",
 						"annotation_key": "mvc.controller.class",
@@ -521,6 +552,7 @@ func TestZipkinPlugin(t *testing.T) {
 				},
 				testutil.Metric{
 					Measurement: "zipkin",
+					Type:        telegraf.Untyped,
 					Tags: map[string]string{
 						"annotation":     "hi",
 						"annotation_key": "mvc.controller.method",
@@ -538,6 +570,7 @@ func TestZipkinPlugin(t *testing.T) {
 				},
 				testutil.Metric{
 					Measurement: "zipkin",
+					Type:        telegraf.Untyped,
 					Tags: map[string]string{
 						"annotation":     "192.168.0.8:test:8010",
 						"annotation_key": "spring.instance_id",
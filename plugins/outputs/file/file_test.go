@@ -9,6 +9,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/plugins/serializers"
 	"github.com/influxdata/telegraf/testutil"
@@ -132,6 +133,41 @@ func TestFileBoth(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestFileBatchSerializer(t *testing.T) {
+	s, _ := serializers.NewPrometheusSerializer(true)
+	fh := tmpFile()
+	f := File{
+		Files:      []string{fh},
+		serializer: s,
+	}
+
+	err := f.Connect()
+	assert.NoError(t, err)
+
+	// the metrics are serialized together, with a single HELP and TYPE line
+	// for each family.
+	m := testutil.TestMetric(3.0)
+	m.AddTag("tag1", "value2")
+	err = f.Write([]telegraf.Metric{
+		testutil.TestMetric(1.0),
+		testutil.TestMetric(2.0, "test2"),
+		m,
+	})
+	assert.NoError(t, err)
+
+	validateFile(fh, `# HELP test1 Telegraf collected metric
+# TYPE test1 untyped
+test1{tag1="value1"} 1 1257894000000
+test1{tag1="value2"} 3 1257894000000
+# HELP test2 Telegraf collected metric
+# TYPE test2 untyped
+test2{tag1="value1"} 2 1257894000000
+`, t)
+
+	err = f.Close()
+	assert.NoError(t, err)
+}
+
 func TestFileStdout(t *testing.T) {
 	// keep backup of the real stdout
 	old := os.Stdout
@@ -201,10 +201,9 @@ func (i *InfluxDB) Write(metrics []telegraf.Metric) error {
 			}
 
 			if strings.Contains(e.Error(), "field type conflict") {
-				log.Printf("E! Field type conflict, dropping conflicted points: %s", e)
-				// setting err to nil, otherwise we will keep retrying and points
-				// w/ conflicting types will get stuck in the buffer forever.
-				err = nil
+				// Retrying will not help, points w/ conflicting types would
+				// get stuck in the buffer forever.
+				err = internal.NonRetryable(e)
 				break
 			}
 
@@ -219,10 +218,9 @@ func (i *InfluxDB) Write(metrics []telegraf.Metric) error {
 			}
 
 			if strings.Contains(e.Error(), "unable to parse") {
-				log.Printf("E! Parse error; dropping points: %s", e)
 				// This error indicates a bug in Telegraf or InfluxDB parsing
 				// of line protocol.  Retries will not be successful.
-				err = nil
+				err = internal.NonRetryable(e)
 				break
 			}
 
@@ -7,6 +7,7 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/plugins/outputs/influxdb/client"
 	"github.com/influxdata/telegraf/testutil"
 
@@ -185,6 +186,8 @@ func TestHTTPError_WriteErrors(t *testing.T) {
 		contentType string
 		body        string
 		err         error
+		// the batch is dropped instead of retried
+		nonRetryable bool
 	}{
 		{
 			// HTTP/1.1 400 Bad Request
@@ -208,11 +211,11 @@ func TestHTTPError_WriteErrors(t *testing.T) {
 			// {
 			//     "error": "unable to parse 'foo bar=': missing field value"
 			// }
-			name:        "unable to parse is not an error",
-			status:      http.StatusBadRequest,
-			contentType: "application/json",
-			body:        `{"error":"unable to parse 'foo bar=': missing field value"}`,
-			err:         nil,
+			name:         "unable to parse is not retryable",
+			status:       http.StatusBadRequest,
+			contentType:  "application/json",
+			body:         `{"error":"unable to parse 'foo bar=': missing field value"}`,
+			nonRetryable: true,
 		},
 		{
 			// HTTP/1.1 400 Bad Request
@@ -222,11 +225,11 @@ func TestHTTPError_WriteErrors(t *testing.T) {
 			// {
 			//     "error": "partial write: field type conflict: input field \"bar\" on measurement \"foo\" is type float, already exists as type integer dropped=1"
 			// }
-			name:        "field type conflict is not an error",
-			status:      http.StatusBadRequest,
-			contentType: "application/json",
-			body:        `{"error": "partial write: field type conflict: input field \"bar\" on measurement \"foo\" is type float, already exists as type integer dropped=1"}`,
-			err:         nil,
+			name:         "field type conflict is not retryable",
+			status:       http.StatusBadRequest,
+			contentType:  "application/json",
+			body:         `{"error": "partial write: field type conflict: input field \"bar\" on measurement \"foo\" is type float, already exists as type integer dropped=1"}`,
+			nonRetryable: true,
 		},
 		{
 			// HTTP/1.1 500 Internal Server Error
@@ -275,7 +278,11 @@ func TestHTTPError_WriteErrors(t *testing.T) {
 			err := influx.Connect()
 			require.NoError(t, err)
 			err = influx.Write(testutil.MockMetrics())
-			require.Equal(t, tt.err, err)
+			if tt.nonRetryable {
+				require.True(t, internal.IsNonRetryable(err))
+			} else {
+				require.Equal(t, tt.err, err)
+			}
 			require.NoError(t, influx.Close())
 		})
 	}
@@ -14,6 +14,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/plugins/outputs"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -30,6 +31,15 @@ type Sample struct {
 	Labels map[string]string
 	// Value is the value in the Prometheus output.
 	Value float64
+	// Count and Sum are the number and sum of the observations of histograms
+	// and summaries.
+	Count uint64
+	Sum   float64
+	// HistogramValue is the cumulative count by upper bound of the buckets
+	// of a histogram.
+	HistogramValue map[float64]uint64
+	// SummaryValue is the value by quantile of a summary.
+	SummaryValue map[float64]float64
 	// Expiration is the deadline that this Sample is valid until.
 	Expiration time.Time
 }
@@ -40,6 +50,9 @@ type MetricFamily struct {
 	Samples map[SampleID]*Sample
 	// Type of the Value.
 	ValueType prometheus.ValueType
+	// TelegrafValueType is set to telegraf.Histogram or telegraf.Summary for
+	// families of histograms and summaries, ValueType is not used for them.
+	TelegrafValueType telegraf.ValueType
 	// LabelSet is the label counts for all Samples.
 	LabelSet map[string]int
 }
@@ -189,11 +202,24 @@ func (p *PrometheusClient) Collect(ch chan<- prometheus.Metric) {
 				labels = append(labels, v)
 			}
 
-			metric, err := prometheus.NewConstMetric(desc, family.ValueType, sample.Value, labels...)
+			var metric prometheus.Metric
+			var err error
+			switch family.TelegrafValueType {
+			case telegraf.Histogram:
+				metric, err = prometheus.NewConstHistogram(desc, sample.Count,
+					sample.Sum, sample.HistogramValue, labels...)
+			case telegraf.Summary:
+				metric, err = prometheus.NewConstSummary(desc, sample.Count,
+					sample.Sum, sample.SummaryValue, labels...)
+			default:
+				metric, err = prometheus.NewConstMetric(desc, family.ValueType,
+					sample.Value, labels...)
+			}
 			if err != nil {
 				log.Printf("E! Error creating prometheus metric, "+
 					"key: %s, labels: %v,\nerr: %s\n",
 					name, labels, err.Error())
+				continue
 			}
 
 			ch <- metric
@@ -242,6 +268,13 @@ func (p *PrometheusClient) Write(metrics []telegraf.Metric) error {
 			labels[sanitize(k)] = v
 		}
 
+		// Histograms and summaries are a single Prometheus metric, their
+		// fields are numeric.
+		if isDistribution(point.Type()) {
+			p.addDistribution(point, labels, sampleID, now)
+			continue
+		}
+
 		// Prometheus doesn't have a string value type, so convert string
 		// fields to labels.
 		for fn, fv := range point.Fields() {
@@ -306,11 +339,13 @@ func (p *PrometheusClient) Write(metrics []telegraf.Metric) error {
 				// preserve value type and received using an input such as a
 				// queue consumer.  To avoid issues we automatically upgrade
 				// value type from untyped to a typed metric.
-				if fam.ValueType == prometheus.UntypedValue {
+				if fam.ValueType == prometheus.UntypedValue &&
+					!isDistribution(fam.TelegrafValueType) {
 					fam.ValueType = vt
 				}
 
-				if vt != prometheus.UntypedValue && fam.ValueType != vt {
+				if isDistribution(fam.TelegrafValueType) ||
+					(vt != prometheus.UntypedValue && fam.ValueType != vt) {
 					// Don't return an error since this would be a permanent error
 					log.Printf("Mixed ValueType for measurement %q; dropping point", point.Name())
 					break
@@ -327,6 +362,48 @@ func (p *PrometheusClient) Write(metrics []telegraf.Metric) error {
 	return nil
 }
 
+// addDistribution adds a histogram or summary metric.
+func (p *PrometheusClient) addDistribution(
+	point telegraf.Metric,
+	labels map[string]string,
+	sampleID SampleID,
+	now time.Time,
+) {
+	sample := &Sample{
+		Labels:     labels,
+		Expiration: now.Add(p.ExpirationInterval.Duration),
+	}
+	if point.Type() == telegraf.Histogram {
+		sample.Count, sample.Sum, sample.HistogramValue = metric.Histogram(point)
+	} else {
+		sample.Count, sample.Sum, sample.SummaryValue = metric.Summary(point)
+	}
+
+	mname := sanitize(point.Name())
+	fam, ok := p.fam[mname]
+	if !ok {
+		fam = &MetricFamily{
+			Samples:           make(map[SampleID]*Sample),
+			ValueType:         prometheus.UntypedValue,
+			TelegrafValueType: point.Type(),
+			LabelSet:          make(map[string]int),
+		}
+		p.fam[mname] = fam
+	} else if fam.TelegrafValueType != point.Type() {
+		log.Printf("Mixed ValueType for measurement %q; dropping point", point.Name())
+		return
+	}
+
+	for k, _ := range sample.Labels {
+		fam.LabelSet[k]++
+	}
+	fam.Samples[sampleID] = sample
+}
+
+func isDistribution(tt telegraf.ValueType) bool {
+	return tt == telegraf.Histogram || tt == telegraf.Summary
+}
+
 func init() {
 	outputs.Add("prometheus_client", func() telegraf.Output {
 		return &PrometheusClient{
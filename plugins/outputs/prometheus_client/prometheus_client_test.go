@@ -1,6 +1,7 @@
 package prometheus_client
 
 import (
+	"math"
 	"testing"
 	"time"
 
@@ -212,6 +213,91 @@ func TestWrite_Gauge(t *testing.T) {
 	require.Equal(t, prometheus.GaugeValue, fam.ValueType)
 }
 
+func TestWrite_Histogram(t *testing.T) {
+	client := NewClient()
+
+	p1, err := metric.New(
+		"http_request_duration_seconds",
+		map[string]string{"handler": "query"},
+		map[string]interface{}{
+			"0.1":   float64(2),
+			"0.5":   float64(5),
+			"+Inf":  float64(7),
+			"count": float64(7),
+			"sum":   float64(3.2),
+		},
+		time.Now(),
+		telegraf.Histogram)
+	require.NoError(t, err)
+	err = client.Write([]telegraf.Metric{p1})
+	require.NoError(t, err)
+
+	fam, ok := client.fam["http_request_duration_seconds"]
+	require.True(t, ok)
+	require.Equal(t, telegraf.Histogram, fam.TelegrafValueType)
+	require.Equal(t, 1, len(fam.Samples))
+	for _, sample := range fam.Samples {
+		require.Equal(t, uint64(7), sample.Count)
+		require.Equal(t, 3.2, sample.Sum)
+		require.Equal(t, map[float64]uint64{0.1: 2, 0.5: 5, math.Inf(1): 7},
+			sample.HistogramValue)
+	}
+}
+
+func TestWrite_Summary(t *testing.T) {
+	client := NewClient()
+
+	p1, err := metric.New(
+		"rpc_duration_seconds",
+		make(map[string]string),
+		map[string]interface{}{
+			"0.5":   float64(0.2),
+			"0.99":  float64(1.5),
+			"count": float64(10),
+			"sum":   float64(4),
+		},
+		time.Now(),
+		telegraf.Summary)
+	require.NoError(t, err)
+	err = client.Write([]telegraf.Metric{p1})
+	require.NoError(t, err)
+
+	fam, ok := client.fam["rpc_duration_seconds"]
+	require.True(t, ok)
+	require.Equal(t, telegraf.Summary, fam.TelegrafValueType)
+	for _, sample := range fam.Samples {
+		require.Equal(t, uint64(10), sample.Count)
+		require.Equal(t, float64(4), sample.Sum)
+		require.Equal(t, map[float64]float64{0.5: 0.2, 0.99: 1.5}, sample.SummaryValue)
+	}
+}
+
+func TestWrite_MixedDistribution(t *testing.T) {
+	now := time.Now()
+	p1, err := metric.New(
+		"foo",
+		make(map[string]string),
+		map[string]interface{}{"0.5": 1.0, "count": 2.0, "sum": 3.0},
+		now,
+		telegraf.Summary)
+	require.NoError(t, err)
+	p2, err := metric.New(
+		"foo",
+		map[string]string{"host": "a"},
+		map[string]interface{}{"value": 2.0},
+		now)
+	require.NoError(t, err)
+
+	client := NewClient()
+	err = client.Write([]telegraf.Metric{p1, p2})
+	require.NoError(t, err)
+
+	fam, ok := client.fam["foo"]
+	require.True(t, ok)
+	require.Equal(t, telegraf.Summary, fam.TelegrafValueType)
+	require.Equal(t, 1, len(fam.Samples))
+}
+
 func TestWrite_MixedValueType(t *testing.T) {
 	now := time.Now()
 	p1, err := metric.New(
@@ -517,6 +603,80 @@ func TestPrometheusWritePointEmptyTag(t *testing.T) {
 	}
 }
 
+func TestPrometheusRoundTripValueTypes(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	pClient, p, err := setupPrometheus()
+	require.NoError(t, err)
+	defer pClient.Stop()
+
+	now := time.Now()
+	tags := make(map[string]string)
+	histogram, err := metric.New(
+		"test_histogram",
+		tags,
+		map[string]interface{}{
+			"0.1":   float64(2),
+			"0.5":   float64(5),
+			"+Inf":  float64(7),
+			"count": float64(7),
+			"sum":   float64(3.2),
+		},
+		now,
+		telegraf.Histogram)
+	require.NoError(t, err)
+	summary, err := metric.New(
+		"test_summary",
+		tags,
+		map[string]interface{}{
+			"0.5":   float64(0.2),
+			"0.99":  float64(1.5),
+			"count": float64(10),
+			"sum":   float64(4),
+		},
+		now,
+		telegraf.Summary)
+	require.NoError(t, err)
+	counter, err := metric.New(
+		"test_counter",
+		tags,
+		map[string]interface{}{"counter": float64(3)},
+		now,
+		telegraf.Counter)
+	require.NoError(t, err)
+	require.NoError(t, pClient.Write([]telegraf.Metric{histogram, summary, counter}))
+
+	var acc testutil.Accumulator
+	require.NoError(t, p.Gather(&acc))
+
+	m, ok := acc.Get("test_histogram")
+	require.True(t, ok)
+	require.Equal(t, telegraf.Histogram, m.Type)
+	require.Equal(t, map[string]interface{}{
+		"0.1":   float64(2),
+		"0.5":   float64(5),
+		"+Inf":  float64(7),
+		"count": float64(7),
+		"sum":   float64(3.2),
+	}, m.Fields)
+
+	m, ok = acc.Get("test_summary")
+	require.True(t, ok)
+	require.Equal(t, telegraf.Summary, m.Type)
+	require.Equal(t, map[string]interface{}{
+		"0.5":   float64(0.2),
+		"0.99":  float64(1.5),
+		"count": float64(10),
+		"sum":   float64(4),
+	}, m.Fields)
+
+	m, ok = acc.Get("test_counter")
+	require.True(t, ok)
+	require.Equal(t, telegraf.Counter, m.Type)
+}
+
 func setupPrometheus() (*PrometheusClient, *prometheus_input.Prometheus, error) {
 	if pTesting == nil {
 		pTesting = NewClient()
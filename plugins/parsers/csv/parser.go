@@ -0,0 +1,296 @@
+package csv
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+)
+
+// Parser parses CSV data, each record of the data is a metric.
+type Parser struct {
+	MetricName string
+	// HeaderRowCount is the number of rows holding the column names, the
+	// names of a column in several header rows are concatenated.
+	HeaderRowCount int
+	// SkipRows is the number of rows skipped before the header.
+	SkipRows int
+	// SkipColumns is the number of columns skipped on the left of each row.
+	SkipColumns int
+	Delimiter   string
+	Comment     string
+	TrimSpace   bool
+	// ColumnNames are the names of the columns, they take precedence over
+	// the names of the header. Unnamed columns are named column1, column2...
+	ColumnNames []string
+	// ColumnTypes are the types of the columns: int, float, bool or string.
+	// The type of the columns without type is inferred from their values.
+	ColumnTypes       []string
+	TagColumns        []string
+	MeasurementColumn string
+	// TimestampColumn is the column of the timestamp, which is parsed using
+	// TimestampFormat: unix, unix_ms, unix_us, unix_ns, RFC3339 or a Go time
+	// layout.
+	TimestampColumn string
+	TimestampFormat string
+	DefaultTags     map[string]string
+
+	compiled  bool
+	delimiter rune
+	comment   rune
+
+	// state of ParseLine, which is given the rows one at a time.
+	rowsSkipped int
+	headerRows  int
+	header      []string
+}
+
+func (p *Parser) compile() error {
+	if p.compiled {
+		return nil
+	}
+	if p.HeaderRowCount < 0 || p.SkipRows < 0 || p.SkipColumns < 0 {
+		return fmt.Errorf("csv_header_row_count, csv_skip_rows and csv_skip_columns must not be negative")
+	}
+	if p.TimestampColumn != "" && p.TimestampFormat == "" {
+		return fmt.Errorf("csv_timestamp_format must be set to parse csv_timestamp_column")
+	}
+	for _, typ := range p.ColumnTypes {
+		switch typ {
+		case "int", "float", "bool", "string", "":
+		default:
+			return fmt.Errorf("invalid csv_column_types %q, expected int, float, bool or string", typ)
+		}
+	}
+
+	p.delimiter = ','
+	if p.Delimiter != "" {
+		r, size := utf8.DecodeRuneInString(p.Delimiter)
+		if size != len(p.Delimiter) {
+			return fmt.Errorf("csv_delimiter must be a single character, got %q", p.Delimiter)
+		}
+		p.delimiter = r
+	}
+	if p.Comment != "" {
+		r, size := utf8.DecodeRuneInString(p.Comment)
+		if size != len(p.Comment) {
+			return fmt.Errorf("csv_comment must be a single character, got %q", p.Comment)
+		}
+		p.comment = r
+	}
+	p.compiled = true
+	return nil
+}
+
+func (p *Parser) newReader(r io.Reader) *csv.Reader {
+	reader := csv.NewReader(r)
+	reader.Comma = p.delimiter
+	reader.Comment = p.comment
+	reader.TrimLeadingSpace = p.TrimSpace
+	// the records may not all have the same number of columns
+	reader.FieldsPerRecord = -1
+	return reader
+}
+
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	if err := p.compile(); err != nil {
+		return nil, err
+	}
+
+	r := bufio.NewReader(bytes.NewReader(buf))
+	for i := 0; i < p.SkipRows; i++ {
+		if _, err := r.ReadString('\n'); err == io.EOF {
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	reader := p.newReader(r)
+	var header []string
+	for i := 0; i < p.HeaderRowCount; i++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		header = p.appendHeader(header, record)
+	}
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	metrics := make([]telegraf.Metric, 0, len(records))
+	for _, record := range records {
+		m, err := p.parseRecord(header, record)
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, nil
+}
+
+// ParseLine parses a single row. The first rows given to the parser are
+// skipped or read as the header, nil is returned for them as well as for
+// empty and comment rows.
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	if err := p.compile(); err != nil {
+		return nil, err
+	}
+	if p.rowsSkipped < p.SkipRows {
+		p.rowsSkipped++
+		return nil, nil
+	}
+
+	record, err := p.newReader(strings.NewReader(line)).Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if p.headerRows < p.HeaderRowCount {
+		p.header = p.appendHeader(p.header, record)
+		p.headerRows++
+		return nil, nil
+	}
+	return p.parseRecord(p.header, record)
+}
+
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
+
+// appendHeader concatenates the names of the columns of a header row to the
+// names of the previous header rows.
+func (p *Parser) appendHeader(header []string, record []string) []string {
+	for i, name := range p.skipColumns(record) {
+		if p.TrimSpace {
+			name = strings.TrimSpace(name)
+		}
+		if i < len(header) {
+			header[i] += name
+		} else {
+			header = append(header, name)
+		}
+	}
+	return header
+}
+
+func (p *Parser) skipColumns(record []string) []string {
+	if len(record) <= p.SkipColumns {
+		return nil
+	}
+	return record[p.SkipColumns:]
+}
+
+func (p *Parser) parseRecord(header []string, record []string) (telegraf.Metric, error) {
+	names := p.ColumnNames
+	if len(names) == 0 {
+		names = header
+	}
+
+	name := p.MetricName
+	tags := make(map[string]string)
+	for k, v := range p.DefaultTags {
+		tags[k] = v
+	}
+	fields := make(map[string]interface{})
+	t := time.Now().UTC()
+
+	for i, value := range p.skipColumns(record) {
+		if p.TrimSpace {
+			value = strings.TrimSpace(value)
+		}
+		column := "column" + strconv.Itoa(i+1)
+		if i < len(names) && names[i] != "" {
+			column = names[i]
+		}
+
+		switch {
+		case column == p.MeasurementColumn:
+			if value != "" {
+				name = value
+			}
+		case column == p.TimestampColumn:
+			var err error
+			t, err = internal.ParseTimestamp(value, p.TimestampFormat)
+			if err != nil {
+				return nil, fmt.Errorf("invalid timestamp in column %s: %s", column, err)
+			}
+		case p.isTag(column):
+			if value != "" {
+				tags[column] = value
+			}
+		default:
+			if value == "" {
+				continue
+			}
+			v, err := p.convert(value, i)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value in column %s: %s", column, err)
+			}
+			fields[column] = v
+		}
+	}
+
+	return metric.New(name, tags, fields, t)
+}
+
+func (p *Parser) isTag(column string) bool {
+	for _, tag := range p.TagColumns {
+		if tag == column {
+			return true
+		}
+	}
+	return false
+}
+
+// convert converts the value of the i-th column to its type. Without type,
+// the value is an int, a float or a bool (true or false) if it can be parsed
+// as such and a string otherwise.
+func (p *Parser) convert(value string, i int) (interface{}, error) {
+	var typ string
+	if i < len(p.ColumnTypes) {
+		typ = p.ColumnTypes[i]
+	}
+
+	switch typ {
+	case "int":
+		return strconv.ParseInt(value, 10, 64)
+	case "float":
+		return strconv.ParseFloat(value, 64)
+	case "bool":
+		return strconv.ParseBool(value)
+	case "string":
+		return value, nil
+	}
+
+	if v, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return v, nil
+	}
+	if v, err := strconv.ParseFloat(value, 64); err == nil && !math.IsNaN(v) && !math.IsInf(v, 0) {
+		return v, nil
+	}
+	switch {
+	case strings.EqualFold(value, "true"):
+		return true, nil
+	case strings.EqualFold(value, "false"):
+		return false, nil
+	}
+	return value, nil
+}
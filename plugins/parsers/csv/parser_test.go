@@ -0,0 +1,148 @@
+package csv
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const validCSV = `# exported by the backup tool
+host,job,duration,bytes,ok,message
+server01,daily,12.5,1024,true,done
+server02,daily,3,,false,no space left
+`
+
+func TestParseHeader(t *testing.T) {
+	parser := Parser{
+		MetricName:     "backup",
+		HeaderRowCount: 1,
+		Comment:        "#",
+		TagColumns:     []string{"host"},
+		DefaultTags:    map[string]string{"source": "csv"},
+	}
+	metrics, err := parser.Parse([]byte(validCSV))
+	require.NoError(t, err)
+	require.Len(t, metrics, 2)
+
+	assert.Equal(t, "backup", metrics[0].Name())
+	assert.Equal(t, map[string]string{"host": "server01", "source": "csv"}, metrics[0].Tags())
+	assert.Equal(t, map[string]interface{}{
+		"job":      "daily",
+		"duration": 12.5,
+		"bytes":    int64(1024),
+		"ok":       true,
+		"message":  "done",
+	}, metrics[0].Fields())
+
+	// the empty values are skipped
+	assert.Equal(t, map[string]interface{}{
+		"job":      "daily",
+		"duration": int64(3),
+		"ok":       false,
+		"message":  "no space left",
+	}, metrics[1].Fields())
+}
+
+func TestParseColumnNamesAndTypes(t *testing.T) {
+	parser := Parser{
+		MetricName:  "backup",
+		Delimiter:   ";",
+		TrimSpace:   true,
+		SkipColumns: 1,
+		ColumnNames: []string{"host", "duration", "code"},
+		ColumnTypes: []string{"string", "float", "string"},
+	}
+	metrics, err := parser.Parse([]byte("1; server01; 3; 007; 42\n"))
+	require.NoError(t, err)
+	require.Len(t, metrics, 1)
+	assert.Equal(t, map[string]interface{}{
+		"host":     "server01",
+		"duration": 3.0,
+		"code":     "007",
+		"column4":  int64(42),
+	}, metrics[0].Fields())
+
+	_, err = parser.Parse([]byte("1;server01;fast;007\n"))
+	assert.Error(t, err)
+}
+
+func TestParseSkipRowsAndMultipleHeaders(t *testing.T) {
+	parser := Parser{
+		MetricName:     "disk",
+		SkipRows:       2,
+		HeaderRowCount: 2,
+	}
+	data := "Disk report\ngenerated at 12:00\nused_,free_\nbytes,bytes\n100,200\n"
+	metrics, err := parser.Parse([]byte(data))
+	require.NoError(t, err)
+	require.Len(t, metrics, 1)
+	assert.Equal(t, map[string]interface{}{
+		"used_bytes": int64(100),
+		"free_bytes": int64(200),
+	}, metrics[0].Fields())
+
+	// data without any record
+	metrics, err = parser.Parse([]byte("Disk report\n"))
+	assert.NoError(t, err)
+	assert.Len(t, metrics, 0)
+}
+
+func TestParseMeasurementAndTimestamp(t *testing.T) {
+	parser := Parser{
+		MetricName:        "csv",
+		HeaderRowCount:    1,
+		MeasurementColumn: "name",
+		TimestampColumn:   "time",
+		TimestampFormat:   "2006-01-02 15:04:05",
+	}
+	data := "name,time,value\ncpu,2018-01-01 00:00:00,42\n,2018-01-01 00:00:10,43\n"
+	metrics, err := parser.Parse([]byte(data))
+	require.NoError(t, err)
+	require.Len(t, metrics, 2)
+
+	assert.Equal(t, "cpu", metrics[0].Name())
+	assert.True(t, time.Unix(1514764800, 0).Equal(metrics[0].Time()))
+	assert.Equal(t, map[string]interface{}{"value": int64(42)}, metrics[0].Fields())
+	assert.Equal(t, "csv", metrics[1].Name())
+	assert.True(t, time.Unix(1514764810, 0).Equal(metrics[1].Time()))
+
+	_, err = parser.Parse([]byte("name,time,value\ncpu,yesterday,42\n"))
+	assert.Error(t, err)
+}
+
+func TestParseLine(t *testing.T) {
+	parser := Parser{
+		MetricName:     "backup",
+		SkipRows:       1,
+		HeaderRowCount: 1,
+		Comment:        "#",
+		TagColumns:     []string{"host"},
+	}
+
+	for _, line := range []string{"Backup report", "host,bytes", "", "# comment"} {
+		m, err := parser.ParseLine(line)
+		require.NoError(t, err)
+		assert.Nil(t, m, line)
+	}
+
+	m, err := parser.ParseLine("server01,1024")
+	require.NoError(t, err)
+	require.NotNil(t, m)
+	assert.Equal(t, map[string]string{"host": "server01"}, m.Tags())
+	assert.Equal(t, map[string]interface{}{"bytes": int64(1024)}, m.Fields())
+}
+
+func TestParseInvalidConfig(t *testing.T) {
+	for _, parser := range []*Parser{
+		{MetricName: "csv", Delimiter: ";;"},
+		{MetricName: "csv", Comment: "//"},
+		{MetricName: "csv", ColumnTypes: []string{"integer"}},
+		{MetricName: "csv", TimestampColumn: "time"},
+		{MetricName: "csv", SkipRows: -1},
+	} {
+		_, err := parser.Parse([]byte("1,2\n"))
+		assert.Error(t, err)
+	}
+}
@@ -0,0 +1,253 @@
+package dropwizard
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/parsers/graphite"
+)
+
+// sections are the sections of the metric registry and the metric_type tag
+// of their metrics.
+var sections = []struct {
+	name       string
+	metricType string
+}{
+	{"counters", "counter"},
+	{"gauges", "gauge"},
+	{"histograms", "histogram"},
+	{"meters", "meter"},
+	{"timers", "timer"},
+}
+
+// Parser parses the JSON representation of a Dropwizard metric registry,
+// each registered metric is a metric.
+type Parser struct {
+	// MetricRegistryPath is the path of the metric registry in the JSON
+	// document, keys separated by dots. By default the whole document is the
+	// registry.
+	MetricRegistryPath string
+	// TimePath is the path of the timestamp in the JSON document, which is
+	// parsed using TimeFormat: unix, unix_ms, unix_us, unix_ns, RFC3339 or a
+	// Go time layout.
+	TimePath   string
+	TimeFormat string
+	// TagsPath is the path of an object of the JSON document whose string
+	// values are added as tags to all the metrics.
+	TagsPath string
+
+	// Templates extract the measurement and tags from the metric names, like
+	// the templates of the graphite parser.
+	Separator   string
+	Templates   []string
+	DefaultTags map[string]string
+
+	templateEngine *graphite.GraphiteParser
+}
+
+// Compile compiles the templates, it is called by Parse if needed.
+func (p *Parser) Compile() error {
+	if p.TimePath != "" && p.TimeFormat == "" {
+		return fmt.Errorf("dropwizard_time_format must be set to parse dropwizard_time_path")
+	}
+
+	var err error
+	p.templateEngine, err = graphite.NewGraphiteParser(p.Separator, p.Templates, nil)
+	return err
+}
+
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	if p.templateEngine == nil {
+		if err := p.Compile(); err != nil {
+			return nil, err
+		}
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(buf, &doc); err != nil {
+		return nil, err
+	}
+
+	registry, err := lookupObject(doc, p.MetricRegistryPath)
+	if err != nil {
+		return nil, err
+	}
+
+	t := time.Now().UTC()
+	if p.TimePath != "" {
+		v, err := lookup(doc, p.TimePath)
+		if err != nil {
+			return nil, err
+		}
+		if t, err = internal.ParseTimestamp(v, p.TimeFormat); err != nil {
+			return nil, err
+		}
+	}
+
+	tags := make(map[string]string)
+	for k, v := range p.DefaultTags {
+		tags[k] = v
+	}
+	if p.TagsPath != "" {
+		object, err := lookupObject(doc, p.TagsPath)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range object {
+			if s, ok := v.(string); ok {
+				tags[k] = s
+			}
+		}
+	}
+
+	metrics := make([]telegraf.Metric, 0)
+	for _, section := range sections {
+		entries, ok := registry[section.name].(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		names := make([]string, 0, len(entries))
+		for name := range entries {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			values, ok := entries[name].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			m, err := p.newMetric(name, section.metricType, values, tags, t)
+			if err != nil {
+				return nil, err
+			}
+			if m != nil {
+				metrics = append(metrics, m)
+			}
+		}
+	}
+	return metrics, nil
+}
+
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	metrics, err := p.Parse([]byte(line))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(metrics) < 1 {
+		return nil, fmt.Errorf("Can not parse the line: %s, for data format: dropwizard ", line)
+	}
+
+	return metrics[0], nil
+}
+
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
+
+// newMetric creates the metric of a registered metric, nil is returned if it
+// has no fields.
+func (p *Parser) newMetric(
+	name string,
+	metricType string,
+	values map[string]interface{},
+	registryTags map[string]string,
+	t time.Time,
+) (telegraf.Metric, error) {
+	measurement, tags, err := p.parseName(name)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range registryTags {
+		if _, ok := tags[k]; !ok {
+			tags[k] = v
+		}
+	}
+	tags["metric_type"] = metricType
+
+	fields := make(map[string]interface{})
+	for k, v := range values {
+		switch v := v.(type) {
+		case float64:
+			if k == "count" {
+				fields[k] = int64(v)
+			} else {
+				fields[k] = v
+			}
+		case string, bool:
+			// the units of the meters and timers are not kept, only
+			// the gauges can have non numeric values.
+			if metricType == "gauge" {
+				fields[k] = v
+			}
+		}
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	return metric.New(measurement, tags, fields, t)
+}
+
+// parseName returns the measurement and tags of a metric name. The name can
+// embed tags in the line protocol format, ie "requests,method=get", and the
+// rest of the name is parsed using the templates.
+func (p *Parser) parseName(name string) (string, map[string]string, error) {
+	parts := strings.Split(name, ",")
+
+	measurement, tags, _, err := p.templateEngine.ApplyTemplateToName(parts[0])
+	if err != nil {
+		return "", nil, err
+	}
+	if measurement == "" {
+		measurement = parts[0]
+	}
+
+	for _, part := range parts[1:] {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+			return "", nil, fmt.Errorf("invalid tag %q in metric name %q", part, name)
+		}
+		tags[kv[0]] = kv[1]
+	}
+	return measurement, tags, nil
+}
+
+// lookup returns the value at the path of the document, keys separated by
+// dots. An empty path is the document itself.
+func lookup(doc map[string]interface{}, path string) (interface{}, error) {
+	var v interface{} = doc
+	if path == "" {
+		return v, nil
+	}
+	for _, key := range strings.Split(path, ".") {
+		object, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid path %q, %q is not in an object", path, key)
+		}
+		if v, ok = object[key]; !ok {
+			return nil, fmt.Errorf("invalid path %q, no key %q", path, key)
+		}
+	}
+	return v, nil
+}
+
+func lookupObject(doc map[string]interface{}, path string) (map[string]interface{}, error) {
+	v, err := lookup(doc, path)
+	if err != nil {
+		return nil, err
+	}
+	object, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid path %q, expected an object", path)
+	}
+	return object, nil
+}
@@ -0,0 +1,171 @@
+package dropwizard
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const validRegistry = `
+{
+	"version": "3.0.0",
+	"counters": {
+		"jobs.active,queue=emails": {"count": 3}
+	},
+	"gauges": {
+		"jvm.threads.count": {"value": 42},
+		"build.version": {"value": "1.2.3"}
+	},
+	"histograms": {
+		"responses.size": {"count": 2, "max": 20, "mean": 15.0, "min": 10, "p50": 15.0, "p99": 20.0, "stddev": 5.0}
+	},
+	"meters": {
+		"requests": {"count": 10, "m1_rate": 1.5, "mean_rate": 2.0, "units": "events/second"}
+	},
+	"timers": {
+		"requests.latency": {"count": 10, "max": 0.2, "p50": 0.1, "m1_rate": 1.5, "duration_units": "seconds", "rate_units": "calls/second"}
+	}
+}
+`
+
+func TestParseRegistry(t *testing.T) {
+	parser := Parser{DefaultTags: map[string]string{"host": "server01"}}
+	metrics, err := parser.Parse([]byte(validRegistry))
+	require.NoError(t, err)
+	require.Len(t, metrics, 6)
+
+	assert.Equal(t, "jobs.active", metrics[0].Name())
+	assert.Equal(t, map[string]string{"host": "server01", "queue": "emails", "metric_type": "counter"}, metrics[0].Tags())
+	assert.Equal(t, map[string]interface{}{"count": int64(3)}, metrics[0].Fields())
+
+	assert.Equal(t, "build.version", metrics[1].Name())
+	assert.Equal(t, map[string]interface{}{"value": "1.2.3"}, metrics[1].Fields())
+	assert.Equal(t, "jvm.threads.count", metrics[2].Name())
+	assert.Equal(t, map[string]interface{}{"value": 42.0}, metrics[2].Fields())
+
+	assert.Equal(t, "responses.size", metrics[3].Name())
+	assert.Equal(t, "histogram", metrics[3].Tags()["metric_type"])
+	assert.Equal(t, map[string]interface{}{
+		"count":  int64(2),
+		"max":    20.0,
+		"mean":   15.0,
+		"min":    10.0,
+		"p50":    15.0,
+		"p99":    20.0,
+		"stddev": 5.0,
+	}, metrics[3].Fields())
+
+	assert.Equal(t, "requests", metrics[4].Name())
+	assert.Equal(t, "meter", metrics[4].Tags()["metric_type"])
+	assert.Equal(t, map[string]interface{}{
+		"count":     int64(10),
+		"m1_rate":   1.5,
+		"mean_rate": 2.0,
+	}, metrics[4].Fields())
+
+	assert.Equal(t, "requests.latency", metrics[5].Name())
+	assert.Equal(t, "timer", metrics[5].Tags()["metric_type"])
+	assert.Equal(t, map[string]interface{}{
+		"count":   int64(10),
+		"max":     0.2,
+		"p50":     0.1,
+		"m1_rate": 1.5,
+	}, metrics[5].Fields())
+}
+
+func TestParseTemplates(t *testing.T) {
+	parser := Parser{
+		Separator: "_",
+		Templates: []string{
+			"jvm.* measurement.measurement.type",
+			"measurement.host.measurement* env=prod",
+		},
+	}
+	metrics, err := parser.Parse([]byte(`{
+		"gauges": {
+			"jvm.memory.heap": {"value": 1024},
+			"queue.server01.jobs.pending,queue=emails": {"value": 3}
+		}
+	}`))
+	require.NoError(t, err)
+	require.Len(t, metrics, 2)
+
+	assert.Equal(t, "jvm_memory", metrics[0].Name())
+	assert.Equal(t, map[string]string{"type": "heap", "metric_type": "gauge"}, metrics[0].Tags())
+	assert.Equal(t, "queue_jobs_pending", metrics[1].Name())
+	assert.Equal(t, map[string]string{
+		"host":        "server01",
+		"env":         "prod",
+		"queue":       "emails",
+		"metric_type": "gauge",
+	}, metrics[1].Tags())
+}
+
+func TestParseNameWithSpaces(t *testing.T) {
+	parser := Parser{
+		Separator: "_",
+		Templates: []string{"jvm.* measurement.measurement.type"},
+	}
+	metrics, err := parser.Parse([]byte(`{
+		"gauges": {
+			"jvm.memory pool.heap usage": {"value": 1024},
+			"requests total,method=get": {"value": 3}
+		}
+	}`))
+	require.NoError(t, err)
+	require.Len(t, metrics, 2)
+
+	// the whole name is given to the templates
+	assert.Equal(t, "jvm_memory pool", metrics[0].Name())
+	assert.Equal(t, map[string]string{"type": "heap usage", "metric_type": "gauge"}, metrics[0].Tags())
+	assert.Equal(t, "requests total", metrics[1].Name())
+	assert.Equal(t, map[string]string{"method": "get", "metric_type": "gauge"}, metrics[1].Tags())
+}
+
+func TestParsePaths(t *testing.T) {
+	parser := Parser{
+		MetricRegistryPath: "app.metrics",
+		TimePath:           "app.time",
+		TimeFormat:         "unix_ms",
+		TagsPath:           "app.tags",
+		DefaultTags:        map[string]string{"host": "default", "dc": "eu"},
+	}
+	metrics, err := parser.Parse([]byte(`{
+		"app": {
+			"time": 1514764800123,
+			"tags": {"host": "server01", "replicas": 3},
+			"metrics": {"counters": {"requests": {"count": 5}}}
+		}
+	}`))
+	require.NoError(t, err)
+	require.Len(t, metrics, 1)
+
+	assert.True(t, time.Unix(1514764800, 123000000).Equal(metrics[0].Time()))
+	assert.Equal(t, map[string]string{"host": "server01", "dc": "eu", "metric_type": "counter"}, metrics[0].Tags())
+
+	for _, data := range []string{
+		`{"app": {"time": 1514764800123, "tags": {}}}`,
+		`{"app": {"time": "now", "tags": {}, "metrics": {}}}`,
+		`{"app": {"time": 1514764800123, "metrics": {}}}`,
+		`{"app": []}`,
+	} {
+		_, err = parser.Parse([]byte(data))
+		assert.Error(t, err, data)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	parser := Parser{}
+	for _, data := range []string{
+		`not json`,
+		`{"counters": {"requests,method": {"count": 5}}}`,
+	} {
+		_, err := parser.Parse([]byte(data))
+		assert.Error(t, err, data)
+	}
+
+	parser = Parser{Templates: []string{"host.field"}}
+	assert.Error(t, parser.Compile())
+}
@@ -228,9 +228,16 @@ func (p *GraphiteParser) ApplyTemplate(line string) (string, map[string]string,
 	if len(fields) == 0 {
 		return "", make(map[string]string), "", nil
 	}
+	return p.ApplyTemplateToName(fields[0])
+}
+
+// ApplyTemplateToName extracts the template fields from the given metric name
+// and returns the measurement name and tags. Unlike the lines given to
+// ApplyTemplate, the name can contain whitespace.
+func (p *GraphiteParser) ApplyTemplateToName(name string) (string, map[string]string, string, error) {
 	// decode the name and tags
-	template := p.matcher.Match(fields[0])
-	name, tags, field, err := template.Apply(fields[0])
+	template := p.matcher.Match(name)
+	measurement, tags, field, err := template.Apply(name)
 
 	// Set the default tags on the point if they are not already set
 	for k, v := range p.DefaultTags {
@@ -239,7 +246,7 @@ func (p *GraphiteParser) ApplyTemplate(line string) (string, map[string]string,
 		}
 	}
 
-	return name, tags, field, err
+	return measurement, tags, field, err
 }
 
 // template represents a pattern and tags to map a graphite metric string to a influxdb Point
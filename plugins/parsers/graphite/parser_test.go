@@ -617,6 +617,19 @@ func TestApplyTemplate(t *testing.T) {
 	assert.Equal(t, "current_users", measurement)
 }
 
+// Test that the whole name is used by ApplyTemplateToName
+func TestApplyTemplateToName(t *testing.T) {
+	p, err := NewGraphiteParser("_",
+		[]string{"current.* measurement.measurement.user"},
+		nil)
+	assert.NoError(t, err)
+
+	measurement, tags, _, err := p.ApplyTemplateToName("current.users.jane doe")
+	assert.NoError(t, err)
+	assert.Equal(t, "current_users", measurement)
+	assert.Equal(t, map[string]string{"user": "jane doe"}, tags)
+}
+
 // Test basic functionality of ApplyTemplate
 func TestApplyTemplateNoMatch(t *testing.T) {
 	p, err := NewGraphiteParser(".",
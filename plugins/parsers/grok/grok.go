@@ -2,6 +2,7 @@ package grok
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
 	"log"
 	"os"
@@ -81,6 +82,10 @@ type Parser struct {
 	Timezone string
 	loc      *time.Location
 
+	// DefaultTags are added to the parsed metrics, the captured tags take
+	// precedence over them.
+	DefaultTags map[string]string
+
 	// typeMap is a map of patterns -> capture name -> modifier,
 	//   ie, {
 	//          "%{TESTLOG}":
@@ -200,6 +205,9 @@ func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
 
 	fields := make(map[string]interface{})
 	tags := make(map[string]string)
+	for k, v := range p.DefaultTags {
+		tags[k] = v
+	}
 	timestamp := time.Now()
 	for k, v := range values {
 		if k == "" || v == "" {
@@ -329,6 +337,31 @@ func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
 	return metric.New(p.Measurement, tags, fields, p.tsModder.tsMod(timestamp))
 }
 
+// Parse parses each line of the buffer, the lines not matching any pattern
+// are skipped.
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	metrics := make([]telegraf.Metric, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(buf))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		m, err := p.ParseLine(line)
+		if err != nil {
+			return nil, err
+		}
+		if m != nil {
+			metrics = append(metrics, m)
+		}
+	}
+	return metrics, scanner.Err()
+}
+
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
+
 func (p *Parser) addCustomPatterns(scanner *bufio.Scanner) {
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
@@ -391,7 +424,7 @@ func (p *Parser) parseTypedCaptures(name, pattern string) (string, error) {
 		// regex capture 2 is the modifier of the capture
 		if strings.HasPrefix(match[2], "ts") {
 			if hasTimestamp {
-				return pattern, fmt.Errorf("logparser pattern compile error: "+
+				return pattern, fmt.Errorf("grok pattern compile error: "+
 					"Each pattern is allowed only one named "+
 					"timestamp data type. pattern: %s", pattern)
 			}
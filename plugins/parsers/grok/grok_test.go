@@ -79,6 +79,27 @@ func TestSimpleParse(t *testing.T) {
 		m.Fields())
 }
 
+func TestParseMultipleLines(t *testing.T) {
+	p := &Parser{
+		Measurement: "grok",
+		Patterns:    []string{"%{TESTLOG}"},
+		CustomPatterns: `
+			TESTLOG %{NUMBER:num:int} %{WORD:client:tag}
+		`,
+		DefaultTags: map[string]string{"client": "default", "source": "grok"},
+	}
+	assert.NoError(t, p.Compile())
+
+	metrics, err := p.Parse([]byte("142 bot\r\nno match\n\n7 human\n"))
+	assert.NoError(t, err)
+	require.Len(t, metrics, 2)
+
+	assert.Equal(t, "grok", metrics[0].Name())
+	assert.Equal(t, map[string]interface{}{"num": int64(142)}, metrics[0].Fields())
+	assert.Equal(t, map[string]string{"client": "bot", "source": "grok"}, metrics[0].Tags())
+	assert.Equal(t, map[string]string{"client": "human", "source": "grok"}, metrics[1].Tags())
+}
+
 // Verify that patterns with a regex lookahead fail at compile time.
 func TestParsePatternsWithLookahead(t *testing.T) {
 	p := &Parser{
@@ -9,6 +9,8 @@ import (
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/metric"
 )
 
@@ -16,19 +18,54 @@ type JSONParser struct {
 	MetricName  string
 	TagKeys     []string
 	DefaultTags map[string]string
+
+	// StringFields are the string values kept as fields, globs can be used.
+	StringFields []string
+	// Query is the path of the object or array to parse, as keys and array
+	// indexes separated by dots.
+	Query string
+	// NameKey is the key of the measurement name.
+	NameKey string
+	// TimeKey is the key of the timestamp, which is parsed using TimeFormat:
+	// unix, unix_ms, unix_us, unix_ns, RFC3339 or a Go time layout.
+	TimeKey    string
+	TimeFormat string
+
+	compiled     bool
+	stringFilter filter.Filter
 }
 
-func (p *JSONParser) parseArray(buf []byte) ([]telegraf.Metric, error) {
-	metrics := make([]telegraf.Metric, 0)
+func (p *JSONParser) compile() error {
+	if p.compiled {
+		return nil
+	}
+	if p.TimeKey != "" && p.TimeFormat == "" {
+		return fmt.Errorf("json_time_format must be set to parse json_time_key")
+	}
 
-	var jsonOut []map[string]interface{}
-	err := json.Unmarshal(buf, &jsonOut)
+	var err error
+	p.stringFilter, err = filter.Compile(p.StringFields)
 	if err != nil {
-		err = fmt.Errorf("unable to parse out as JSON Array, %s", err)
-		return nil, err
+		return fmt.Errorf("invalid json_string_fields: %s", err)
 	}
-	for _, item := range jsonOut {
-		metrics, err = p.parseObject(metrics, item)
+	p.compiled = true
+	return nil
+}
+
+func (p *JSONParser) parseArray(items []interface{}) ([]telegraf.Metric, error) {
+	metrics := make([]telegraf.Metric, 0)
+	for _, item := range items {
+		object, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unable to parse out as JSON Array, "+
+				"expected objects but got %T", item)
+		}
+
+		var err error
+		metrics, err = p.parseObject(metrics, object)
+		if err != nil {
+			return nil, err
+		}
 	}
 	return metrics, nil
 }
@@ -52,13 +89,40 @@ func (p *JSONParser) parseObject(metrics []telegraf.Metric, jsonOut map[string]i
 		delete(jsonOut, tag)
 	}
 
+	name := p.MetricName
+	if p.NameKey != "" {
+		if v, ok := jsonOut[p.NameKey].(string); ok && v != "" {
+			name = v
+		}
+		delete(jsonOut, p.NameKey)
+	}
+
+	t := time.Now().UTC()
+	if p.TimeKey != "" {
+		v, ok := jsonOut[p.TimeKey]
+		if !ok {
+			return nil, fmt.Errorf("JSON time key %q is missing", p.TimeKey)
+		}
+		var err error
+		t, err = internal.ParseTimestamp(v, p.TimeFormat)
+		if err != nil {
+			return nil, err
+		}
+		delete(jsonOut, p.TimeKey)
+	}
+
 	f := JSONFlattener{}
-	err := f.FlattenJSON("", jsonOut)
+	err := f.FullFlattenJSON("", jsonOut, p.stringFilter != nil, false)
 	if err != nil {
 		return nil, err
 	}
+	for k, v := range f.Fields {
+		if _, ok := v.(string); ok && !p.stringFilter.Match(k) {
+			delete(f.Fields, k)
+		}
+	}
 
-	metric, err := metric.New(p.MetricName, tags, f.Fields, time.Now().UTC())
+	metric, err := metric.New(name, tags, f.Fields, t)
 
 	if err != nil {
 		return nil, err
@@ -67,22 +131,61 @@ func (p *JSONParser) parseObject(metrics []telegraf.Metric, jsonOut map[string]i
 }
 
 func (p *JSONParser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	if err := p.compile(); err != nil {
+		return nil, err
+	}
+
 	buf = bytes.TrimSpace(buf)
 	if len(buf) == 0 {
 		return make([]telegraf.Metric, 0), nil
 	}
 
-	if !isarray(buf) {
-		metrics := make([]telegraf.Metric, 0)
-		var jsonOut map[string]interface{}
-		err := json.Unmarshal(buf, &jsonOut)
+	var jsonOut interface{}
+	err := json.Unmarshal(buf, &jsonOut)
+	if err != nil {
+		err = fmt.Errorf("unable to parse out as JSON, %s", err)
+		return nil, err
+	}
+
+	if p.Query != "" {
+		jsonOut, err = query(jsonOut, p.Query)
 		if err != nil {
-			err = fmt.Errorf("unable to parse out as JSON, %s", err)
 			return nil, err
 		}
-		return p.parseObject(metrics, jsonOut)
 	}
-	return p.parseArray(buf)
+
+	switch v := jsonOut.(type) {
+	case map[string]interface{}:
+		return p.parseObject(make([]telegraf.Metric, 0), v)
+	case []interface{}:
+		return p.parseArray(v)
+	default:
+		return nil, fmt.Errorf("unable to parse out as JSON, "+
+			"expected an object or an array but got %T", jsonOut)
+	}
+}
+
+// query returns the value at the given path of keys and array indexes
+// separated by dots, such as "data.items.0".
+func query(v interface{}, path string) (interface{}, error) {
+	for _, key := range strings.Split(path, ".") {
+		switch t := v.(type) {
+		case map[string]interface{}:
+			var ok bool
+			if v, ok = t[key]; !ok {
+				return nil, fmt.Errorf("JSON query %q: key %q not found", path, key)
+			}
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(t) {
+				return nil, fmt.Errorf("JSON query %q: invalid array index %q", path, key)
+			}
+			v = t[i]
+		default:
+			return nil, fmt.Errorf("JSON query %q: cannot get %q of %T", path, key, v)
+		}
+	}
+	return v, nil
 }
 
 func (p *JSONParser) ParseLine(line string) (telegraf.Metric, error) {
@@ -166,13 +269,3 @@ func (f *JSONFlattener) FullFlattenJSON(
 	}
 	return nil
 }
-
-func isarray(buf []byte) bool {
-	ia := bytes.IndexByte(buf, '[')
-	ib := bytes.IndexByte(buf, '{')
-	if ia > -1 && ia < ib {
-		return true
-	} else {
-		return false
-	}
-}
@@ -2,8 +2,10 @@ package json
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 const (
@@ -428,3 +430,90 @@ func TestParseArrayWithTagKeys(t *testing.T) {
 		"othertag": "baz",
 	}, metrics[1].Tags())
 }
+
+const validJSONQuery = `
+{
+    "status": "ok",
+    "data": {
+        "servers": [
+            {
+                "name": "web",
+                "measurement": "server_stats",
+                "time": "2018-01-01T00:00:00.5Z",
+                "state": "running",
+                "version": "1.2.0",
+                "load": 0.5,
+                "disk": {"mount": "/", "used": 42}
+            },
+            {
+                "name": "db",
+                "time": "2018-01-01T00:00:01Z",
+                "state": "stopped",
+                "load": 1.5,
+                "disk": {"mount": "/var", "used": 84}
+            }
+        ]
+    }
+}
+`
+
+func TestParseWithQuery(t *testing.T) {
+	parser := JSONParser{
+		MetricName:   "json_test",
+		TagKeys:      []string{"name"},
+		StringFields: []string{"state", "disk_*"},
+		Query:        "data.servers",
+		NameKey:      "measurement",
+		TimeKey:      "time",
+		TimeFormat:   "RFC3339",
+	}
+	metrics, err := parser.Parse([]byte(validJSONQuery))
+	require.NoError(t, err)
+	require.Len(t, metrics, 2)
+
+	assert.Equal(t, "server_stats", metrics[0].Name())
+	assert.Equal(t, map[string]string{"name": "web"}, metrics[0].Tags())
+	assert.Equal(t, map[string]interface{}{
+		"state":      "running",
+		"load":       0.5,
+		"disk_mount": "/",
+		"disk_used":  float64(42),
+	}, metrics[0].Fields())
+	assert.True(t, time.Unix(1514764800, 500000000).Equal(metrics[0].Time()))
+
+	assert.Equal(t, "json_test", metrics[1].Name())
+	assert.True(t, time.Unix(1514764801, 0).Equal(metrics[1].Time()))
+
+	// a single object of an array
+	parser.Query = "data.servers.1"
+	metrics, err = parser.Parse([]byte(validJSONQuery))
+	require.NoError(t, err)
+	require.Len(t, metrics, 1)
+	assert.Equal(t, map[string]string{"name": "db"}, metrics[0].Tags())
+
+	for _, query := range []string{"data.clients", "data.servers.2", "data.servers.x", "status.0"} {
+		parser.Query = query
+		_, err = parser.Parse([]byte(validJSONQuery))
+		assert.Error(t, err, query)
+	}
+}
+
+func TestParseWithTimeKey(t *testing.T) {
+	parser := JSONParser{
+		MetricName: "json_test",
+		TimeKey:    "time",
+		TimeFormat: "unix_ms",
+	}
+	metrics, err := parser.Parse([]byte(`{"a": 5, "time": 1514764800000}`))
+	require.NoError(t, err)
+	require.Len(t, metrics, 1)
+	assert.Equal(t, map[string]interface{}{"a": float64(5)}, metrics[0].Fields())
+	assert.True(t, time.Unix(1514764800, 0).Equal(metrics[0].Time()))
+
+	_, err = parser.Parse([]byte(`{"a": 5}`))
+	assert.Error(t, err)
+
+	parser = JSONParser{MetricName: "json_test", TimeKey: "time"}
+	_, err = parser.Parse([]byte(`{"a": 5, "time": 1514764800000}`))
+	assert.Error(t, err)
+}
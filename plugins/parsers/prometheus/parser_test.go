@@ -5,6 +5,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/influxdata/telegraf"
+
 	"github.com/stretchr/testify/assert"
 )
 
@@ -110,6 +112,7 @@ func TestParseValidPrometheus(t *testing.T) {
 	assert.Equal(t, map[string]interface{}{
 		"gauge": float64(1),
 	}, metrics[0].Fields())
+	assert.Equal(t, telegraf.Gauge, metrics[0].Type())
 	assert.Equal(t, map[string]string{
 		"osVersion":     "CentOS Linux 7 (Core)",
 		"dockerVersion": "1.8.2",
@@ -124,6 +127,7 @@ func TestParseValidPrometheus(t *testing.T) {
 	assert.Equal(t, map[string]interface{}{
 		"counter": float64(0),
 	}, metrics[0].Fields())
+	assert.Equal(t, telegraf.Counter, metrics[0].Type())
 	assert.Equal(t, map[string]string{}, metrics[0].Tags())
 
 	// Summary data
@@ -140,6 +144,7 @@ func TestParseValidPrometheus(t *testing.T) {
 		"sum":   1.8909097205e+07,
 	}, metrics[0].Fields())
 	assert.Equal(t, map[string]string{"handler": "prometheus"}, metrics[0].Tags())
+	assert.Equal(t, telegraf.Summary, metrics[0].Type())
 
 	// histogram data
 	metrics, err = Parse([]byte(validUniqueHistogram), http.Header{})
@@ -161,5 +166,23 @@ func TestParseValidPrometheus(t *testing.T) {
 	assert.Equal(t,
 		map[string]string{"verb": "POST", "resource": "bindings"},
 		metrics[0].Tags())
+	assert.Equal(t, telegraf.Histogram, metrics[0].Type())
+
+}
+
+func TestParserDefaultTags(t *testing.T) {
+	parser := Parser{DefaultTags: map[string]string{"host": "server01", "handler": "default"}}
+	metrics, err := parser.Parse([]byte(validUniqueSummary))
+	assert.NoError(t, err)
+	assert.Len(t, metrics, 1)
+	assert.Equal(t, map[string]string{"host": "server01", "handler": "prometheus"}, metrics[0].Tags())
+
+	m, err := parser.ParseLine(`go_goroutines{host="server02"} 15`)
+	assert.NoError(t, err)
+	assert.Equal(t, "go_goroutines", m.Name())
+	assert.Equal(t, map[string]interface{}{"value": 15.0}, m.Fields())
+	assert.Equal(t, map[string]string{"host": "server02", "handler": "default"}, m.Tags())
 
+	_, err = parser.ParseLine(`# HELP go_goroutines Number of goroutines`)
+	assert.Error(t, err)
 }
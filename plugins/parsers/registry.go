@@ -6,10 +6,14 @@ import (
 	"github.com/influxdata/telegraf"
 
 	"github.com/influxdata/telegraf/plugins/parsers/collectd"
+	"github.com/influxdata/telegraf/plugins/parsers/csv"
+	"github.com/influxdata/telegraf/plugins/parsers/dropwizard"
 	"github.com/influxdata/telegraf/plugins/parsers/graphite"
+	"github.com/influxdata/telegraf/plugins/parsers/grok"
 	"github.com/influxdata/telegraf/plugins/parsers/influx"
 	"github.com/influxdata/telegraf/plugins/parsers/json"
 	"github.com/influxdata/telegraf/plugins/parsers/nagios"
+	"github.com/influxdata/telegraf/plugins/parsers/prometheus"
 	"github.com/influxdata/telegraf/plugins/parsers/value"
 )
 
@@ -41,17 +45,77 @@ type Parser interface {
 // Config is a struct that covers the data types needed for all parser types,
 // and can be used to instantiate _any_ of the parsers.
 type Config struct {
-	// Dataformat can be one of: json, influx, graphite, value, nagios
+	// Dataformat can be one of: json, influx, graphite, value, nagios, collectd, csv, grok,
+	// dropwizard, prometheus
 	DataFormat string
 
-	// Separator only applied to Graphite data.
+	// Separator only applied to Graphite & Dropwizard data.
 	Separator string
-	// Templates only apply to Graphite data.
+	// Templates only apply to Graphite & Dropwizard data.
 	Templates []string
 
 	// TagKeys only apply to JSON data
 	TagKeys []string
-	// MetricName applies to JSON & value. This will be the name of the measurement.
+	// JSONStringFields are the string values of JSON data kept as fields
+	JSONStringFields []string
+	// JSONQuery is the path of the object or array of JSON data to parse
+	JSONQuery string
+	// JSONNameKey is the key of the measurement name in JSON data
+	JSONNameKey string
+	// JSONTimeKey is the key of the timestamp in JSON data
+	JSONTimeKey string
+	// JSONTimeFormat is the format of the timestamp, one of unix, unix_ms,
+	// unix_us, unix_ns, RFC3339 or a Go time layout
+	JSONTimeFormat string
+
+	// CSVHeaderRowCount is the number of header rows of CSV data
+	CSVHeaderRowCount int
+	// CSVSkipRows is the number of rows skipped before the header of CSV data
+	CSVSkipRows int
+	// CSVSkipColumns is the number of columns skipped on the left of CSV data
+	CSVSkipColumns int
+	// CSVDelimiter is the character separating the columns of CSV data
+	CSVDelimiter string
+	// CSVComment is the character starting the comment rows of CSV data
+	CSVComment string
+	// CSVTrimSpace trims the leading and trailing spaces of the CSV values
+	CSVTrimSpace bool
+	// CSVColumnNames are the names of the columns of CSV data
+	CSVColumnNames []string
+	// CSVColumnTypes are the types of the columns of CSV data
+	CSVColumnTypes []string
+	// CSVTagColumns are the columns of CSV data added as tags
+	CSVTagColumns []string
+	// CSVMeasurementColumn is the column of the measurement name in CSV data
+	CSVMeasurementColumn string
+	// CSVTimestampColumn is the column of the timestamp in CSV data
+	CSVTimestampColumn string
+	// CSVTimestampFormat is the format of the timestamp, one of unix,
+	// unix_ms, unix_us, unix_ns, RFC3339 or a Go time layout
+	CSVTimestampFormat string
+
+	// GrokPatterns are the patterns matched against grok data
+	GrokPatterns []string
+	// GrokCustomPatterns are custom patterns, one per line, for grok data
+	GrokCustomPatterns string
+	// GrokCustomPatternFiles are files of custom patterns for grok data
+	GrokCustomPatternFiles []string
+	// GrokTimezone is the timezone of the timestamps of grok data without
+	// offset: Local, UTC or a zone name such as America/Chicago
+	GrokTimezone string
+
+	// DropwizardMetricRegistryPath is the path of the metric registry in
+	// Dropwizard data
+	DropwizardMetricRegistryPath string
+	// DropwizardTimePath is the path of the timestamp in Dropwizard data
+	DropwizardTimePath string
+	// DropwizardTimeFormat is the format of the timestamp, one of unix,
+	// unix_ms, unix_us, unix_ns, RFC3339 or a Go time layout
+	DropwizardTimeFormat string
+	// DropwizardTagsPath is the path of the tags in Dropwizard data
+	DropwizardTagsPath string
+
+	// MetricName applies to JSON, CSV, grok & value. This will be the name of the measurement.
 	MetricName string
 
 	// Authentication file for collectd
@@ -74,8 +138,7 @@ func NewParser(config *Config) (Parser, error) {
 	var parser Parser
 	switch config.DataFormat {
 	case "json":
-		parser, err = NewJSONParser(config.MetricName,
-			config.TagKeys, config.DefaultTags)
+		parser, err = newJSONParser(config)
 	case "value":
 		parser, err = NewValueParser(config.MetricName,
 			config.DataType, config.DefaultTags)
@@ -89,6 +152,14 @@ func NewParser(config *Config) (Parser, error) {
 	case "collectd":
 		parser, err = NewCollectdParser(config.CollectdAuthFile,
 			config.CollectdSecurityLevel, config.CollectdTypesDB)
+	case "csv":
+		parser, err = newCSVParser(config)
+	case "grok":
+		parser, err = newGrokParser(config)
+	case "dropwizard":
+		parser, err = newDropwizardParser(config)
+	case "prometheus":
+		parser, err = NewPrometheusParser(config.DefaultTags)
 	default:
 		err = fmt.Errorf("Invalid data format: %s", config.DataFormat)
 	}
@@ -108,6 +179,71 @@ func NewJSONParser(
 	return parser, nil
 }
 
+func newJSONParser(config *Config) (Parser, error) {
+	parser := &json.JSONParser{
+		MetricName:   config.MetricName,
+		TagKeys:      config.TagKeys,
+		StringFields: config.JSONStringFields,
+		Query:        config.JSONQuery,
+		NameKey:      config.JSONNameKey,
+		TimeKey:      config.JSONTimeKey,
+		TimeFormat:   config.JSONTimeFormat,
+		DefaultTags:  config.DefaultTags,
+	}
+	return parser, nil
+}
+
+func newCSVParser(config *Config) (Parser, error) {
+	parser := &csv.Parser{
+		MetricName:        config.MetricName,
+		HeaderRowCount:    config.CSVHeaderRowCount,
+		SkipRows:          config.CSVSkipRows,
+		SkipColumns:       config.CSVSkipColumns,
+		Delimiter:         config.CSVDelimiter,
+		Comment:           config.CSVComment,
+		TrimSpace:         config.CSVTrimSpace,
+		ColumnNames:       config.CSVColumnNames,
+		ColumnTypes:       config.CSVColumnTypes,
+		TagColumns:        config.CSVTagColumns,
+		MeasurementColumn: config.CSVMeasurementColumn,
+		TimestampColumn:   config.CSVTimestampColumn,
+		TimestampFormat:   config.CSVTimestampFormat,
+		DefaultTags:       config.DefaultTags,
+	}
+	return parser, nil
+}
+
+func newGrokParser(config *Config) (Parser, error) {
+	parser := &grok.Parser{
+		Measurement:        config.MetricName,
+		Patterns:           config.GrokPatterns,
+		CustomPatterns:     config.GrokCustomPatterns,
+		CustomPatternFiles: config.GrokCustomPatternFiles,
+		Timezone:           config.GrokTimezone,
+		DefaultTags:        config.DefaultTags,
+	}
+	err := parser.Compile()
+	return parser, err
+}
+
+func newDropwizardParser(config *Config) (Parser, error) {
+	parser := &dropwizard.Parser{
+		MetricRegistryPath: config.DropwizardMetricRegistryPath,
+		TimePath:           config.DropwizardTimePath,
+		TimeFormat:         config.DropwizardTimeFormat,
+		TagsPath:           config.DropwizardTagsPath,
+		Separator:          config.Separator,
+		Templates:          config.Templates,
+		DefaultTags:        config.DefaultTags,
+	}
+	err := parser.Compile()
+	return parser, err
+}
+
+func NewPrometheusParser(defaultTags map[string]string) (Parser, error) {
+	return &prometheus.Parser{DefaultTags: defaultTags}, nil
+}
+
 func NewNagiosParser() (Parser, error) {
 	return &nagios.NagiosParser{}, nil
 }
@@ -0,0 +1,387 @@
+package converter
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+var sampleConfig = `
+  ## Tags to convert
+  ##
+  ## The table key determines the target type, and the array of key-values
+  ## select the keys to convert.  The array may contain globs.
+  ##   <target-type> = [<tag-key>...]
+  [processors.converter.tags]
+    measurement = []
+    string = []
+    integer = []
+    unsigned = []
+    boolean = []
+    float = []
+
+  ## Fields to convert
+  ##
+  ## The table key determines the target type, and the array of key-values
+  ## select the keys to convert.  The array may contain globs.
+  ##   <target-type> = [<field-key>...]
+  [processors.converter.fields]
+    measurement = []
+    tag = []
+    string = []
+    integer = []
+    unsigned = []
+    boolean = []
+    float = []
+`
+
+// Conversion lists the keys to convert for each target type.
+type Conversion struct {
+	Measurement []string `toml:"measurement"`
+	Tag         []string `toml:"tag"`
+	String      []string `toml:"string"`
+	Integer     []string `toml:"integer"`
+	Unsigned    []string `toml:"unsigned"`
+	Boolean     []string `toml:"boolean"`
+	Float       []string `toml:"float"`
+}
+
+type Converter struct {
+	Tags   *Conversion `toml:"tags"`
+	Fields *Conversion `toml:"fields"`
+
+	tagConversions   *conversionFilter
+	fieldConversions *conversionFilter
+}
+
+type conversionFilter struct {
+	Measurement filter.Filter
+	Tag         filter.Filter
+	String      filter.Filter
+	Integer     filter.Filter
+	Unsigned    filter.Filter
+	Boolean     filter.Filter
+	Float       filter.Filter
+}
+
+func (p *Converter) SampleConfig() string {
+	return sampleConfig
+}
+
+func (p *Converter) Description() string {
+	return "Convert values to another metric value type"
+}
+
+// Init compiles the conversions, so that an invalid configuration fails to
+// load.
+func (p *Converter) Init() error {
+	return p.compile()
+}
+
+func (p *Converter) Apply(metrics ...telegraf.Metric) []telegraf.Metric {
+	out := metrics[:0]
+	for _, metric := range metrics {
+		p.convertTags(metric)
+		if !p.convertFields(metric) {
+			// a metric without fields cannot be written.
+			log.Printf("D! [processors.converter] Dropping metric %s with no fields left",
+				metric.Name())
+			continue
+		}
+		out = append(out, metric)
+	}
+	return out
+}
+
+func (p *Converter) compile() error {
+	tf, err := compileFilter(p.Tags)
+	if err != nil {
+		return err
+	}
+
+	ff, err := compileFilter(p.Fields)
+	if err != nil {
+		return err
+	}
+
+	if tf == nil && ff == nil {
+		return fmt.Errorf("no filters found")
+	}
+
+	p.tagConversions = tf
+	p.fieldConversions = ff
+	return nil
+}
+
+func compileFilter(conv *Conversion) (*conversionFilter, error) {
+	if conv == nil {
+		return nil, nil
+	}
+
+	var err error
+	cf := &conversionFilter{}
+	for _, f := range []struct {
+		filter *filter.Filter
+		keys   []string
+		name   string
+	}{
+		{&cf.Measurement, conv.Measurement, "measurement"},
+		{&cf.Tag, conv.Tag, "tag"},
+		{&cf.String, conv.String, "string"},
+		{&cf.Integer, conv.Integer, "integer"},
+		{&cf.Unsigned, conv.Unsigned, "unsigned"},
+		{&cf.Boolean, conv.Boolean, "boolean"},
+		{&cf.Float, conv.Float, "float"},
+	} {
+		*f.filter, err = filter.Compile(f.keys)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s filter: %s", f.name, err)
+		}
+	}
+	return cf, nil
+}
+
+func match(f filter.Filter, key string) bool {
+	return f != nil && f.Match(key)
+}
+
+// convertTags converts tags into the measurement name or fields.
+func (p *Converter) convertTags(metric telegraf.Metric) {
+	cf := p.tagConversions
+	if cf == nil {
+		return
+	}
+
+	for key, value := range metric.Tags() {
+		var v interface{}
+		var ok bool
+		switch {
+		case match(cf.Measurement, key):
+			metric.RemoveTag(key)
+			metric.SetName(value)
+			continue
+		case match(cf.String, key):
+			v, ok = value, true
+		case match(cf.Integer, key):
+			v, ok = toInteger(value)
+		case match(cf.Unsigned, key):
+			v, ok = toUnsigned(value)
+		case match(cf.Boolean, key):
+			v, ok = toBool(value)
+		case match(cf.Float, key):
+			v, ok = toFloat(value)
+		default:
+			continue
+		}
+
+		metric.RemoveTag(key)
+		if !ok {
+			logConversionError(metric, "tag", key, value)
+			continue
+		}
+		metric.AddField(key, v)
+	}
+}
+
+// convertFields converts fields into the measurement name, tags, or another
+// field type. It returns false if no fields are left in the metric.
+func (p *Converter) convertFields(metric telegraf.Metric) bool {
+	cf := p.fieldConversions
+	if cf == nil {
+		return true
+	}
+
+	for key, value := range metric.Fields() {
+		var v interface{}
+		var ok bool
+		switch {
+		case match(cf.Measurement, key):
+			if s, ok := toString(value); ok {
+				metric.SetName(s)
+			} else {
+				logConversionError(metric, "field", key, value)
+			}
+			if err := metric.RemoveField(key); err != nil {
+				return false
+			}
+			continue
+		case match(cf.Tag, key):
+			if s, ok := toString(value); ok {
+				metric.AddTag(key, s)
+			} else {
+				logConversionError(metric, "field", key, value)
+			}
+			if err := metric.RemoveField(key); err != nil {
+				return false
+			}
+			continue
+		case match(cf.String, key):
+			v, ok = toString(value)
+		case match(cf.Integer, key):
+			v, ok = toInteger(value)
+		case match(cf.Unsigned, key):
+			v, ok = toUnsigned(value)
+		case match(cf.Boolean, key):
+			v, ok = toBool(value)
+		case match(cf.Float, key):
+			v, ok = toFloat(value)
+		default:
+			continue
+		}
+
+		if !ok {
+			logConversionError(metric, "field", key, value)
+			if err := metric.RemoveField(key); err != nil {
+				return false
+			}
+			continue
+		}
+		metric.AddField(key, v)
+	}
+	return true
+}
+
+func logConversionError(metric telegraf.Metric, kind, key string, value interface{}) {
+	log.Printf("D! [processors.converter] Dropping %s %s of %s, could not convert %T %v",
+		kind, key, metric.Name(), value, value)
+}
+
+func toBool(v interface{}) (bool, bool) {
+	switch value := v.(type) {
+	case int64:
+		return value != 0, true
+	case uint64:
+		return value != 0, true
+	case float64:
+		return value != 0, true
+	case bool:
+		return value, true
+	case string:
+		result, err := strconv.ParseBool(value)
+		return result, err == nil
+	}
+	return false, false
+}
+
+func toInteger(v interface{}) (int64, bool) {
+	switch value := v.(type) {
+	case int64:
+		return value, true
+	case uint64:
+		if value <= uint64(math.MaxInt64) {
+			return int64(value), true
+		}
+		return math.MaxInt64, true
+	case float64:
+		if math.IsNaN(value) {
+			return 0, false
+		}
+		if value < float64(math.MinInt64) {
+			return math.MinInt64, true
+		}
+		if value >= float64(math.MaxInt64) {
+			return math.MaxInt64, true
+		}
+		return int64(value), true
+	case bool:
+		if value {
+			return 1, true
+		}
+		return 0, true
+	case string:
+		result, err := strconv.ParseInt(value, 0, 64)
+		if err != nil {
+			// try to parse as a float, to accept values like "4.0".
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return 0, false
+			}
+			return toInteger(f)
+		}
+		return result, true
+	}
+	return 0, false
+}
+
+func toUnsigned(v interface{}) (uint64, bool) {
+	switch value := v.(type) {
+	case uint64:
+		return value, true
+	case int64:
+		if value < 0 {
+			return 0, false
+		}
+		return uint64(value), true
+	case float64:
+		if math.IsNaN(value) || value < 0 {
+			return 0, false
+		}
+		if value >= float64(math.MaxUint64) {
+			return math.MaxUint64, true
+		}
+		return uint64(value), true
+	case bool:
+		if value {
+			return 1, true
+		}
+		return 0, true
+	case string:
+		result, err := strconv.ParseUint(value, 0, 64)
+		if err != nil {
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return 0, false
+			}
+			return toUnsigned(f)
+		}
+		return result, true
+	}
+	return 0, false
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch value := v.(type) {
+	case int64:
+		return float64(value), true
+	case uint64:
+		return float64(value), true
+	case float64:
+		return value, true
+	case bool:
+		if value {
+			return 1.0, true
+		}
+		return 0.0, true
+	case string:
+		result, err := strconv.ParseFloat(value, 64)
+		return result, err == nil
+	}
+	return 0.0, false
+}
+
+func toString(v interface{}) (string, bool) {
+	switch value := v.(type) {
+	case int64:
+		return strconv.FormatInt(value, 10), true
+	case uint64:
+		return strconv.FormatUint(value, 10), true
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(value), true
+	case string:
+		return value, true
+	}
+	return "", false
+}
+
+func init() {
+	processors.Add("converter", func() telegraf.Processor {
+		return &Converter{}
+	})
+}
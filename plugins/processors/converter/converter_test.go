@@ -0,0 +1,487 @@
+package converter
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Metric(v telegraf.Metric, err error) telegraf.Metric {
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func TestConverter(t *testing.T) {
+	tests := []struct {
+		name      string
+		converter *Converter
+		input     telegraf.Metric
+		expected  telegraf.Metric
+	}{
+		{
+			name: "from tag",
+			converter: &Converter{
+				Tags: &Conversion{
+					String:   []string{"string"},
+					Integer:  []string{"int"},
+					Unsigned: []string{"uint"},
+					Boolean:  []string{"bool"},
+					Float:    []string{"float"},
+					Tag:      []string{"tag"},
+				},
+			},
+			input: Metric(
+				metric.New(
+					"cpu",
+					map[string]string{
+						"float":  "42",
+						"int":    "42",
+						"uint":   "42",
+						"bool":   "true",
+						"string": "howdy",
+						"tag":    "tag",
+					},
+					map[string]interface{}{
+						"value": 42.0,
+					},
+					time.Unix(0, 0),
+				),
+			),
+			expected: Metric(
+				metric.New(
+					"cpu",
+					map[string]string{
+						"tag": "tag",
+					},
+					map[string]interface{}{
+						"value":  42.0,
+						"float":  42.0,
+						"int":    int64(42),
+						"uint":   int64(42),
+						"bool":   true,
+						"string": "howdy",
+					},
+					time.Unix(0, 0),
+				),
+			),
+		},
+		{
+			name: "from string field",
+			converter: &Converter{
+				Fields: &Conversion{
+					String:   []string{"a"},
+					Integer:  []string{"b", "b1", "b2"},
+					Unsigned: []string{"c", "c1", "c2"},
+					Boolean:  []string{"d"},
+					Float:    []string{"e"},
+					Tag:      []string{"f"},
+				},
+			},
+			input: Metric(
+				metric.New(
+					"cpu",
+					map[string]string{},
+					map[string]interface{}{
+						"a":  "howdy",
+						"b":  "42",
+						"b1": "42.2",
+						"b2": "0x2a",
+						"c":  "42",
+						"c1": "42.2",
+						"c2": "0x2a",
+						"d":  "true",
+						"e":  "42.0",
+						"f":  "foo",
+					},
+					time.Unix(0, 0),
+				),
+			),
+			expected: Metric(
+				metric.New(
+					"cpu",
+					map[string]string{
+						"f": "foo",
+					},
+					map[string]interface{}{
+						"a":  "howdy",
+						"b":  int64(42),
+						"b1": int64(42),
+						"b2": int64(42),
+						"c":  int64(42),
+						"c1": int64(42),
+						"c2": int64(42),
+						"d":  true,
+						"e":  42.0,
+					},
+					time.Unix(0, 0),
+				),
+			),
+		},
+		{
+			name: "from integer field",
+			converter: &Converter{
+				Fields: &Conversion{
+					String:   []string{"a"},
+					Integer:  []string{"b"},
+					Unsigned: []string{"c"},
+					Boolean:  []string{"d", "bool_zero"},
+					Float:    []string{"e"},
+					Tag:      []string{"f"},
+				},
+			},
+			input: Metric(
+				metric.New(
+					"cpu",
+					map[string]string{},
+					map[string]interface{}{
+						"a":         int64(42),
+						"b":         int64(42),
+						"c":         int64(42),
+						"d":         int64(42),
+						"e":         int64(42),
+						"f":         int64(42),
+						"bool_zero": int64(0),
+					},
+					time.Unix(0, 0),
+				),
+			),
+			expected: Metric(
+				metric.New(
+					"cpu",
+					map[string]string{
+						"f": "42",
+					},
+					map[string]interface{}{
+						"a":         "42",
+						"b":         int64(42),
+						"c":         int64(42),
+						"d":         true,
+						"e":         42.0,
+						"bool_zero": false,
+					},
+					time.Unix(0, 0),
+				),
+			),
+		},
+		{
+			name: "from float field",
+			converter: &Converter{
+				Fields: &Conversion{
+					String:   []string{"a"},
+					Integer:  []string{"b", "too_large_int", "too_small_int"},
+					Unsigned: []string{"c"},
+					Boolean:  []string{"d"},
+					Float:    []string{"e"},
+					Tag:      []string{"f"},
+				},
+			},
+			input: Metric(
+				metric.New(
+					"cpu",
+					map[string]string{},
+					map[string]interface{}{
+						"a":             42.5,
+						"b":             42.5,
+						"c":             42.5,
+						"d":             42.5,
+						"e":             42.5,
+						"f":             42.5,
+						"too_large_int": math.MaxFloat64,
+						"too_small_int": -math.MaxFloat64,
+					},
+					time.Unix(0, 0),
+				),
+			),
+			expected: Metric(
+				metric.New(
+					"cpu",
+					map[string]string{
+						"f": "42.5",
+					},
+					map[string]interface{}{
+						"a":             "42.5",
+						"b":             int64(42),
+						"c":             int64(42),
+						"d":             true,
+						"e":             42.5,
+						"too_large_int": int64(math.MaxInt64),
+						"too_small_int": int64(math.MinInt64),
+					},
+					time.Unix(0, 0),
+				),
+			),
+		},
+		{
+			name: "from bool field",
+			converter: &Converter{
+				Fields: &Conversion{
+					String:   []string{"a", "af"},
+					Integer:  []string{"b", "bf"},
+					Unsigned: []string{"c", "cf"},
+					Boolean:  []string{"d", "df"},
+					Float:    []string{"e", "ef"},
+					Tag:      []string{"f", "ff"},
+				},
+			},
+			input: Metric(
+				metric.New(
+					"cpu",
+					map[string]string{},
+					map[string]interface{}{
+						"a":  true,
+						"b":  true,
+						"c":  true,
+						"d":  true,
+						"e":  true,
+						"f":  true,
+						"af": false,
+						"bf": false,
+						"cf": false,
+						"df": false,
+						"ef": false,
+						"ff": false,
+					},
+					time.Unix(0, 0),
+				),
+			),
+			expected: Metric(
+				metric.New(
+					"cpu",
+					map[string]string{
+						"f":  "true",
+						"ff": "false",
+					},
+					map[string]interface{}{
+						"a":  "true",
+						"af": "false",
+						"b":  int64(1),
+						"bf": int64(0),
+						"c":  int64(1),
+						"cf": int64(0),
+						"d":  true,
+						"df": false,
+						"e":  1.0,
+						"ef": 0.0,
+					},
+					time.Unix(0, 0),
+				),
+			),
+		},
+		{
+			name: "out of range for unsigned",
+			converter: &Converter{
+				Fields: &Conversion{
+					Unsigned: []string{"a", "b"},
+				},
+			},
+			input: Metric(
+				metric.New(
+					"cpu",
+					map[string]string{},
+					map[string]interface{}{
+						"a":     int64(-42),
+						"b":     -42.0,
+						"value": 42.0,
+					},
+					time.Unix(0, 0),
+				),
+			),
+			expected: Metric(
+				metric.New(
+					"cpu",
+					map[string]string{},
+					map[string]interface{}{
+						"value": 42.0,
+					},
+					time.Unix(0, 0),
+				),
+			),
+		},
+		{
+			name: "invalid values are dropped",
+			converter: &Converter{
+				Tags: &Conversion{
+					Integer: []string{"a"},
+				},
+				Fields: &Conversion{
+					Integer: []string{"b"},
+					Boolean: []string{"c"},
+					Float:   []string{"d"},
+				},
+			},
+			input: Metric(
+				metric.New(
+					"cpu",
+					map[string]string{
+						"a":    "howdy",
+						"host": "localhost",
+					},
+					map[string]interface{}{
+						"b":     "howdy",
+						"c":     "howdy",
+						"d":     "howdy",
+						"value": 42.0,
+					},
+					time.Unix(0, 0),
+				),
+			),
+			expected: Metric(
+				metric.New(
+					"cpu",
+					map[string]string{
+						"host": "localhost",
+					},
+					map[string]interface{}{
+						"value": 42.0,
+					},
+					time.Unix(0, 0),
+				),
+			),
+		},
+		{
+			name: "globbing",
+			converter: &Converter{
+				Fields: &Conversion{
+					Integer: []string{"int_*"},
+				},
+			},
+			input: Metric(
+				metric.New(
+					"cpu",
+					map[string]string{},
+					map[string]interface{}{
+						"int_a":   "1",
+						"int_b":   "2",
+						"float_a": 1.0,
+					},
+					time.Unix(0, 0),
+				),
+			),
+			expected: Metric(
+				metric.New(
+					"cpu",
+					map[string]string{},
+					map[string]interface{}{
+						"int_a":   int64(1),
+						"int_b":   int64(2),
+						"float_a": 1.0,
+					},
+					time.Unix(0, 0),
+				),
+			),
+		},
+		{
+			name: "from tag to measurement",
+			converter: &Converter{
+				Tags: &Conversion{
+					Measurement: []string{"region"},
+				},
+			},
+			input: Metric(
+				metric.New(
+					"cpu",
+					map[string]string{
+						"region": "eu",
+						"host":   "localhost",
+					},
+					map[string]interface{}{
+						"value": 42.0,
+					},
+					time.Unix(0, 0),
+				),
+			),
+			expected: Metric(
+				metric.New(
+					"eu",
+					map[string]string{
+						"host": "localhost",
+					},
+					map[string]interface{}{
+						"value": 42.0,
+					},
+					time.Unix(0, 0),
+				),
+			),
+		},
+		{
+			name: "from field to measurement",
+			converter: &Converter{
+				Fields: &Conversion{
+					Measurement: []string{"name"},
+				},
+			},
+			input: Metric(
+				metric.New(
+					"cpu",
+					map[string]string{},
+					map[string]interface{}{
+						"name":  "usage",
+						"value": 42.0,
+					},
+					time.Unix(0, 0),
+				),
+			),
+			expected: Metric(
+				metric.New(
+					"usage",
+					map[string]string{},
+					map[string]interface{}{
+						"value": 42.0,
+					},
+					time.Unix(0, 0),
+				),
+			),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, tt.converter.Init())
+			metrics := tt.converter.Apply(tt.input)
+
+			require.Len(t, metrics, 1)
+			assert.Equal(t, tt.expected.Name(), metrics[0].Name())
+			assert.Equal(t, tt.expected.Tags(), metrics[0].Tags())
+			assert.Equal(t, tt.expected.Fields(), metrics[0].Fields())
+			assert.Equal(t, tt.expected.Time(), metrics[0].Time())
+		})
+	}
+}
+
+func TestNoFieldsLeft(t *testing.T) {
+	converter := &Converter{
+		Fields: &Conversion{
+			Tag: []string{"value"},
+		},
+	}
+	require.NoError(t, converter.Init())
+
+	m := Metric(metric.New("cpu",
+		map[string]string{},
+		map[string]interface{}{"value": "42"},
+		time.Unix(0, 0),
+	))
+	metrics := converter.Apply(m)
+	assert.Len(t, metrics, 0)
+}
+
+func TestNoConversions(t *testing.T) {
+	converter := &Converter{}
+	assert.Error(t, converter.Init())
+}
+
+func TestInvalidFilter(t *testing.T) {
+	converter := &Converter{
+		Fields: &Conversion{
+			Integer: []string{"[a"},
+		},
+	}
+	assert.Error(t, converter.Init())
+}
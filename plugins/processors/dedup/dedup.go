@@ -0,0 +1,138 @@
+package dedup
+
+import (
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+var sampleConfig = `
+  ## Maximum time to suppress unchanged metrics of a series, a metric is
+  ## emitted at least once per interval even if its fields did not change
+  # dedup_interval = "10m"
+
+  ## Time after which the state of a series that was not seen is forgotten,
+  ## the next metric of the series is then emitted.  Defaults to the
+  ## dedup_interval.
+  # expiry = "10m"
+`
+
+type Dedup struct {
+	DedupInterval internal.Duration `toml:"dedup_interval"`
+	Expiry        internal.Duration
+
+	series      map[uint64]*series
+	nextCleanup time.Time
+	// now returns the current time.
+	now func() time.Time
+}
+
+// series is the state of a series: the fields and time of the last metric
+// emitted, and when the series was last seen.
+type series struct {
+	fields   map[string]interface{}
+	emitted  time.Time
+	lastSeen time.Time
+}
+
+func New() *Dedup {
+	return &Dedup{
+		DedupInterval: internal.Duration{Duration: 10 * time.Minute},
+		series:        make(map[uint64]*series),
+		now:           time.Now,
+	}
+}
+
+func (d *Dedup) SampleConfig() string {
+	return sampleConfig
+}
+
+func (d *Dedup) Description() string {
+	return "Drop metrics whose fields did not change since the last one of the series."
+}
+
+func (d *Dedup) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	out := in[:0]
+	for _, m := range in {
+		if d.suppress(m) {
+			continue
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+// suppress returns true if the fields of the metric are identical to the last
+// metric emitted for its series, during the dedup interval.
+func (d *Dedup) suppress(m telegraf.Metric) bool {
+	if d.series == nil {
+		d.series = make(map[uint64]*series)
+	}
+
+	// the series expire using the clock, so that series whose metrics have
+	// old timestamps are not kept forever.
+	now := d.now()
+	d.cleanup(now)
+
+	id := m.HashID()
+	fields := m.Fields()
+	s, ok := d.series[id]
+	if ok && now.Sub(s.lastSeen) < d.expiry() {
+		s.lastSeen = now
+		// the metrics older than the last metric emitted are emitted, they
+		// do not change the state of the series.
+		if m.Time().Before(s.emitted) {
+			return false
+		}
+		// the dedup interval is measured using the times of the metrics so
+		// that metrics buffered by the inputs are deduplicated consistently.
+		if m.Time().Sub(s.emitted) < d.DedupInterval.Duration && sameFields(s.fields, fields) {
+			return true
+		}
+	}
+
+	d.series[id] = &series{fields: fields, emitted: m.Time(), lastSeen: now}
+	return false
+}
+
+// cleanup forgets the series that expired, at most once per expiry.
+func (d *Dedup) cleanup(now time.Time) {
+	if now.Before(d.nextCleanup) {
+		return
+	}
+	expiry := d.expiry()
+	d.nextCleanup = now.Add(expiry)
+
+	for id, s := range d.series {
+		if now.Sub(s.lastSeen) >= expiry {
+			delete(d.series, id)
+		}
+	}
+}
+
+func (d *Dedup) expiry() time.Duration {
+	if d.Expiry.Duration > 0 {
+		return d.Expiry.Duration
+	}
+	return d.DedupInterval.Duration
+}
+
+func sameFields(a, b map[string]interface{}) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if w, ok := b[k]; !ok || v != w {
+			return false
+		}
+	}
+	return true
+}
+
+func init() {
+	processors.Add("dedup", func() telegraf.Processor {
+		return New()
+	})
+}
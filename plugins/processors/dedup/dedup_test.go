@@ -0,0 +1,112 @@
+package dedup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/stretchr/testify/assert"
+)
+
+var epoch = time.Unix(1514764800, 0)
+
+func newMetric(tags map[string]string, value interface{}, offset time.Duration) telegraf.Metric {
+	m, err := metric.New("filestat", tags, map[string]interface{}{"size": value}, epoch.Add(offset))
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+func TestDedup(t *testing.T) {
+	dedup := New()
+	dedup.DedupInterval = internal.Duration{Duration: time.Minute}
+	tags := map[string]string{"file": "/var/log/syslog"}
+
+	tests := []struct {
+		name    string
+		value   interface{}
+		offset  time.Duration
+		emitted bool
+	}{
+		{"first metric", int64(42), 0, true},
+		{"unchanged", int64(42), 10 * time.Second, false},
+		{"changed", int64(43), 20 * time.Second, true},
+		{"changed type", 43.0, 30 * time.Second, true},
+		{"unchanged again", 43.0, 40 * time.Second, false},
+		{"heartbeat", 43.0, 90 * time.Second, true},
+		{"after heartbeat", 43.0, 100 * time.Second, false},
+	}
+
+	for _, tt := range tests {
+		out := dedup.Apply(newMetric(tags, tt.value, tt.offset))
+		assert.Equal(t, tt.emitted, len(out) == 1, tt.name)
+	}
+}
+
+func TestDedupSeries(t *testing.T) {
+	dedup := New()
+
+	out := dedup.Apply(
+		newMetric(map[string]string{"file": "a"}, int64(1), 0),
+		newMetric(map[string]string{"file": "b"}, int64(1), 0),
+		newMetric(map[string]string{"file": "a"}, int64(1), time.Second),
+		newMetric(map[string]string{"file": "b"}, int64(2), time.Second),
+	)
+	assert.Len(t, out, 3)
+	assert.Equal(t, map[string]interface{}{"size": int64(2)}, out[2].Fields())
+}
+
+func TestDedupExpiry(t *testing.T) {
+	dedup := New()
+	dedup.Expiry = internal.Duration{Duration: time.Minute}
+	now := time.Now()
+	dedup.now = func() time.Time {
+		return now
+	}
+
+	a := map[string]string{"file": "a"}
+	b := map[string]string{"file": "b"}
+	assert.Len(t, dedup.Apply(newMetric(a, int64(1), 0)), 1)
+	assert.Len(t, dedup.Apply(newMetric(b, int64(1), 0)), 1)
+	now = now.Add(50 * time.Second)
+	assert.Len(t, dedup.Apply(newMetric(a, int64(1), 10*time.Second)), 0)
+
+	// b expired and was removed, a was seen recently enough to be kept. The
+	// times of the metrics are not used for the expiry.
+	now = now.Add(20 * time.Second)
+	assert.Len(t, dedup.Apply(newMetric(b, int64(1), 0)), 1)
+	assert.Len(t, dedup.series, 2)
+	now = now.Add(30 * time.Second)
+	assert.Len(t, dedup.Apply(newMetric(a, int64(1), 20*time.Second)), 0)
+
+	now = now.Add(100 * time.Second)
+	assert.Len(t, dedup.Apply(newMetric(b, int64(1), 0)), 1)
+	assert.Len(t, dedup.series, 1)
+}
+
+func TestDedupOutOfOrder(t *testing.T) {
+	dedup := New()
+	dedup.DedupInterval = internal.Duration{Duration: time.Minute}
+	tags := map[string]string{"file": "/var/log/syslog"}
+
+	tests := []struct {
+		name    string
+		value   interface{}
+		offset  time.Duration
+		emitted bool
+	}{
+		{"first metric", int64(42), 0, true},
+		{"older unchanged", int64(42), -10 * time.Second, true},
+		{"older changed", int64(43), -20 * time.Second, true},
+		{"unchanged", int64(42), 10 * time.Second, false},
+		{"heartbeat", int64(42), 70 * time.Second, true},
+	}
+
+	for _, tt := range tests {
+		out := dedup.Apply(newMetric(tags, tt.value, tt.offset))
+		assert.Equal(t, tt.emitted, len(out) == 1, tt.name)
+	}
+}
@@ -0,0 +1,228 @@
+package enum
+
+import (
+	"encoding/csv"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+var sampleConfig = `
+  [[processors.enum.mapping]]
+    ## Name of the field to map
+    field = "status"
+
+    ## Name of the tag to map, instead of a field
+    # tag = "status"
+
+    ## Destination field or tag to be used for the mapped value.  By default
+    ## the source field or tag is used, overwriting the original value.
+    # dest = "status_code"
+
+    ## Default value to be used for all values not contained in the mapping
+    ## table.  When unset, the unmodified value for the field will be used if
+    ## no match is found.
+    # default = 0
+
+    ## CSV file of mappings, with the value to map in the first column and
+    ## the mapped value in the second one.  The file is reloaded when it
+    ## changes, its mappings take precedence over the value_mappings table.
+    # file = "/etc/telegraf/status_codes.csv"
+
+    ## Table of mappings
+    [processors.enum.mapping.value_mappings]
+      green = 1
+      amber = 2
+      red = 3
+`
+
+// fileCheckInterval is how often the mapping files are checked for changes.
+var fileCheckInterval = 10 * time.Second
+
+type EnumMapper struct {
+	Mappings []*Mapping `toml:"mapping"`
+}
+
+type Mapping struct {
+	Tag           string
+	Field         string
+	Dest          string
+	Default       interface{}
+	File          string
+	ValueMappings map[string]interface{} `toml:"value_mappings"`
+
+	fileMappings map[string]interface{}
+	modTime      time.Time
+	lastCheck    time.Time
+}
+
+func (mapper *EnumMapper) SampleConfig() string {
+	return sampleConfig
+}
+
+func (mapper *EnumMapper) Description() string {
+	return "Map enum values according to given table."
+}
+
+func (mapper *EnumMapper) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	now := time.Now()
+	for _, mapping := range mapper.Mappings {
+		if mapping.File != "" && now.Sub(mapping.lastCheck) >= fileCheckInterval {
+			mapping.lastCheck = now
+			if err := mapping.loadFile(); err != nil {
+				log.Printf("E! [processors.enum] Error loading mappings from %s: %s",
+					mapping.File, err)
+			}
+		}
+	}
+
+	for _, metric := range in {
+		mapper.applyMappings(metric)
+	}
+	return in
+}
+
+func (mapper *EnumMapper) applyMappings(metric telegraf.Metric) {
+	for _, mapping := range mapper.Mappings {
+		if mapping.Tag != "" {
+			value, ok := metric.Tags()[mapping.Tag]
+			if !ok {
+				continue
+			}
+			if result, ok := mapping.mapValue(value); ok {
+				if s, ok := toString(result); ok {
+					metric.AddTag(mapping.destination(), s)
+				}
+			}
+			continue
+		}
+
+		if mapping.Field == "" {
+			continue
+		}
+		value, ok := metric.Fields()[mapping.Field]
+		if !ok {
+			continue
+		}
+		key, ok := toString(value)
+		if !ok {
+			continue
+		}
+		if result, ok := mapping.mapValue(key); ok {
+			metric.AddField(mapping.destination(), result)
+		}
+	}
+}
+
+// mapValue returns the mapped value, ok is false if the value is not mapped
+// and there is no default.
+func (mapping *Mapping) mapValue(value string) (interface{}, bool) {
+	if result, ok := mapping.fileMappings[value]; ok {
+		return result, true
+	}
+	if result, ok := mapping.ValueMappings[value]; ok {
+		return result, true
+	}
+	if mapping.Default != nil {
+		return mapping.Default, true
+	}
+	return nil, false
+}
+
+func (mapping *Mapping) destination() string {
+	if mapping.Dest != "" {
+		return mapping.Dest
+	}
+	if mapping.Tag != "" {
+		return mapping.Tag
+	}
+	return mapping.Field
+}
+
+// loadFile loads the mappings of the CSV file if it was modified since it was
+// last loaded. The previous mappings are kept if the file cannot be read.
+func (mapping *Mapping) loadFile() error {
+	info, err := os.Stat(mapping.File)
+	if err != nil {
+		return err
+	}
+	if mapping.fileMappings != nil && info.ModTime().Equal(mapping.modTime) {
+		return nil
+	}
+
+	f, err := os.Open(mapping.File)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = 2
+	r.Comment = '#'
+	r.TrimLeadingSpace = true
+
+	mappings := make(map[string]interface{})
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		mappings[record[0]] = parseValue(record[1])
+	}
+
+	mapping.fileMappings = mappings
+	mapping.modTime = info.ModTime()
+	log.Printf("D! [processors.enum] Loaded %d mappings from %s",
+		len(mappings), mapping.File)
+	return nil
+}
+
+// parseValue returns the mapped value of the CSV file as an integer or a float
+// if it is a number, as a boolean if it is true or false, and as a string
+// otherwise.
+func parseValue(s string) interface{} {
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f
+	}
+	switch s {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	return s
+}
+
+func toString(v interface{}) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case bool:
+		return strconv.FormatBool(v), true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case uint64:
+		return strconv.FormatUint(v, 10), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	default:
+		return "", false
+	}
+}
+
+func init() {
+	processors.Add("enum", func() telegraf.Processor {
+		return &EnumMapper{}
+	})
+}
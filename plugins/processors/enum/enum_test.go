@@ -0,0 +1,199 @@
+package enum
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/toml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createTestMetric() telegraf.Metric {
+	metric, _ := metric.New("m1",
+		map[string]string{"tag": "tag_value"},
+		map[string]interface{}{
+			"string_value": "test",
+			"int_value":    int64(13),
+			"true_value":   true,
+		},
+		time.Now(),
+	)
+	return metric
+}
+
+func calculateProcessedValues(mapper EnumMapper, metric telegraf.Metric) map[string]interface{} {
+	processed := mapper.Apply(metric)
+	return processed[0].Fields()
+}
+
+func assertFieldValue(t *testing.T, expected interface{}, field string, fields map[string]interface{}) {
+	value, present := fields[field]
+	assert.True(t, present, "value of field '"+field+"' was not present")
+	assert.EqualValues(t, expected, value)
+}
+
+func TestRetainsMetric(t *testing.T) {
+	mapper := EnumMapper{}
+	source := createTestMetric()
+
+	target := mapper.Apply(source)[0]
+	fields := target.Fields()
+
+	assertFieldValue(t, "test", "string_value", fields)
+	assertFieldValue(t, 13, "int_value", fields)
+	assertFieldValue(t, true, "true_value", fields)
+	assert.Equal(t, "m1", target.Name())
+	assert.Equal(t, source.Tags(), target.Tags())
+	assert.Equal(t, source.Time(), target.Time())
+}
+
+func TestMapsSingleStringValue(t *testing.T) {
+	mapper := EnumMapper{Mappings: []*Mapping{{Field: "string_value", ValueMappings: map[string]interface{}{"test": int64(1)}}}}
+
+	fields := calculateProcessedValues(mapper, createTestMetric())
+
+	assertFieldValue(t, 1, "string_value", fields)
+}
+
+func TestMapsNonStringValues(t *testing.T) {
+	mapper := EnumMapper{Mappings: []*Mapping{
+		{Field: "int_value", ValueMappings: map[string]interface{}{"13": "thirteen"}},
+		{Field: "true_value", ValueMappings: map[string]interface{}{"true": int64(1)}},
+	}}
+
+	fields := calculateProcessedValues(mapper, createTestMetric())
+
+	assertFieldValue(t, "thirteen", "int_value", fields)
+	assertFieldValue(t, 1, "true_value", fields)
+}
+
+func TestMapsTag(t *testing.T) {
+	mapper := EnumMapper{Mappings: []*Mapping{
+		{Tag: "tag", ValueMappings: map[string]interface{}{"tag_value": int64(7)}},
+		{Tag: "tag", Dest: "tag_name", ValueMappings: map[string]interface{}{"tag_value": "valuable"}},
+	}}
+
+	processed := mapper.Apply(createTestMetric())
+
+	// the second mapping sees the result of the first one
+	assert.Equal(t, map[string]string{"tag": "7"}, processed[0].Tags())
+
+	mapper.Mappings[0].Dest = "tag_code"
+	processed = mapper.Apply(createTestMetric())
+	assert.Equal(t,
+		map[string]string{"tag": "tag_value", "tag_code": "7", "tag_name": "valuable"},
+		processed[0].Tags())
+}
+
+func TestNoFailureOnMappingsOnNonExistingFields(t *testing.T) {
+	mapper := EnumMapper{Mappings: []*Mapping{{Field: "string_value_non_existing", ValueMappings: map[string]interface{}{"other": int64(1)}}}}
+
+	fields := calculateProcessedValues(mapper, createTestMetric())
+
+	assertFieldValue(t, "test", "string_value", fields)
+}
+
+func TestRetainsNonMappedValues(t *testing.T) {
+	mapper := EnumMapper{Mappings: []*Mapping{{Field: "string_value", ValueMappings: map[string]interface{}{"other": int64(1)}}}}
+
+	fields := calculateProcessedValues(mapper, createTestMetric())
+
+	assertFieldValue(t, "test", "string_value", fields)
+}
+
+func TestMappingWithDefaultValue(t *testing.T) {
+	mapper := EnumMapper{Mappings: []*Mapping{{Field: "string_value", Default: int64(42), ValueMappings: map[string]interface{}{"other": int64(1)}}}}
+
+	fields := calculateProcessedValues(mapper, createTestMetric())
+
+	assertFieldValue(t, 42, "string_value", fields)
+}
+
+func TestWritesToDestination(t *testing.T) {
+	mapper := EnumMapper{Mappings: []*Mapping{{Field: "string_value", Dest: "string_code", ValueMappings: map[string]interface{}{"test": int64(1)}}}}
+
+	fields := calculateProcessedValues(mapper, createTestMetric())
+
+	assertFieldValue(t, "test", "string_value", fields)
+	assertFieldValue(t, 1, "string_code", fields)
+}
+
+func TestDoNotWriteToDestinationWithoutDefaultOrDefinedMapping(t *testing.T) {
+	field := "string_code"
+	mapper := EnumMapper{Mappings: []*Mapping{{Field: "string_value", Dest: field, ValueMappings: map[string]interface{}{"other": int64(1)}}}}
+
+	fields := calculateProcessedValues(mapper, createTestMetric())
+
+	assertFieldValue(t, "test", "string_value", fields)
+	_, present := fields[field]
+	assert.False(t, present, "value of field '"+field+"' was present")
+}
+
+func TestMappingFile(t *testing.T) {
+	defer func(interval time.Duration) { fileCheckInterval = interval }(fileCheckInterval)
+	fileCheckInterval = 0
+
+	f, err := ioutil.TempFile("", "enum")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	_, err = f.WriteString("# value,mapped\ntest,2\nother,\"three, four\"\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	mapper := EnumMapper{Mappings: []*Mapping{{
+		Field:         "string_value",
+		File:          f.Name(),
+		ValueMappings: map[string]interface{}{"test": int64(1), "unused": int64(0)},
+	}}}
+
+	fields := calculateProcessedValues(mapper, createTestMetric())
+	assertFieldValue(t, 2, "string_value", fields)
+	assert.Equal(t,
+		map[string]interface{}{"test": int64(2), "other": "three, four"},
+		mapper.Mappings[0].fileMappings)
+
+	// the file is reloaded once it changes
+	require.NoError(t, ioutil.WriteFile(f.Name(), []byte("test,true\n"), 0644))
+	modTime := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(f.Name(), modTime, modTime))
+
+	fields = calculateProcessedValues(mapper, createTestMetric())
+	assertFieldValue(t, true, "string_value", fields)
+
+	// invalid files keep the previous mappings
+	require.NoError(t, ioutil.WriteFile(f.Name(), []byte("test\n"), 0644))
+	modTime = modTime.Add(time.Minute)
+	require.NoError(t, os.Chtimes(f.Name(), modTime, modTime))
+
+	fields = calculateProcessedValues(mapper, createTestMetric())
+	assertFieldValue(t, true, "string_value", fields)
+}
+
+func TestMappingConfig(t *testing.T) {
+	tbl, err := toml.Parse([]byte(`
+[[mapping]]
+  field = "string_value"
+  dest = "string_code"
+  default = 0
+  [mapping.value_mappings]
+    test = 1
+    other = 2.5
+`))
+	require.NoError(t, err)
+
+	mapper := EnumMapper{}
+	require.NoError(t, toml.UnmarshalTable(tbl, &mapper))
+	require.Len(t, mapper.Mappings, 1)
+
+	fields := calculateProcessedValues(mapper, createTestMetric())
+	assertFieldValue(t, int64(1), "string_code", fields)
+	assert.Equal(t,
+		map[string]interface{}{"test": int64(1), "other": 2.5},
+		mapper.Mappings[0].ValueMappings)
+	assert.Equal(t, int64(0), mapper.Mappings[0].Default)
+}
@@ -0,0 +1,141 @@
+package regex
+
+import (
+	"log"
+	"regexp"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+type Regex struct {
+	Tags        []converter
+	Fields      []converter
+	Measurement []converter
+
+	regexCache map[string]*regexp.Regexp
+}
+
+// converter replaces the matches of Pattern in the value of Key by
+// Replacement, which can refer to the capture groups of the pattern as ${1}.
+// The result is written to ResultKey if set, the original key is kept.
+type converter struct {
+	Key         string
+	Pattern     string
+	Replacement string
+	ResultKey   string `toml:"result_key"`
+}
+
+var sampleConfig = `
+  ## The rules are applied in order, to the tags, the fields and the
+  ## measurement name of the metric.
+  [[processors.regex.tags]]
+    ## Tag to change
+    key = "resp_code"
+    ## Regular expression to match on a tag value
+    pattern = "^(\\d)\\d\\d$"
+    ## Pattern for constructing a new value (${1} represents first subgroup)
+    replacement = "${1}xx"
+
+  [[processors.regex.fields]]
+    key = "request"
+    ## All the power of the Go regular expressions available here
+    ## For example, named subgroups
+    pattern = "^/api(?P<method>/[\\w/]+)\\S*"
+    replacement = "${method}"
+    ## If result_key is present, a new field will be created
+    ## instead of changing existing field
+    result_key = "method"
+
+  ## Multiple conversions may be applied for one field sequentially
+  ## Let's extract one more value
+  [[processors.regex.fields]]
+    key = "request"
+    pattern = ".*category=(\\w+).*"
+    replacement = "${1}"
+    result_key = "search_category"
+
+  ## The measurement name can be changed as well, key and result_key are
+  ## not used.
+  # [[processors.regex.measurement]]
+  #   pattern = "^(\\w+)_(stats|metrics)$"
+  #   replacement = "${1}"
+`
+
+func (r *Regex) SampleConfig() string {
+	return sampleConfig
+}
+
+func (r *Regex) Description() string {
+	return "Transforms tag and field values with regex pattern"
+}
+
+func (r *Regex) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	for _, metric := range in {
+		for _, c := range r.Tags {
+			if value, ok := metric.Tags()[c.Key]; ok {
+				if key, value, ok := r.convert(c, value); ok {
+					metric.AddTag(key, value)
+				}
+			}
+		}
+
+		for _, c := range r.Fields {
+			if value, ok := metric.Fields()[c.Key]; ok {
+				// only string fields can be matched
+				if value, ok := value.(string); ok {
+					if key, value, ok := r.convert(c, value); ok {
+						metric.AddField(key, value)
+					}
+				}
+			}
+		}
+
+		for _, c := range r.Measurement {
+			if _, name, ok := r.convert(c, metric.Name()); ok && name != "" {
+				metric.SetName(name)
+			}
+		}
+	}
+
+	return in
+}
+
+// convert returns the key to write and the converted value, ok is false if
+// the pattern does not match the value.
+func (r *Regex) convert(c converter, src string) (string, string, bool) {
+	regex, ok := r.regex(c.Pattern)
+	if !ok || !regex.MatchString(src) {
+		return "", "", false
+	}
+
+	key := c.Key
+	if c.ResultKey != "" {
+		key = c.ResultKey
+	}
+	return key, regex.ReplaceAllString(src, c.Replacement), true
+}
+
+// regex returns the compiled pattern, invalid patterns are reported once and
+// never match.
+func (r *Regex) regex(pattern string) (*regexp.Regexp, bool) {
+	if r.regexCache == nil {
+		r.regexCache = make(map[string]*regexp.Regexp)
+	}
+	regex, ok := r.regexCache[pattern]
+	if !ok {
+		var err error
+		regex, err = regexp.Compile(pattern)
+		if err != nil {
+			log.Printf("E! [processors.regex] Invalid pattern %q: %s", pattern, err)
+		}
+		r.regexCache[pattern] = regex
+	}
+	return regex, regex != nil
+}
+
+func init() {
+	processors.Add("regex", func() telegraf.Processor {
+		return &Regex{}
+	})
+}
@@ -0,0 +1,269 @@
+package topk
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+var sampleConfig = `
+  ## How long the metrics are buffered before the top k is computed
+  # period = "10s"
+
+  ## How many top groups to return
+  # k = 10
+
+  ## Over which tags should the aggregation be done. Globs can be specified, in
+  ## which case any tag matching the glob will be aggregated over. If set to an
+  ## empty list, the metrics are only grouped by measurement name
+  # group_by = ['*']
+
+  ## Over which fields the top k are calculated, a group is kept if it is in
+  ## the top k of any of them
+  # fields = ["value"]
+
+  ## What aggregation to use. Options: sum, mean, min, max
+  # aggregation = "mean"
+
+  ## Instead of the top k largest metrics, return the bottom k lowest metrics
+  # bottomk = false
+
+  ## Name of the tag to add with the rank of the group, starting at 1
+  # add_rank_tag = ""
+
+  ## Fields for which a field with the aggregate of the group is added, named
+  ## after the field with the "_topk_aggregate" suffix
+  # add_aggregate_fields = []
+`
+
+type TopK struct {
+	K                  int
+	GroupBy            []string `toml:"group_by"`
+	Fields             []string
+	Aggregation        string
+	Bottomk            bool
+	AddRankTag         string   `toml:"add_rank_tag"`
+	AddAggregateFields []string `toml:"add_aggregate_fields"`
+
+	groupFilter filter.Filter
+	groups      map[string]*group
+}
+
+// group holds the metrics of a group during the window, and the aggregates
+// of their fields.
+type group struct {
+	metrics    []telegraf.Metric
+	aggregates map[string]*aggregate
+}
+
+type aggregate struct {
+	sum   float64
+	min   float64
+	max   float64
+	count int
+}
+
+func New() *TopK {
+	return &TopK{
+		K:           10,
+		GroupBy:     []string{"*"},
+		Fields:      []string{"value"},
+		Aggregation: "mean",
+	}
+}
+
+func (t *TopK) SampleConfig() string {
+	return sampleConfig
+}
+
+func (t *TopK) Description() string {
+	return "Keep only the top k groups of metrics over each period."
+}
+
+// Init checks the settings and compiles group_by, so that an invalid
+// configuration fails to load.
+func (t *TopK) Init() error {
+	if t.K < 1 {
+		return fmt.Errorf("k must be at least 1, got %d", t.K)
+	}
+	switch t.Aggregation {
+	case "sum", "mean", "min", "max":
+	default:
+		return fmt.Errorf("unknown aggregation %q", t.Aggregation)
+	}
+
+	var err error
+	t.groupFilter, err = filter.Compile(t.GroupBy)
+	if err != nil {
+		return fmt.Errorf("invalid group_by: %s", err)
+	}
+	t.groups = make(map[string]*group)
+	return nil
+}
+
+func (t *TopK) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	for _, m := range in {
+		key := t.groupKey(m)
+		g, ok := t.groups[key]
+		if !ok {
+			g = &group{aggregates: make(map[string]*aggregate)}
+			t.groups[key] = g
+		}
+		g.add(m, t.Fields)
+	}
+	return nil
+}
+
+// Flush returns the metrics of the top k groups of the window, the other
+// metrics are dropped.
+func (t *TopK) Flush() []telegraf.Metric {
+	if len(t.groups) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(t.groups))
+	for key := range t.groups {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	// rank the groups on each field, a group is kept if it is in the top k
+	// of any of them.
+	ranks := make(map[string]int)
+	for _, field := range t.Fields {
+		ranked := make([]string, 0, len(keys))
+		for _, key := range keys {
+			if _, ok := t.groups[key].aggregates[field]; ok {
+				ranked = append(ranked, key)
+			}
+		}
+		sort.SliceStable(ranked, func(i, j int) bool {
+			vi := t.groups[ranked[i]].aggregates[field].value(t.Aggregation)
+			vj := t.groups[ranked[j]].aggregates[field].value(t.Aggregation)
+			if t.Bottomk {
+				return vi < vj
+			}
+			return vi > vj
+		})
+
+		for i, key := range ranked {
+			if i >= t.K {
+				break
+			}
+			if rank, ok := ranks[key]; !ok || i+1 < rank {
+				ranks[key] = i + 1
+			}
+		}
+	}
+
+	sort.SliceStable(keys, func(i, j int) bool {
+		ri, oki := ranks[keys[i]]
+		rj, okj := ranks[keys[j]]
+		return oki && (!okj || ri < rj)
+	})
+
+	var out []telegraf.Metric
+	for _, key := range keys {
+		g := t.groups[key]
+		rank, ok := ranks[key]
+		if !ok {
+			for _, m := range g.metrics {
+				m.Drop()
+			}
+			continue
+		}
+
+		for _, m := range g.metrics {
+			if t.AddRankTag != "" {
+				m.AddTag(t.AddRankTag, strconv.Itoa(rank))
+			}
+			for _, field := range t.AddAggregateFields {
+				if agg, ok := g.aggregates[field]; ok {
+					m.AddField(field+"_topk_aggregate", agg.value(t.Aggregation))
+				}
+			}
+			out = append(out, m)
+		}
+	}
+
+	t.groups = make(map[string]*group)
+	return out
+}
+
+// groupKey returns the measurement name and the group_by tags of m.
+func (t *TopK) groupKey(m telegraf.Metric) string {
+	tags := m.Tags()
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		if t.groupFilter != nil && t.groupFilter.Match(k) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	buf.WriteString(m.Name())
+	for _, k := range keys {
+		buf.WriteString("," + k + "=" + tags[k])
+	}
+	return buf.String()
+}
+
+func (g *group) add(m telegraf.Metric, fields []string) {
+	g.metrics = append(g.metrics, m)
+	values := m.Fields()
+	for _, field := range fields {
+		v, ok := toFloat(values[field])
+		if !ok {
+			continue
+		}
+
+		agg, ok := g.aggregates[field]
+		if !ok {
+			agg = &aggregate{min: math.Inf(1), max: math.Inf(-1)}
+			g.aggregates[field] = agg
+		}
+		agg.sum += v
+		agg.min = math.Min(agg.min, v)
+		agg.max = math.Max(agg.max, v)
+		agg.count++
+	}
+}
+
+func (a *aggregate) value(aggregation string) float64 {
+	switch aggregation {
+	case "sum":
+		return a.sum
+	case "min":
+		return a.min
+	case "max":
+		return a.max
+	default:
+		return a.sum / float64(a.count)
+	}
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
+func init() {
+	processors.Add("topk", func() telegraf.Processor {
+		return New()
+	})
+}
@@ -0,0 +1,173 @@
+package topk
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMetric(name string, tags map[string]string, fields map[string]interface{}) telegraf.Metric {
+	m, err := metric.New(name, tags, fields, time.Unix(0, 0))
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// processes returns a metric per process, with two samples each.
+func processes() []telegraf.Metric {
+	var metrics []telegraf.Metric
+	for _, p := range []struct {
+		pid    string
+		cpu    []float64
+		memory int64
+	}{
+		{"1", []float64{10, 30}, 100},
+		{"2", []float64{50, 10}, 300},
+		{"3", []float64{5, 5}, 200},
+		{"4", []float64{90, 0}, 50},
+	} {
+		for _, cpu := range p.cpu {
+			metrics = append(metrics, newMetric("procstat",
+				map[string]string{"pid": p.pid, "host": "localhost"},
+				map[string]interface{}{"cpu": cpu, "memory": p.memory},
+			))
+		}
+	}
+	return metrics
+}
+
+// pids returns the pid tag of each metric, in order.
+func pids(metrics []telegraf.Metric) []string {
+	var out []string
+	for _, m := range metrics {
+		out = append(out, m.Tags()["pid"])
+	}
+	return out
+}
+
+func TestTopK(t *testing.T) {
+	tests := []struct {
+		name     string
+		topk     *TopK
+		expected []string
+	}{
+		{
+			name: "mean",
+			topk: &TopK{K: 2, GroupBy: []string{"pid"}, Fields: []string{"cpu"}, Aggregation: "mean"},
+			// 4: 45, 2: 30, 1: 20, 3: 5
+			expected: []string{"4", "4", "2", "2"},
+		},
+		{
+			name:     "sum",
+			topk:     &TopK{K: 1, GroupBy: []string{"pid"}, Fields: []string{"cpu"}, Aggregation: "sum"},
+			expected: []string{"4", "4"},
+		},
+		{
+			name:     "min",
+			topk:     &TopK{K: 1, GroupBy: []string{"pid"}, Fields: []string{"cpu"}, Aggregation: "min"},
+			expected: []string{"1", "1"},
+		},
+		{
+			name:     "max",
+			topk:     &TopK{K: 2, GroupBy: []string{"pid"}, Fields: []string{"cpu"}, Aggregation: "max"},
+			expected: []string{"4", "4", "2", "2"},
+		},
+		{
+			name:     "bottomk",
+			topk:     &TopK{K: 1, GroupBy: []string{"pid"}, Fields: []string{"cpu"}, Aggregation: "mean", Bottomk: true},
+			expected: []string{"3", "3"},
+		},
+		{
+			name: "multiple fields",
+			topk: &TopK{K: 1, GroupBy: []string{"pid"}, Fields: []string{"cpu", "memory"}, Aggregation: "mean"},
+			// top cpu is 4 and top memory is 2, both ranked first
+			expected: []string{"2", "2", "4", "4"},
+		},
+		{
+			name:     "k larger than groups",
+			topk:     &TopK{K: 10, GroupBy: []string{"p*"}, Fields: []string{"memory"}, Aggregation: "max"},
+			expected: []string{"2", "2", "3", "3", "1", "1", "4", "4"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, tt.topk.Init())
+			assert.Len(t, tt.topk.Apply(processes()...), 0)
+			assert.Equal(t, tt.expected, pids(tt.topk.Flush()))
+
+			// the window is reset after each flush
+			assert.Len(t, tt.topk.Flush(), 0)
+		})
+	}
+}
+
+func TestTopKGroupByName(t *testing.T) {
+	topk := New()
+	topk.K = 2
+	topk.GroupBy = nil
+	topk.Fields = []string{"cpu"}
+	require.NoError(t, topk.Init())
+
+	in := append(processes(),
+		newMetric("docker", map[string]string{"pid": "5"}, map[string]interface{}{"cpu": 20.0}))
+	topk.Apply(in...)
+	out := topk.Flush()
+
+	// all the processes are in the same group, which comes first.
+	require.Len(t, out, 9)
+	assert.Equal(t, "procstat", out[0].Name())
+	assert.Equal(t, "docker", out[8].Name())
+}
+
+func TestTopKAddedTagsAndFields(t *testing.T) {
+	topk := &TopK{
+		K:                  2,
+		GroupBy:            []string{"pid"},
+		Fields:             []string{"cpu"},
+		Aggregation:        "mean",
+		AddRankTag:         "rank",
+		AddAggregateFields: []string{"cpu", "missing"},
+	}
+	require.NoError(t, topk.Init())
+	topk.Apply(processes()...)
+	out := topk.Flush()
+
+	require.Len(t, out, 4)
+	assert.Equal(t, map[string]string{"pid": "4", "host": "localhost", "rank": "1"}, out[0].Tags())
+	assert.Equal(t, 45.0, out[0].Fields()["cpu_topk_aggregate"])
+	assert.Equal(t, map[string]string{"pid": "2", "host": "localhost", "rank": "2"}, out[2].Tags())
+	assert.Equal(t, 30.0, out[2].Fields()["cpu_topk_aggregate"])
+	assert.NotContains(t, out[2].Fields(), "missing_topk_aggregate")
+}
+
+func TestTopKDropsMetricsWithoutFields(t *testing.T) {
+	var delivered []bool
+	m, _ := metric.WithTracking(
+		newMetric("procstat", map[string]string{"pid": "5"}, map[string]interface{}{"threads": int64(2)}),
+		func(info telegraf.DeliveryInfo) { delivered = append(delivered, info.Delivered()) })
+
+	topk := New()
+	topk.Fields = []string{"cpu"}
+	require.NoError(t, topk.Init())
+	topk.Apply(append(processes(), m)...)
+	out := topk.Flush()
+
+	assert.Len(t, out, 8)
+	assert.Equal(t, []bool{true}, delivered)
+}
+
+func TestTopKInvalidConfig(t *testing.T) {
+	for _, topk := range []*TopK{
+		{K: 0, Aggregation: "mean"},
+		{K: 1, Aggregation: "median"},
+		{K: 1, Aggregation: "mean", GroupBy: []string{"[a"}},
+	} {
+		assert.Error(t, topk.Init())
+	}
+}
@@ -0,0 +1,52 @@
+package directory
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/secretstores"
+)
+
+type Directory struct {
+	Path string
+}
+
+var sampleConfig = `
+  ## Unique name of the store, secrets are referenced as "@{<id>:<key>}".
+  id = "secrets"
+
+  ## Directory holding one file per secret, named after its key. This is the
+  ## layout of Docker secrets and mounted Kubernetes secrets.
+  path = "/run/secrets"
+`
+
+func (d *Directory) SampleConfig() string {
+	return sampleConfig
+}
+
+func (d *Directory) Description() string {
+	return "Read secrets from the files of a directory."
+}
+
+func (d *Directory) Get(key string) (string, error) {
+	if key == "" || key == "." || key == ".." ||
+		strings.ContainsAny(key, `/\`) {
+		return "", fmt.Errorf("invalid secret key %q", key)
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(d.Path, key))
+	if err != nil {
+		return "", err
+	}
+	// editors and "echo" add a trailing newline that isn't part of the secret
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+func init() {
+	secretstores.Add("directory", func() telegraf.SecretStore {
+		return &Directory{}
+	})
+}
@@ -0,0 +1,29 @@
+package directory
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "secrets")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "db_password"),
+		[]byte("hunter2\n"), 0600))
+
+	d := &Directory{Path: dir}
+	value, err := d.Get("db_password")
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", value)
+
+	_, err = d.Get("missing")
+	assert.Error(t, err)
+	_, err = d.Get("../db_password")
+	assert.Error(t, err)
+}
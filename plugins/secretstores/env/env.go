@@ -0,0 +1,44 @@
+package env
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/secretstores"
+)
+
+type Env struct {
+	Prefix string
+}
+
+var sampleConfig = `
+  ## Unique name of the store, secrets are referenced as "@{<id>:<key>}".
+  id = "env"
+
+  ## Prefix of the environment variables holding the secrets, the secret
+  ## "@{env:MYSQL_PASSWORD}" is read from $TELEGRAF_SECRET_MYSQL_PASSWORD.
+  prefix = "TELEGRAF_SECRET_"
+`
+
+func (e *Env) SampleConfig() string {
+	return sampleConfig
+}
+
+func (e *Env) Description() string {
+	return "Read secrets from environment variables."
+}
+
+func (e *Env) Get(key string) (string, error) {
+	value, ok := os.LookupEnv(e.Prefix + key)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", e.Prefix+key)
+	}
+	return value, nil
+}
+
+func init() {
+	secretstores.Add("env", func() telegraf.SecretStore {
+		return &Env{}
+	})
+}
@@ -0,0 +1,22 @@
+package env
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGet(t *testing.T) {
+	os.Setenv("TELEGRAF_TEST_SECRET_PASSWORD", "hunter2")
+	defer os.Unsetenv("TELEGRAF_TEST_SECRET_PASSWORD")
+
+	e := &Env{Prefix: "TELEGRAF_TEST_SECRET_"}
+	value, err := e.Get("PASSWORD")
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", value)
+
+	_, err = e.Get("TOKEN")
+	assert.Error(t, err)
+}
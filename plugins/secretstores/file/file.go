@@ -0,0 +1,154 @@
+package file
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/secretstores"
+)
+
+type File struct {
+	Path    string
+	KeyFile string `toml:"key_file"`
+
+	secrets map[string]string
+}
+
+var sampleConfig = `
+  ## Unique name of the store, secrets are referenced as "@{<id>:<key>}".
+  id = "vault"
+
+  ## Encrypted file holding the secrets, created with
+  ##   telegraf encrypt-secrets <key_file> <secrets.json> > <path>
+  path = "/etc/telegraf/secrets.enc"
+
+  ## File holding the hex encoded 256-bit key the secrets are encrypted
+  ## with, it can be generated with "openssl rand -hex 32".
+  key_file = "/etc/telegraf/secrets.key"
+`
+
+func (f *File) SampleConfig() string {
+	return sampleConfig
+}
+
+func (f *File) Description() string {
+	return "Read secrets from an AES-256 encrypted file."
+}
+
+func (f *File) Get(key string) (string, error) {
+	if f.secrets == nil {
+		if err := f.load(); err != nil {
+			return "", err
+		}
+	}
+
+	value, ok := f.secrets[key]
+	if !ok {
+		return "", fmt.Errorf("secret %q not found in %s", key, f.Path)
+	}
+	return value, nil
+}
+
+func (f *File) load() error {
+	key, err := ReadKey(f.KeyFile)
+	if err != nil {
+		return err
+	}
+	data, err := ioutil.ReadFile(f.Path)
+	if err != nil {
+		return err
+	}
+	secrets, err := Decrypt(key, data)
+	if err != nil {
+		return fmt.Errorf("unable to decrypt %s, %s", f.Path, err)
+	}
+	f.secrets = secrets
+	return nil
+}
+
+// ReadKey reads a hex encoded 256-bit key from path.
+func ReadKey(path string) ([]byte, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("%s does not hold a hex encoded 256-bit key", path)
+	}
+	return key, nil
+}
+
+// Encrypt encrypts the secrets with AES-256-GCM. The result is the base64
+// encoding of the nonce followed by the encrypted JSON object of the secrets.
+func Encrypt(key []byte, secrets map[string]string) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := json.Marshal(secrets)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	sealed := gcm.Seal(nonce, nonce, plaintext, nil)
+
+	out := make([]byte, base64.StdEncoding.EncodedLen(len(sealed)))
+	base64.StdEncoding.Encode(out, sealed)
+	return append(out, '\n'), nil
+}
+
+// Decrypt returns the secrets encrypted by Encrypt.
+func Decrypt(key []byte, data []byte) (map[string]string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("file is too short")
+	}
+
+	nonce := sealed[:gcm.NonceSize()]
+	plaintext, err := gcm.Open(nil, nonce, sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, err
+	}
+
+	secrets := make(map[string]string)
+	if err := json.Unmarshal(plaintext, &secrets); err != nil {
+		return nil, err
+	}
+	return secrets, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func init() {
+	secretstores.Add("file", func() telegraf.SecretStore {
+		return &File{}
+	})
+}
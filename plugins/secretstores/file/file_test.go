@@ -0,0 +1,64 @@
+package file
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testKey = "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"
+
+func TestEncryptDecrypt(t *testing.T) {
+	key, _ := hex.DecodeString(testKey)
+	data, err := Encrypt(key, map[string]string{"password": "hunter2"})
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "hunter2")
+
+	secrets, err := Decrypt(key, data)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"password": "hunter2"}, secrets)
+
+	key[0] = 0xff
+	_, err = Decrypt(key, data)
+	assert.Error(t, err)
+}
+
+func TestGet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "secrets")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	keyFile := filepath.Join(dir, "secrets.key")
+	require.NoError(t, ioutil.WriteFile(keyFile, []byte(testKey+"\n"), 0600))
+	key, err := ReadKey(keyFile)
+	require.NoError(t, err)
+
+	data, err := Encrypt(key, map[string]string{"password": "hunter2"})
+	require.NoError(t, err)
+	path := filepath.Join(dir, "secrets.enc")
+	require.NoError(t, ioutil.WriteFile(path, data, 0600))
+
+	f := &File{Path: path, KeyFile: keyFile}
+	value, err := f.Get("password")
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", value)
+
+	_, err = f.Get("token")
+	assert.Error(t, err)
+}
+
+func TestReadKeyInvalid(t *testing.T) {
+	dir, err := ioutil.TempDir("", "secrets")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	keyFile := filepath.Join(dir, "secrets.key")
+	require.NoError(t, ioutil.WriteFile(keyFile, []byte("0001"), 0600))
+	_, err = ReadKey(keyFile)
+	assert.Error(t, err)
+}
@@ -0,0 +1,264 @@
+package prometheus
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+var (
+	invalidNameCharRE = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+	labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+)
+
+// PrometheusSerializer serializes metrics in the Prometheus text exposition
+// format, the same way the prometheus_client output exposes them: each
+// numeric field is a sample of the family <measurement>_<field>, string
+// fields are labels and histograms and summaries are a single family.
+type PrometheusSerializer struct {
+	// ExportTimestamp adds the timestamp of the metrics to the samples.
+	ExportTimestamp bool
+}
+
+// family is a Prometheus metric family, its samples are kept by label set,
+// and by timestamp when the timestamps are exported.
+type family struct {
+	valueType telegraf.ValueType
+	samples   map[string]*sample
+}
+
+type sample struct {
+	labels    map[string]string
+	value     float64
+	count     uint64
+	sum       float64
+	buckets   map[float64]uint64
+	quantiles map[float64]float64
+	timestamp int64
+}
+
+// Serialize serializes a metric with the HELP and TYPE lines of its
+// families.
+func (s *PrometheusSerializer) Serialize(m telegraf.Metric) ([]byte, error) {
+	return s.SerializeBatch([]telegraf.Metric{m})
+}
+
+// SerializeBatch serializes the metrics grouped by family, with a single
+// HELP and TYPE line for each family. The last sample of a series is kept,
+// unless the timestamps are exported, in which case the samples of a series
+// are all kept in time order.
+func (s *PrometheusSerializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	families := make(map[string]*family)
+	for _, m := range metrics {
+		s.add(families, m)
+	}
+
+	names := make([]string, 0, len(families))
+	for name := range families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	for _, name := range names {
+		fam := families[name]
+		fmt.Fprintf(&buf, "# HELP %s Telegraf collected metric\n", name)
+		fmt.Fprintf(&buf, "# TYPE %s %s\n", name, typeName(fam.valueType))
+
+		keys := make([]string, 0, len(fam.samples))
+		for key := range fam.samples {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			s.writeSample(&buf, name, fam.valueType, fam.samples[key])
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *PrometheusSerializer) add(families map[string]*family, m telegraf.Metric) {
+	labels := make(map[string]string)
+	for k, v := range m.Tags() {
+		labels[sanitize(k)] = v
+	}
+	timestamp := m.UnixNano() / int64(1000000)
+
+	if m.Type() == telegraf.Histogram || m.Type() == telegraf.Summary {
+		smp := &sample{labels: labels, timestamp: timestamp}
+		if m.Type() == telegraf.Histogram {
+			smp.count, smp.sum, smp.buckets = metric.Histogram(m)
+		} else {
+			smp.count, smp.sum, smp.quantiles = metric.Summary(m)
+		}
+		s.addSample(families, sanitize(m.Name()), m.Type(), smp)
+		return
+	}
+
+	// Prometheus doesn't have a string value type, so the string fields are
+	// converted to labels.
+	for k, v := range m.Fields() {
+		if v, ok := v.(string); ok {
+			labels[sanitize(k)] = v
+		}
+	}
+
+	for k, v := range m.Fields() {
+		var value float64
+		switch v := v.(type) {
+		case int64:
+			value = float64(v)
+		case uint64:
+			value = float64(v)
+		case float64:
+			value = v
+		default:
+			continue
+		}
+
+		// the counter, gauge and value fields are named after the
+		// measurement, for passthrough from the prometheus input.
+		name := sanitize(m.Name() + "_" + k)
+		if k == "value" ||
+			(k == "counter" && m.Type() == telegraf.Counter) ||
+			(k == "gauge" && m.Type() == telegraf.Gauge) {
+			name = sanitize(m.Name())
+		}
+
+		s.addSample(families, name, m.Type(), &sample{
+			labels:    labels,
+			value:     value,
+			timestamp: timestamp,
+		})
+	}
+}
+
+// addSample adds a sample to its family. The samples of a type other than
+// the type of their family are dropped, except that untyped families take
+// the type of their first counter or gauge sample.
+func (s *PrometheusSerializer) addSample(families map[string]*family, name string, valueType telegraf.ValueType, smp *sample) {
+	fam, ok := families[name]
+	if !ok {
+		fam = &family{valueType: valueType, samples: make(map[string]*sample)}
+		families[name] = fam
+	} else if fam.valueType != valueType {
+		switch {
+		case fam.valueType == telegraf.Untyped &&
+			(valueType == telegraf.Counter || valueType == telegraf.Gauge):
+			fam.valueType = valueType
+		case valueType != telegraf.Untyped:
+			return
+		case fam.valueType == telegraf.Histogram || fam.valueType == telegraf.Summary:
+			return
+		}
+	}
+	key := labelString(smp.labels, "", "")
+	if s.ExportTimestamp {
+		// the timestamps are padded so that the samples sort in time order
+		key += fmt.Sprintf(" %020d", smp.timestamp)
+	}
+	fam.samples[key] = smp
+}
+
+func (s *PrometheusSerializer) writeSample(buf *bytes.Buffer, name string, valueType telegraf.ValueType, smp *sample) {
+	var timestamp string
+	if s.ExportTimestamp {
+		timestamp = " " + strconv.FormatInt(smp.timestamp, 10)
+	}
+
+	switch valueType {
+	case telegraf.Histogram:
+		bounds := make([]float64, 0, len(smp.buckets)+1)
+		for bound := range smp.buckets {
+			bounds = append(bounds, bound)
+		}
+		if _, ok := smp.buckets[math.Inf(1)]; !ok {
+			bounds = append(bounds, math.Inf(1))
+		}
+		sort.Float64s(bounds)
+		for _, bound := range bounds {
+			count, ok := smp.buckets[bound]
+			if !ok {
+				count = smp.count
+			}
+			fmt.Fprintf(buf, "%s_bucket%s %d%s\n", name,
+				labelString(smp.labels, "le", formatFloat(bound)), count, timestamp)
+		}
+	case telegraf.Summary:
+		quantiles := make([]float64, 0, len(smp.quantiles))
+		for q := range smp.quantiles {
+			quantiles = append(quantiles, q)
+		}
+		sort.Float64s(quantiles)
+		for _, q := range quantiles {
+			fmt.Fprintf(buf, "%s%s %s%s\n", name,
+				labelString(smp.labels, "quantile", formatFloat(q)),
+				formatFloat(smp.quantiles[q]), timestamp)
+		}
+	default:
+		fmt.Fprintf(buf, "%s%s %s%s\n", name,
+			labelString(smp.labels, "", ""), formatFloat(smp.value), timestamp)
+		return
+	}
+
+	labels := labelString(smp.labels, "", "")
+	fmt.Fprintf(buf, "%s_sum%s %s%s\n", name, labels, formatFloat(smp.sum), timestamp)
+	fmt.Fprintf(buf, "%s_count%s %d%s\n", name, labels, smp.count, timestamp)
+}
+
+// labelString formats the labels sorted by name, with an optional extra
+// label such as the le label of the buckets.
+func labelString(labels map[string]string, extraName, extraValue string) string {
+	pairs := make([]string, 0, len(labels)+1)
+	for k, v := range labels {
+		if k == extraName {
+			continue
+		}
+		pairs = append(pairs, k+`="`+labelValueEscaper.Replace(v)+`"`)
+	}
+	sort.Strings(pairs)
+	if extraName != "" {
+		pairs = append(pairs, extraName+`="`+extraValue+`"`)
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// sanitize replaces the characters that are not valid in metric and label
+// names, names cannot start with a digit.
+func sanitize(name string) string {
+	name = invalidNameCharRE.ReplaceAllString(name, "_")
+	if name != "" && name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+	return name
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func typeName(valueType telegraf.ValueType) string {
+	switch valueType {
+	case telegraf.Counter:
+		return "counter"
+	case telegraf.Gauge:
+		return "gauge"
+	case telegraf.Histogram:
+		return "histogram"
+	case telegraf.Summary:
+		return "summary"
+	default:
+		return "untyped"
+	}
+}
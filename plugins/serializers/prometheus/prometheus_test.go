@@ -0,0 +1,142 @@
+package prometheus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+var epoch = time.Unix(1514764800, 0)
+
+func newMetric(
+	name string,
+	tags map[string]string,
+	fields map[string]interface{},
+	tp ...telegraf.ValueType,
+) telegraf.Metric {
+	return newMetricAt(name, tags, fields, epoch, tp...)
+}
+
+func newMetricAt(
+	name string,
+	tags map[string]string,
+	fields map[string]interface{},
+	tm time.Time,
+	tp ...telegraf.ValueType,
+) telegraf.Metric {
+	m, err := metric.New(name, tags, fields, tm, tp...)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+func TestSerializeFields(t *testing.T) {
+	s := PrometheusSerializer{}
+	m := newMetric("cpu 0",
+		map[string]string{"host": "server01", "cpu.id": "0"},
+		map[string]interface{}{
+			"usage_idle": 91.5,
+			"uptime":     int64(42),
+			"state":      `running "ok"`,
+			"valid":      true,
+		},
+	)
+
+	buf, err := s.Serialize(m)
+	require.NoError(t, err)
+	assert.Equal(t, `# HELP cpu_0_uptime Telegraf collected metric
+# TYPE cpu_0_uptime untyped
+cpu_0_uptime{cpu_id="0",host="server01",state="running \"ok\""} 42
+# HELP cpu_0_usage_idle Telegraf collected metric
+# TYPE cpu_0_usage_idle untyped
+cpu_0_usage_idle{cpu_id="0",host="server01",state="running \"ok\""} 91.5
+`, string(buf))
+}
+
+func TestSerializeBatch(t *testing.T) {
+	s := PrometheusSerializer{ExportTimestamp: true}
+	metrics := []telegraf.Metric{
+		newMetric("requests", map[string]string{"code": "200"},
+			map[string]interface{}{"counter": 10.0}, telegraf.Counter),
+		newMetric("requests", map[string]string{"code": "500"},
+			map[string]interface{}{"counter": 1.0}, telegraf.Counter),
+		// the samples of a series are all kept with their timestamps
+		newMetricAt("requests", map[string]string{"code": "200"},
+			map[string]interface{}{"counter": 12.0}, epoch.Add(time.Minute), telegraf.Counter),
+		// the family is a counter, gauges are dropped
+		newMetric("requests", map[string]string{"code": "404"},
+			map[string]interface{}{"value": 3.0}, telegraf.Gauge),
+		newMetric("1temp", nil, map[string]interface{}{"value": 21.5}),
+	}
+
+	buf, err := s.SerializeBatch(metrics)
+	require.NoError(t, err)
+	assert.Equal(t, `# HELP _1temp Telegraf collected metric
+# TYPE _1temp untyped
+_1temp 21.5 1514764800000
+# HELP requests Telegraf collected metric
+# TYPE requests counter
+requests{code="200"} 10 1514764800000
+requests{code="200"} 12 1514764860000
+requests{code="500"} 1 1514764800000
+`, string(buf))
+
+	// without timestamps the last sample of a series is kept
+	s = PrometheusSerializer{}
+	buf, err = s.SerializeBatch(metrics[:3])
+	require.NoError(t, err)
+	assert.Equal(t, `# HELP requests Telegraf collected metric
+# TYPE requests counter
+requests{code="200"} 12
+requests{code="500"} 1
+`, string(buf))
+}
+
+func TestSerializeHistogram(t *testing.T) {
+	s := PrometheusSerializer{}
+	m := newMetric("latency", map[string]string{"path": "/"},
+		map[string]interface{}{
+			"0.1":   10.0,
+			"0.5":   int64(15),
+			"count": 20.0,
+			"sum":   4.5,
+		}, telegraf.Histogram)
+
+	buf, err := s.Serialize(m)
+	require.NoError(t, err)
+	assert.Equal(t, `# HELP latency Telegraf collected metric
+# TYPE latency histogram
+latency_bucket{path="/",le="0.1"} 10
+latency_bucket{path="/",le="0.5"} 15
+latency_bucket{path="/",le="+Inf"} 20
+latency_sum{path="/"} 4.5
+latency_count{path="/"} 20
+`, string(buf))
+}
+
+func TestSerializeSummary(t *testing.T) {
+	s := PrometheusSerializer{}
+	m := newMetric("latency", nil,
+		map[string]interface{}{
+			"0.99":  0.4,
+			"0.5":   0.1,
+			"count": int64(20),
+			"sum":   4.5,
+		}, telegraf.Summary)
+
+	buf, err := s.Serialize(m)
+	require.NoError(t, err)
+	assert.Equal(t, `# HELP latency Telegraf collected metric
+# TYPE latency summary
+latency{quantile="0.5"} 0.1
+latency{quantile="0.99"} 0.4
+latency_sum 4.5
+latency_count 20
+`, string(buf))
+}
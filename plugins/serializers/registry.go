@@ -9,6 +9,7 @@ import (
 	"github.com/influxdata/telegraf/plugins/serializers/graphite"
 	"github.com/influxdata/telegraf/plugins/serializers/influx"
 	"github.com/influxdata/telegraf/plugins/serializers/json"
+	"github.com/influxdata/telegraf/plugins/serializers/prometheus"
 )
 
 // SerializerOutput is an interface for output plugins that are able to
@@ -27,10 +28,19 @@ type Serializer interface {
 	Serialize(metric telegraf.Metric) ([]byte, error)
 }
 
+// BatchSerializer is implemented by the serializers of the data formats in
+// which a batch of metrics is not the concatenation of the serialized
+// metrics, outputs writing batches at once should use it when available.
+type BatchSerializer interface {
+	// SerializeBatch takes a batch of telegraf metrics and turns it into a
+	// byte buffer.
+	SerializeBatch(metrics []telegraf.Metric) ([]byte, error)
+}
+
 // Config is a struct that covers the data types needed for all serializer types,
 // and can be used to instantiate _any_ of the serializers.
 type Config struct {
-	// Dataformat can be one of: influx, graphite, or json
+	// Dataformat can be one of: influx, graphite, json, or prometheus
 	DataFormat string
 
 	// Prefix to add to all measurements, only supports Graphite
@@ -42,6 +52,9 @@ type Config struct {
 
 	// Timestamp units to use for JSON formatted output
 	TimestampUnits time.Duration
+
+	// Include the timestamps in Prometheus formatted output
+	PrometheusExportTimestamp bool
 }
 
 // NewSerializer a Serializer interface based on the given config.
@@ -55,6 +68,8 @@ func NewSerializer(config *Config) (Serializer, error) {
 		serializer, err = NewGraphiteSerializer(config.Prefix, config.Template)
 	case "json":
 		serializer, err = NewJsonSerializer(config.TimestampUnits)
+	case "prometheus":
+		serializer, err = NewPrometheusSerializer(config.PrometheusExportTimestamp)
 	default:
 		err = fmt.Errorf("Invalid data format: %s", config.DataFormat)
 	}
@@ -75,3 +90,7 @@ func NewGraphiteSerializer(prefix, template string) (Serializer, error) {
 		Template: template,
 	}, nil
 }
+
+func NewPrometheusSerializer(exportTimestamp bool) (Serializer, error) {
+	return &prometheus.PrometheusSerializer{ExportTimestamp: exportTimestamp}, nil
+}
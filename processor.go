@@ -10,3 +10,13 @@ type Processor interface {
 	// Apply the filter to the given metric
 	Apply(in ...Metric) []Metric
 }
+
+// WindowedProcessor is a Processor that can hold back the metrics it is
+// given, and release them at the end of each window of time. The length of
+// the window is the period of the processor.
+type WindowedProcessor interface {
+	Processor
+
+	// Flush returns the metrics held back during the window that ended.
+	Flush() []Metric
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -20,6 +21,16 @@ type Metric struct {
 	Tags        map[string]string
 	Fields      map[string]interface{}
 	Time        time.Time
+	Type        telegraf.ValueType
+}
+
+// Equal reports whether the metrics are the same, including their type.
+func (p Metric) Equal(o Metric) bool {
+	return p.Measurement == o.Measurement &&
+		p.Type == o.Type &&
+		reflect.DeepEqual(p.Tags, o.Tags) &&
+		reflect.DeepEqual(p.Fields, o.Fields) &&
+		p.Time.Equal(o.Time)
 }
 
 func (p *Metric) String() string {
@@ -31,11 +42,12 @@ type Accumulator struct {
 	sync.Mutex
 	*sync.Cond
 
-	Metrics  []*Metric
-	nMetrics uint64
-	Discard  bool
-	Errors   []error
-	debug    bool
+	Metrics   []*Metric
+	nMetrics  uint64
+	Discard   bool
+	Errors    []error
+	debug     bool
+	delivered chan telegraf.DeliveryInfo
 }
 
 func (a *Accumulator) NMetrics() uint64 {
@@ -55,6 +67,16 @@ func (a *Accumulator) AddFields(
 	fields map[string]interface{},
 	tags map[string]string,
 	timestamp ...time.Time,
+) {
+	a.addFields(measurement, fields, tags, telegraf.Untyped, timestamp...)
+}
+
+func (a *Accumulator) addFields(
+	measurement string,
+	fields map[string]interface{},
+	tags map[string]string,
+	tp telegraf.ValueType,
+	timestamp ...time.Time,
 ) {
 	a.Lock()
 	defer a.Unlock()
@@ -93,6 +115,7 @@ func (a *Accumulator) AddFields(
 		Fields:      fields,
 		Tags:        tags,
 		Time:        t,
+		Type:        tp,
 	}
 
 	a.Metrics = append(a.Metrics, p)
@@ -104,7 +127,7 @@ func (a *Accumulator) AddCounter(
 	tags map[string]string,
 	timestamp ...time.Time,
 ) {
-	a.AddFields(measurement, fields, tags, timestamp...)
+	a.addFields(measurement, fields, tags, telegraf.Counter, timestamp...)
 }
 
 func (a *Accumulator) AddGauge(
@@ -113,15 +136,58 @@ func (a *Accumulator) AddGauge(
 	tags map[string]string,
 	timestamp ...time.Time,
 ) {
-	a.AddFields(measurement, fields, tags, timestamp...)
+	a.addFields(measurement, fields, tags, telegraf.Gauge, timestamp...)
+}
+
+func (a *Accumulator) AddSummary(
+	measurement string,
+	fields map[string]interface{},
+	tags map[string]string,
+	timestamp ...time.Time,
+) {
+	a.addFields(measurement, fields, tags, telegraf.Summary, timestamp...)
+}
+
+func (a *Accumulator) AddHistogram(
+	measurement string,
+	fields map[string]interface{},
+	tags map[string]string,
+	timestamp ...time.Time,
+) {
+	a.addFields(measurement, fields, tags, telegraf.Histogram, timestamp...)
 }
 
 func (a *Accumulator) AddMetrics(metrics []telegraf.Metric) {
 	for _, m := range metrics {
-		a.AddFields(m.Name(), m.Fields(), m.Tags(), m.Time())
+		a.addFields(m.Name(), m.Fields(), m.Tags(), m.Type(), m.Time())
 	}
 }
 
+func (a *Accumulator) AddMetric(m telegraf.Metric) {
+	a.addFields(m.Name(), m.Fields(), m.Tags(), m.Type(), m.Time())
+}
+
+// WithTracking returns the Accumulator itself, every tracked metric group is
+// reported as delivered as soon as it has been added.
+func (a *Accumulator) WithTracking(maxTracked int) telegraf.TrackingAccumulator {
+	a.Lock()
+	defer a.Unlock()
+	a.delivered = make(chan telegraf.DeliveryInfo, maxTracked)
+	return a
+}
+
+func (a *Accumulator) AddTrackingMetricGroup(group []telegraf.Metric) telegraf.TrackingID {
+	a.AddMetrics(group)
+	_, id := metric.WithGroupTracking(nil, func(info telegraf.DeliveryInfo) {
+		a.delivered <- info
+	})
+	return id
+}
+
+func (a *Accumulator) Delivered() <-chan telegraf.DeliveryInfo {
+	return a.delivered
+}
+
 // AddError appends the given error to Accumulator.Errors.
 func (a *Accumulator) AddError(err error) {
 	if err == nil {